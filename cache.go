@@ -0,0 +1,321 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCacheTTL matches the 24 h after which Doc2X expires parse tasks and download URLs.
+const DefaultCacheTTL = 24 * time.Hour
+
+const cacheParseFile = "parse.json"
+
+// FileCache is a content-addressed cache of parse results and converted files on local disk, keyed by
+// the SHA-256 of the PDF plus, for converted files, the conversion options. Each PDF gets a directory
+// <Dir>/<sha256> holding parse.json and one <format>-<options hash> pair of metadata and file per
+// conversion. Entries older than TTL are misses: their task UID has expired on the server, so a
+// conversion not cached yet could no longer be requested for it.
+type FileCache struct {
+	Dir string
+	TTL time.Duration // Defaults to DefaultCacheTTL
+}
+
+// CachedParse is a cached parse result.
+type CachedParse struct {
+	Hash      string          `json:"sha256"`
+	Name      string          `json:"name"` // Input name the result was first stored under
+	UID       string          `json:"uid"`
+	TraceID   string          `json:"trace_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	Status    *StatusResponse `json:"status"`
+}
+
+// CachedFile describes a cached converted file.
+type CachedFile struct {
+	Request   ConvertRequest `json:"request"`
+	URL       string         `json:"url"` // Download URL the file was fetched from
+	TraceID   string         `json:"trace_id,omitempty"`
+	File      string         `json:"file"` // File name inside the entry directory
+	Size      int64          `json:"size"`
+	CreatedAt time.Time      `json:"created_at"`
+	Path      string         `json:"-"` // Absolute location of the file, set on lookup
+}
+
+// CacheEntry summarizes the cache directory of one PDF for listing and pruning.
+type CacheEntry struct {
+	Hash      string
+	Parse     *CachedParse // Nil when only converted files are left
+	Files     []CachedFile
+	Size      int64 // Bytes used by the entry
+	CreatedAt time.Time
+	Expired   bool
+}
+
+type contentHashKey struct{}
+
+// ContextWithContentHash tells a Pipeline the SHA-256 of the PDF it processes, so Continue can store
+// results in its cache when the content was not hashed by Process itself.
+func ContextWithContentHash(ctx context.Context, hash string) context.Context {
+	return context.WithValue(ctx, contentHashKey{}, hash)
+}
+
+func contentHashFrom(ctx context.Context) string {
+	hash, _ := ctx.Value(contentHashKey{}).(string)
+	return hash
+}
+
+// ContentHash returns the hex SHA-256 of everything read from r, the key used by FileCache.
+func ContentHash(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", fmt.Errorf("hash content: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (c *FileCache) ttl() time.Duration {
+	if c.TTL <= 0 {
+		return DefaultCacheTTL
+	}
+	return c.TTL
+}
+
+func (c *FileCache) fresh(createdAt time.Time) bool {
+	return time.Since(createdAt) < c.ttl()
+}
+
+func (c *FileCache) entryDir(hash string) string {
+	return filepath.Join(c.Dir, hash)
+}
+
+// LookupParse returns the cached parse result of the PDF with the given hash.
+func (c *FileCache) LookupParse(hash string) (*CachedParse, bool, error) {
+	var parse CachedParse
+	if ok, err := readCacheJSON(filepath.Join(c.entryDir(hash), cacheParseFile), &parse); !ok || err != nil {
+		return nil, false, err
+	}
+	if !c.fresh(parse.CreatedAt) || parse.Status == nil {
+		return nil, false, nil
+	}
+	parse.Status.TraceID = parse.TraceID
+	return &parse, true, nil
+}
+
+// StoreParse caches a successful parse result.
+func (c *FileCache) StoreParse(hash, name, uid string, status *StatusResponse) error {
+	return c.writeJSON(hash, cacheParseFile, CachedParse{
+		Hash:      hash,
+		Name:      name,
+		UID:       uid,
+		TraceID:   status.TraceID,
+		CreatedAt: time.Now().UTC(),
+		Status:    status,
+	})
+}
+
+// LookupFile returns the cached file converted from the PDF with the given hash using req's options.
+// req.UID is ignored.
+func (c *FileCache) LookupFile(hash string, req ConvertRequest) (*CachedFile, bool, error) {
+	var file CachedFile
+	if ok, err := readCacheJSON(filepath.Join(c.entryDir(hash), convertCacheKey(req)+".json"), &file); !ok || err != nil {
+		return nil, false, err
+	}
+	if !c.fresh(file.CreatedAt) {
+		return nil, false, nil
+	}
+
+	file.Path = filepath.Join(c.entryDir(hash), file.File)
+	if _, err := os.Stat(file.Path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("stat cached file: %w", err)
+	}
+	return &file, true, nil
+}
+
+// StoreFile caches a converted file; write fills it. Nothing is stored when write fails.
+func (c *FileCache) StoreFile(hash string, out ConvertOutput, write func(io.Writer) error) error {
+	key := convertCacheKey(out.Request)
+	name := key + urlExt(out.URL)
+
+	var size int64
+	if err := c.writeFile(hash, name, func(w io.Writer) error {
+		cw := &countingWriter{w: w}
+		err := write(cw)
+		size = cw.n
+		return err
+	}); err != nil {
+		return err
+	}
+
+	req := out.Request
+	req.UID = ""
+	return c.writeJSON(hash, key+".json", CachedFile{
+		Request:   req,
+		URL:       out.URL,
+		TraceID:   out.TraceID,
+		File:      name,
+		Size:      size,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// Entries lists the cache, oldest first.
+func (c *FileCache) Entries() ([]CacheEntry, error) {
+	dirs, err := os.ReadDir(c.Dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read cache dir: %w", err)
+	}
+
+	var entries []CacheEntry
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		entry, err := c.entry(dir.Name())
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
+
+func (c *FileCache) entry(hash string) (CacheEntry, error) {
+	entry := CacheEntry{Hash: hash}
+	dir := c.entryDir(hash)
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return entry, fmt.Errorf("read cache entry: %w", err)
+	}
+
+	for _, f := range files {
+		if info, err := f.Info(); err == nil {
+			entry.Size += info.Size()
+		}
+		if filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+
+		if f.Name() == cacheParseFile {
+			var parse CachedParse
+			if ok, err := readCacheJSON(filepath.Join(dir, f.Name()), &parse); ok && err == nil {
+				entry.Parse = &parse
+				entry.CreatedAt = parse.CreatedAt
+			}
+			continue
+		}
+
+		var file CachedFile
+		if ok, err := readCacheJSON(filepath.Join(dir, f.Name()), &file); ok && err == nil && file.File != "" {
+			file.Path = filepath.Join(dir, file.File)
+			entry.Files = append(entry.Files, file)
+			if entry.CreatedAt.IsZero() || file.CreatedAt.Before(entry.CreatedAt) {
+				entry.CreatedAt = file.CreatedAt
+			}
+		}
+	}
+
+	entry.Expired = !c.fresh(entry.CreatedAt)
+	return entry, nil
+}
+
+// Prune removes expired entries, or every entry when all is set, and returns how many were removed.
+func (c *FileCache) Prune(all bool) (int, error) {
+	entries, err := c.Entries()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if !all && !entry.Expired {
+			continue
+		}
+		if err := os.RemoveAll(c.entryDir(entry.Hash)); err != nil {
+			return removed, fmt.Errorf("remove cache entry %s: %w", entry.Hash, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func (c *FileCache) writeJSON(hash, name string, v any) error {
+	return c.writeFile(hash, name, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	})
+}
+
+// writeFile writes into the entry directory through a temporary file, so readers never see a
+// partial file.
+func (c *FileCache) writeFile(hash, name string, write func(io.Writer) error) error {
+	if hash == "" {
+		return errors.New("cache key cannot be empty")
+	}
+	return DirSink{Dir: c.entryDir(hash)}.create(name, write)
+}
+
+func readCacheJSON(path string, v any) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("read cache: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		// A corrupt entry is a miss; it is overwritten by the next store.
+		return false, nil
+	}
+	return true, nil
+}
+
+// convertCacheKey names a conversion by format and a digest of every option that changes the output.
+func convertCacheKey(req ConvertRequest) string {
+	formula := req.FormulaMode
+	if formula == "" {
+		formula = FormulaModeNormal
+	}
+	options := strings.Join([]string{
+		string(req.To),
+		string(formula),
+		req.Filename,
+		strconv.FormatBool(req.MergeCrossPageForms),
+	}, "\x00")
+	sum := sha256.Sum256([]byte(options))
+	return string(req.To) + "-" + hex.EncodeToString(sum[:6])
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
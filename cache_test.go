@@ -0,0 +1,267 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	client "github.com/hsn0918/doc2x-client"
+	"github.com/hsn0918/doc2x-client/doc2xtest"
+)
+
+func TestContentHash(t *testing.T) {
+	sum, err := client.ContentHash(strings.NewReader("abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"; sum != want {
+		t.Errorf("ContentHash = %s, want %s", sum, want)
+	}
+}
+
+func TestFileCacheStoreAndLookup(t *testing.T) {
+	cache := &client.FileCache{Dir: t.TempDir()}
+	const sum = "0123abcd"
+	status := &client.StatusResponse{TraceID: "trace-parse", Code: "success"}
+
+	if _, ok, err := cache.LookupParse(sum); ok || err != nil {
+		t.Fatalf("LookupParse on empty cache = %v, %v", ok, err)
+	}
+	if err := cache.StoreParse(sum, "paper.pdf", "uid-1", status); err != nil {
+		t.Fatalf("StoreParse: %v", err)
+	}
+	parse, ok, err := cache.LookupParse(sum)
+	if err != nil || !ok {
+		t.Fatalf("LookupParse = %v, %v", ok, err)
+	}
+	if parse.UID != "uid-1" || parse.Name != "paper.pdf" || parse.Status.TraceID != "trace-parse" {
+		t.Errorf("cached parse = %+v", parse)
+	}
+
+	req := client.ConvertRequest{UID: "uid-1", To: client.FormatMarkdown}
+	out := client.ConvertOutput{Request: req, URL: "https://cdn.example/uid-1.zip", TraceID: "trace-md"}
+	if err := cache.StoreFile(sum, out, func(w io.Writer) error {
+		_, err := io.WriteString(w, "converted")
+		return err
+	}); err != nil {
+		t.Fatalf("StoreFile: %v", err)
+	}
+
+	// The UID of the lookup does not matter, the conversion options do.
+	file, ok, err := cache.LookupFile(sum, client.ConvertRequest{UID: "uid-2", To: client.FormatMarkdown, FormulaMode: client.FormulaModeNormal})
+	if err != nil || !ok {
+		t.Fatalf("LookupFile = %v, %v", ok, err)
+	}
+	if data, err := os.ReadFile(file.Path); err != nil || string(data) != "converted" || file.Size != int64(len(data)) {
+		t.Errorf("cached file %+v holds %q, %v", file, data, err)
+	}
+	if _, ok, _ := cache.LookupFile(sum, client.ConvertRequest{To: client.FormatMarkdown, FormulaMode: client.FormulaModeDollar}); ok {
+		t.Error("file converted with another formula mode was served from the cache")
+	}
+
+	failed := errors.New("download failed")
+	if err := cache.StoreFile(sum, client.ConvertOutput{Request: client.ConvertRequest{To: client.FormatDocx}}, func(io.Writer) error {
+		return failed
+	}); !errors.Is(err, failed) {
+		t.Errorf("StoreFile error = %v, want the write error", err)
+	}
+	if _, ok, _ := cache.LookupFile(sum, client.ConvertRequest{To: client.FormatDocx}); ok {
+		t.Error("failed write left a cached file")
+	}
+}
+
+func TestFileCacheExpiryAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	cache := &client.FileCache{Dir: dir}
+	status := &client.StatusResponse{Code: "success"}
+	for _, sum := range []string{"old", "new"} {
+		if err := cache.StoreParse(sum, sum+".pdf", "uid-"+sum, status); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expired := &client.FileCache{Dir: dir, TTL: time.Nanosecond}
+	if _, ok, err := expired.LookupParse("old"); ok || err != nil {
+		t.Errorf("LookupParse past the TTL = %v, %v, want a miss", ok, err)
+	}
+
+	entries, err := expired.Entries()
+	if err != nil {
+		t.Fatalf("Entries: %v", err)
+	}
+	if len(entries) != 2 || !entries[0].Expired || entries[0].Hash != "old" || entries[0].Size == 0 {
+		t.Errorf("entries = %+v", entries)
+	}
+
+	if n, err := cache.Prune(false); err != nil || n != 0 {
+		t.Errorf("Prune(false) of fresh entries removed %d, %v", n, err)
+	}
+	if n, err := expired.Prune(false); err != nil || n != 2 {
+		t.Errorf("Prune(false) of expired entries removed %d, %v, want 2", n, err)
+	}
+	if entries, _ := cache.Entries(); len(entries) != 0 {
+		t.Errorf("entries after prune = %+v", entries)
+	}
+
+	if err := cache.StoreParse("kept", "kept.pdf", "uid-kept", status); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := cache.Prune(true); err != nil || n != 1 {
+		t.Errorf("Prune(true) removed %d, %v, want 1", n, err)
+	}
+}
+
+func TestPipelineCacheHit(t *testing.T) {
+	srv := doc2xtest.NewServer(doc2xtest.WithConvertContent(convertedContent))
+	defer srv.Close()
+
+	cache := &client.FileCache{Dir: t.TempDir()}
+	pipeline := func(formats ...client.ConvertFormat) (*client.Pipeline, string) {
+		out := t.TempDir()
+		return client.NewPipeline(srv.Client(), client.PipelineOptions{
+			Formats:      formats,
+			PollInterval: time.Millisecond,
+			Sink:         client.DirSink{Dir: out},
+			Cache:        cache,
+		}), out
+	}
+	ctx := context.Background()
+
+	p, _ := pipeline(client.FormatMarkdown)
+	first, err := p.Process(ctx, "paper.pdf", bytes.NewReader(samplePDF), int64(len(samplePDF)))
+	if err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+
+	// The same content under another name is served from the cache; only docx is converted.
+	p, out := pipeline(client.FormatMarkdown, client.FormatDocx)
+	second, err := p.Process(ctx, "copy.pdf", bytes.NewReader(samplePDF), int64(len(samplePDF)))
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if second.UID != first.UID {
+		t.Errorf("second run uid = %s, want the cached %s", second.UID, first.UID)
+	}
+	if n := countRequests(srv, client.EndpointParsePDF); n != 1 {
+		t.Errorf("server received %d uploads, want 1", n)
+	}
+	if n := countRequests(srv, client.EndpointConvertParse); n != 2 {
+		t.Errorf("server received %d conversions, want 2", n)
+	}
+	if len(second.Outputs) != 2 || !second.Outputs[0].Cached || second.Outputs[1].Cached {
+		t.Errorf("outputs = %+v, want cached md and converted docx", second.Outputs)
+	}
+
+	for name, to := range map[string]client.ConvertFormat{"copy_md.zip": client.FormatMarkdown, "copy_docx.docx": client.FormatDocx} {
+		data, err := os.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := convertedContent(first.UID, to); !bytes.Equal(data, want) {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, "copy.json")); err != nil {
+		t.Errorf("cached parse result not written: %v", err)
+	}
+}
+
+func TestPipelineCacheKeepsCachedFormatWhenConversionFails(t *testing.T) {
+	srv := doc2xtest.NewServer(doc2xtest.WithConvertContent(convertedContent))
+	defer srv.Close()
+
+	cache := &client.FileCache{Dir: t.TempDir()}
+	ctx := context.Background()
+
+	p := client.NewPipeline(srv.Client(), client.PipelineOptions{
+		Formats:      []client.ConvertFormat{client.FormatMarkdown},
+		PollInterval: time.Millisecond,
+		Cache:        cache,
+		Sink:         client.DirSink{Dir: t.TempDir()},
+	})
+	if _, err := p.Process(ctx, "paper.pdf", bytes.NewReader(samplePDF), int64(len(samplePDF))); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+
+	out := t.TempDir()
+	p = client.NewPipeline(srv.Client(), client.PipelineOptions{
+		Formats:      []client.ConvertFormat{client.FormatMarkdown, client.FormatDocx},
+		PollInterval: time.Millisecond,
+		Cache:        cache,
+		Sink:         client.DirSink{Dir: out},
+	})
+	srv.FailNext(client.EndpointConvertParse, doc2xtest.Failure{Msg: "conversion rejected"})
+	result, err := p.Process(ctx, "paper.pdf", bytes.NewReader(samplePDF), int64(len(samplePDF)))
+
+	var stageErr *client.StageError
+	if !errors.As(err, &stageErr) || stageErr.Stage != client.StageConvert {
+		t.Fatalf("second run error = %v, want a convert stage error", err)
+	}
+	if len(result.Outputs) != 2 || !result.Outputs[0].Cached || result.Outputs[0].Err != nil || result.Outputs[1].Err == nil {
+		t.Fatalf("outputs = %+v, want cached md and failed docx", result.Outputs)
+	}
+	if _, err := os.Stat(filepath.Join(out, "paper_md.zip")); err != nil {
+		t.Errorf("cached md not downloaded despite the failed docx: %v", err)
+	}
+}
+
+func TestPipelineCacheKeepsCachedFormatWhenConvertLockTimesOut(t *testing.T) {
+	srv := doc2xtest.NewServer(doc2xtest.WithConvertContent(convertedContent))
+	defer srv.Close()
+
+	// Conversions of one UID are serialised per client, so the pipelines share it with the blocker.
+	c := srv.Client()
+	cache := &client.FileCache{Dir: t.TempDir()}
+
+	first, err := client.NewPipeline(c, client.PipelineOptions{
+		Formats:      []client.ConvertFormat{client.FormatMarkdown},
+		PollInterval: time.Millisecond,
+		Cache:        cache,
+		Sink:         client.DirSink{Dir: t.TempDir()},
+	}).Process(context.Background(), "paper.pdf", bytes.NewReader(samplePDF), int64(len(samplePDF)))
+	if err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+
+	// Hold the convert lock of the UID with a conversion that polls once an hour.
+	blockCtx, unblock := context.WithCancel(context.Background())
+	blocked := make(chan struct{})
+	go func() {
+		defer close(blocked)
+		ctx := client.ContextWithPollStrategy(blockCtx, client.FixedPoll(time.Hour))
+		c.ConvertAll(ctx, first.UID, []client.ConvertRequest{{To: client.FormatTex}})
+	}()
+	defer func() {
+		unblock()
+		<-blocked
+	}()
+	for countRequests(srv, client.EndpointConvertParse) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	out := t.TempDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result, err := client.NewPipeline(c, client.PipelineOptions{
+		Formats:      []client.ConvertFormat{client.FormatMarkdown, client.FormatDocx},
+		PollInterval: time.Millisecond,
+		Cache:        cache,
+		Sink:         client.DirSink{Dir: out},
+	}).Process(ctx, "paper.pdf", bytes.NewReader(samplePDF), int64(len(samplePDF)))
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second run error = %v, want the lock wait to time out", err)
+	}
+	if len(result.Outputs) != 2 || !result.Outputs[0].Cached || result.Outputs[1].Err == nil {
+		t.Fatalf("outputs = %+v, want cached md and failed docx", result.Outputs)
+	}
+	if _, err := os.Stat(filepath.Join(out, "paper_md.zip")); err != nil {
+		t.Errorf("cached md not downloaded without the convert lock: %v", err)
+	}
+}
@@ -0,0 +1,311 @@
+// Package chunk splits Doc2X parse results into retrieval-sized chunks.
+//
+// Chunks follow the heading hierarchy of the document: a heading closes the
+// current chunk and every chunk records the path of headings it sits under.
+// Within a section, blocks are packed up to a size budget. Tables, display
+// formulas and images are never split, and captions stay with the figure or
+// table they describe; a single unit larger than the budget becomes its own
+// oversized chunk.
+//
+//	chunks := chunk.Split(result.Document(), chunk.Options{MaxSize: 1500, Source: "paper.pdf"})
+//	err := chunk.WriteJSONL(os.Stdout, chunks)
+package chunk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+// DefaultMaxSize is the chunk budget used when Options.MaxSize is not set.
+const DefaultMaxSize = 2000
+
+// Chunk is a contiguous piece of a document with retrieval metadata.
+type Chunk struct {
+	ID          string   `json:"id"`
+	Index       int      `json:"index"`
+	Text        string   `json:"text"`
+	Source      string   `json:"source,omitempty"` // Source file name
+	UID         string   `json:"uid,omitempty"`    // Doc2X task UID
+	PageStart   int      `json:"page_start"`       // First page index covered by the chunk
+	PageEnd     int      `json:"page_end"`         // Last page index covered by the chunk
+	HeadingPath []string `json:"heading_path,omitempty"`
+	Size        int      `json:"size"` // Size of Text as measured by Options.Measure
+}
+
+// Options configures Split.
+type Options struct {
+	// MaxSize is the chunk budget in units of Measure. Defaults to DefaultMaxSize.
+	MaxSize int
+	// Measure sizes text. Defaults to Characters; ApproxTokens suits token budgets.
+	Measure func(string) int
+	// Source and UID are copied into every chunk.
+	Source string
+	UID    string
+}
+
+// Characters counts runes.
+func Characters(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// ApproxTokens estimates the token count of s without a tokenizer: CJK characters count
+// as one token each and other text as one token per four characters.
+func ApproxTokens(s string) int {
+	var cjk, other int
+	for _, r := range s {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			cjk++
+		case !unicode.IsSpace(r):
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
+
+// unit is the smallest piece Split moves around; atomic units are never divided.
+type unit struct {
+	text      string
+	pageStart int
+	pageEnd   int
+	heading   int // Heading level, or 0 for content
+	atomic    bool
+	formulas  []client.Formula
+}
+
+// Split chunks doc according to opts.
+func Split(doc *client.Document, opts Options) []Chunk {
+	if doc == nil {
+		return nil
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultMaxSize
+	}
+	if opts.Measure == nil {
+		opts.Measure = Characters
+	}
+
+	s := splitter{opts: opts}
+	for _, u := range units(doc.Blocks()) {
+		s.add(u)
+	}
+	s.flush()
+	return s.chunks
+}
+
+type splitter struct {
+	opts     Options
+	chunks   []Chunk
+	headings []unit // Current heading stack
+	current  []unit
+	size     int
+	content  bool // current holds more than headings
+}
+
+func (s *splitter) add(u unit) {
+	if u.heading > 0 {
+		if s.content {
+			s.flush()
+		}
+		for len(s.headings) > 0 && s.headings[len(s.headings)-1].heading >= u.heading {
+			s.headings = s.headings[:len(s.headings)-1]
+			// Without content, current holds the tail of the heading stack: a popped heading had no
+			// content of its own and is dropped rather than carried into the next chunk.
+			if !s.content && len(s.current) > 0 {
+				s.size -= s.opts.Measure(s.current[len(s.current)-1].text)
+				s.current = s.current[:len(s.current)-1]
+			}
+		}
+		s.headings = append(s.headings, u)
+		s.append(u)
+		return
+	}
+
+	size := s.opts.Measure(u.text)
+	if s.content && s.size+size > s.opts.MaxSize {
+		s.flush()
+	}
+	if size > s.opts.MaxSize && !u.atomic {
+		for _, part := range splitText(u, s.opts) {
+			if s.content && s.size+s.opts.Measure(part.text) > s.opts.MaxSize {
+				s.flush()
+			}
+			s.append(part)
+			s.content = true
+		}
+		return
+	}
+	s.append(u)
+	s.content = true
+}
+
+func (s *splitter) append(u unit) {
+	s.current = append(s.current, u)
+	s.size += s.opts.Measure(u.text)
+}
+
+// flush emits the current chunk. Leading headings of a chunk without content are kept for the next one.
+func (s *splitter) flush() {
+	if !s.content {
+		return
+	}
+
+	texts := make([]string, 0, len(s.current))
+	pageStart, pageEnd := s.current[0].pageStart, s.current[0].pageEnd
+	for _, u := range s.current {
+		texts = append(texts, u.text)
+		pageStart, pageEnd = min(pageStart, u.pageStart), max(pageEnd, u.pageEnd)
+	}
+
+	var path []string
+	for _, h := range s.headings {
+		path = append(path, strings.TrimSpace(strings.TrimLeft(h.text, "#")))
+	}
+
+	text := strings.Join(texts, "\n\n")
+	index := len(s.chunks)
+	s.chunks = append(s.chunks, Chunk{
+		ID:          chunkID(s.opts, index),
+		Index:       index,
+		Text:        text,
+		Source:      s.opts.Source,
+		UID:         s.opts.UID,
+		PageStart:   pageStart,
+		PageEnd:     pageEnd,
+		HeadingPath: path,
+		Size:        s.opts.Measure(text),
+	})
+
+	s.current, s.size, s.content = nil, 0, false
+}
+
+func chunkID(opts Options, index int) string {
+	prefix := opts.UID
+	if prefix == "" {
+		prefix = opts.Source
+	}
+	if prefix == "" {
+		return fmt.Sprintf("%04d", index)
+	}
+	return fmt.Sprintf("%s-%04d", prefix, index)
+}
+
+// units converts blocks into units, gluing captions to their figure or table.
+func units(blocks []client.Block) []unit {
+	var out []unit
+	for i := 0; i < len(blocks); i++ {
+		b := blocks[i]
+		u := unit{text: b.Text, pageStart: b.Page, pageEnd: b.Page, formulas: b.Formulas}
+
+		switch b.Kind {
+		case client.BlockHeading:
+			u.text = strings.Repeat("#", max(b.Level, 1)) + " " + b.Text
+			u.heading = max(b.Level, 1)
+			u.formulas = nil
+		case client.BlockFootnote:
+			u.text = "[^" + b.Label + "]: " + b.Text
+			u.formulas = nil
+		case client.BlockTable, client.BlockFormula, client.BlockImage:
+			u.atomic = true
+		case client.BlockCaption:
+			u.atomic = true
+			if n := len(out); n > 0 && isFigure(blocks[i-1].Kind) && out[n-1].pageEnd == b.Page {
+				out[n-1] = merge(out[n-1], u)
+				continue
+			}
+			if i+1 < len(blocks) && isFigure(blocks[i+1].Kind) {
+				next := blocks[i+1]
+				u = merge(u, unit{text: next.Text, pageStart: next.Page, pageEnd: next.Page, atomic: true})
+				i++
+			}
+		}
+		out = append(out, u)
+	}
+	return out
+}
+
+func isFigure(kind client.BlockKind) bool {
+	return kind == client.BlockTable || kind == client.BlockImage
+}
+
+func merge(a, b unit) unit {
+	return unit{
+		text:      a.text + "\n\n" + b.text,
+		pageStart: min(a.pageStart, b.pageStart),
+		pageEnd:   max(a.pageEnd, b.pageEnd),
+		atomic:    true,
+	}
+}
+
+// splitText breaks an oversized paragraph at sentence boundaries outside inline formulas.
+// A single sentence longer than the budget is kept whole.
+func splitText(u unit, opts Options) []unit {
+	var (
+		parts []unit
+		start int
+	)
+	emit := func(end int) {
+		if text := strings.TrimSpace(u.text[start:end]); text != "" {
+			parts = append(parts, unit{text: text, pageStart: u.pageStart, pageEnd: u.pageEnd})
+		}
+		start = end
+	}
+
+	lastBreak := -1
+	for i := 0; i < len(u.text); {
+		if f, ok := formulaAt(u.formulas, i); ok {
+			i = f.Offset + len(f.Raw)
+			continue
+		}
+		r, n := utf8.DecodeRuneInString(u.text[i:])
+		i += n
+		if !isSentenceEnd(r, u.text[i:]) {
+			continue
+		}
+		if lastBreak > start && opts.Measure(u.text[start:i]) > opts.MaxSize {
+			emit(lastBreak)
+		}
+		lastBreak = i
+	}
+	if lastBreak > start && opts.Measure(u.text[start:]) > opts.MaxSize {
+		emit(lastBreak)
+	}
+	emit(len(u.text))
+	return parts
+}
+
+func formulaAt(formulas []client.Formula, offset int) (client.Formula, bool) {
+	for _, f := range formulas {
+		if f.Offset == offset {
+			return f, true
+		}
+	}
+	return client.Formula{}, false
+}
+
+func isSentenceEnd(r rune, rest string) bool {
+	switch r {
+	case '。', '！', '？', '；', '\n':
+		return true
+	case '.', '!', '?', ';':
+		return rest == "" || rest[0] == ' ' || rest[0] == '\n'
+	}
+	return false
+}
+
+// WriteJSONL writes one JSON object per chunk.
+func WriteJSONL(w io.Writer, chunks []Chunk) error {
+	enc := json.NewEncoder(w)
+	for _, c := range chunks {
+		if err := enc.Encode(c); err != nil {
+			return fmt.Errorf("write chunk %d: %w", c.Index, err)
+		}
+	}
+	return nil
+}
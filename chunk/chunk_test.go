@@ -0,0 +1,138 @@
+package chunk
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+func document(pages ...string) *client.Document {
+	result := &client.ParseResult{}
+	for i, md := range pages {
+		result.Pages = append(result.Pages, client.ParsePage{PageIdx: i, Md: md})
+	}
+	return result.Document()
+}
+
+func TestSplitHeadingPaths(t *testing.T) {
+	doc := document(
+		"# Title\n\n## A\n\n## B\n\nText b.\n\n### B1\n\nText b1.",
+		"Page two.",
+	)
+
+	chunks := Split(doc, Options{})
+	if len(chunks) != 2 {
+		t.Fatalf("got %d chunks, want 2: %+v", len(chunks), chunks)
+	}
+
+	// A has no content of its own and must not be carried into B's chunk.
+	if want := "# Title\n\n## B\n\nText b."; chunks[0].Text != want {
+		t.Errorf("chunk 0 text = %q, want %q", chunks[0].Text, want)
+	}
+	if want := []string{"Title", "B"}; !reflect.DeepEqual(chunks[0].HeadingPath, want) {
+		t.Errorf("chunk 0 heading path = %q, want %q", chunks[0].HeadingPath, want)
+	}
+	if chunks[0].Size != Characters(chunks[0].Text) {
+		t.Errorf("chunk 0 size = %d, want %d", chunks[0].Size, Characters(chunks[0].Text))
+	}
+
+	if want := "### B1\n\nText b1.\n\nPage two."; chunks[1].Text != want {
+		t.Errorf("chunk 1 text = %q, want %q", chunks[1].Text, want)
+	}
+	if want := []string{"Title", "B", "B1"}; !reflect.DeepEqual(chunks[1].HeadingPath, want) {
+		t.Errorf("chunk 1 heading path = %q, want %q", chunks[1].HeadingPath, want)
+	}
+	if chunks[1].PageStart != 0 || chunks[1].PageEnd != 1 {
+		t.Errorf("chunk 1 spans pages %d-%d, want 0-1", chunks[1].PageStart, chunks[1].PageEnd)
+	}
+}
+
+func TestSplitOversizedParagraphAtSentences(t *testing.T) {
+	chunks := Split(document("One two. Three four. Five six."), Options{MaxSize: 12})
+
+	var texts []string
+	for _, c := range chunks {
+		texts = append(texts, c.Text)
+	}
+	if want := []string{"One two.", "Three four.", "Five six."}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("chunks = %q, want %q", texts, want)
+	}
+}
+
+func TestSplitKeepsCaptionWithTable(t *testing.T) {
+	table := "| a | b |\n|---|---|\n| 1 | 2 |"
+	chunks := Split(document("Some intro text.\n\nTable 1: numbers\n\n"+table), Options{MaxSize: 20})
+
+	if len(chunks) != 2 {
+		t.Fatalf("got %d chunks, want 2: %+v", len(chunks), chunks)
+	}
+	// The table with its caption exceeds the budget but is never split.
+	if want := "Table 1: numbers\n\n" + table; chunks[1].Text != want {
+		t.Errorf("table chunk = %q, want %q", chunks[1].Text, want)
+	}
+}
+
+func TestChunkIDs(t *testing.T) {
+	doc := document("one", "")
+	tests := []struct {
+		opts Options
+		want string
+	}{
+		{Options{UID: "uid-1", Source: "paper.pdf"}, "uid-1-0000"},
+		{Options{Source: "paper.pdf"}, "paper.pdf-0000"},
+		{Options{}, "0000"},
+	}
+	for _, tt := range tests {
+		chunks := Split(doc, tt.opts)
+		if len(chunks) != 1 || chunks[0].ID != tt.want {
+			t.Errorf("Split(%+v) = %+v, want a single chunk %s", tt.opts, chunks, tt.want)
+			continue
+		}
+		if chunks[0].Source != tt.opts.Source || chunks[0].UID != tt.opts.UID {
+			t.Errorf("chunk %s did not copy source and uid: %+v", tt.want, chunks[0])
+		}
+	}
+}
+
+func TestApproxTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abcd", 1},
+		{"abcd e", 2},
+		{"你好 abcd efgh", 4},
+	}
+	for _, tt := range tests {
+		if got := ApproxTokens(tt.text); got != tt.want {
+			t.Errorf("ApproxTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestWriteJSONL(t *testing.T) {
+	chunks := Split(document("# H\n\nfirst", "## I\n\nsecond"), Options{Source: "a.pdf"})
+
+	var buf bytes.Buffer
+	if err := WriteJSONL(&buf, chunks); err != nil {
+		t.Fatalf("WriteJSONL: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(chunks) {
+		t.Fatalf("wrote %d lines for %d chunks", len(lines), len(chunks))
+	}
+	for i, line := range lines {
+		var got Chunk
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, chunks[i]) {
+			t.Errorf("line %d = %+v, want %+v", i, got, chunks[i])
+		}
+	}
+}
@@ -10,6 +10,13 @@ import (
 type client struct {
 	restyClient       *resty.Client
 	processingTimeout time.Duration
+	progress          ProgressFunc
+	transferProgress  TransferProgressFunc
+	pollStrategy      PollStrategy
+	apiLimits         limits
+	transferLimits    limits
+	transferTransport http.RoundTripper
+	convertLocks      keyedMutex
 }
 
 var _ Client = (*client)(nil)
@@ -82,10 +89,24 @@ func NewClient(apiKey string, opts ...Option) Client {
 	}
 
 	setAuthHeader(c.restyClient, apiKey)
+	c.installLimits()
 
 	return c
 }
 
+// installLimits wraps the API and transfer transports with the configured throttling.
+// Limiters are created once so every request made through the client shares them.
+func (c *client) installLimits() {
+	base := unwrapTransport(c.restyClient.GetClient().Transport)
+
+	if c.apiLimits.enabled() {
+		c.restyClient.SetTransport(newLimitedTransport(base, c.apiLimits))
+	}
+	if c.transferLimits.enabled() {
+		c.transferTransport = newLimitedTransport(base, c.transferLimits)
+	}
+}
+
 // Name returns the service name.
 func (c *client) Name() string {
 	return ServiceName
@@ -126,6 +147,10 @@ func (c *client) transferClient() *resty.Client {
 
 	baseHTTP := *c.restyClient.GetClient()
 	baseHTTP.Timeout = timeout
+	baseHTTP.Transport = unwrapTransport(baseHTTP.Transport)
+	if c.transferTransport != nil {
+		baseHTTP.Transport = c.transferTransport
+	}
 
 	return newTransferClient(timeout, &baseHTTP)
 }
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+// defaultCacheDir returns ~/.doc2x/cache, or an empty path when the home directory is unknown.
+func defaultCacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".doc2x", "cache")
+}
+
+func newCacheCmd() *cobra.Command {
+	co := &cacheOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "List or prune the local result cache used by parse",
+	}
+	cmd.PersistentFlags().StringVar(&co.dir, "cache-dir", defaultCacheDir(), "Directory of the local result cache")
+	cmd.PersistentFlags().DurationVar(&co.ttl, "cache-ttl", client.DefaultCacheTTL, "Age after which cached results are expired")
+
+	ls := &cobra.Command{
+		Use:               "ls",
+		Short:             "List cached PDFs with their task UID, age, formats and size",
+		ValidArgsFunction: positionalAlwaysFlags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := co.Validate(); err != nil {
+				return err
+			}
+			return co.runList(cmd)
+		},
+	}
+
+	prune := &cobra.Command{
+		Use:               "prune",
+		Short:             "Remove expired cache entries",
+		ValidArgsFunction: positionalAlwaysFlags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := co.Validate(); err != nil {
+				return err
+			}
+			return co.runPrune(cmd)
+		},
+	}
+	prune.Flags().BoolVar(&co.all, "all", false, "Remove every entry, not only expired ones")
+
+	cmd.AddCommand(ls, prune)
+
+	return cmd
+}
+
+type cacheOptions struct {
+	dir string
+	ttl time.Duration
+	all bool
+}
+
+func (o *cacheOptions) Validate() error {
+	if o.dir == "" {
+		return errors.New("flag --cache-dir is required")
+	}
+	if o.ttl <= 0 {
+		return errors.New("flag --cache-ttl must be positive")
+	}
+	return nil
+}
+
+func (o *cacheOptions) cache() *client.FileCache {
+	return &client.FileCache{Dir: o.dir, TTL: o.ttl}
+}
+
+func (o *cacheOptions) runList(cmd *cobra.Command) error {
+	entries, err := o.cache().Entries()
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return printOut(cmd, "Cache is empty", slog.String("dir", o.dir))
+	}
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+
+		attrs := []slog.Attr{slog.String("sha256", entry.Hash)}
+		if entry.Parse != nil {
+			attrs = append(attrs,
+				slog.String("name", entry.Parse.Name),
+				slog.String("uid", entry.Parse.UID),
+			)
+		}
+
+		formats := make([]string, 0, len(entry.Files))
+		for _, file := range entry.Files {
+			formats = append(formats, string(file.Request.To))
+		}
+
+		attrs = append(attrs,
+			slog.Duration("age", time.Since(entry.CreatedAt).Round(time.Second)),
+			slog.String("formats", strings.Join(formats, ",")),
+			slog.String("size", formatBytes(entry.Size)),
+			slog.Bool("expired", entry.Expired),
+		)
+		if err := printOut(cmd, "Cache entry", attrs...); err != nil {
+			return err
+		}
+	}
+
+	return printOut(cmd, "Cache total",
+		slog.String("dir", o.dir),
+		slog.Int("entries", len(entries)),
+		slog.String("size", formatBytes(total)),
+	)
+}
+
+func (o *cacheOptions) runPrune(cmd *cobra.Command) error {
+	removed, err := o.cache().Prune(o.all)
+	if err != nil {
+		return err
+	}
+	return printOut(cmd, "Pruned cache",
+		slog.String("dir", o.dir),
+		slog.Int("removed", removed),
+	)
+}
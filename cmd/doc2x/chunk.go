@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hsn0918/doc2x-client/chunk"
+)
+
+func newChunkCmd(opts *cliOptions) *cobra.Command {
+	co := &chunkOptions{
+		opts: opts,
+	}
+
+	cmd := &cobra.Command{
+		Use:               "chunk",
+		Short:             "Split a parse result into retrieval chunks as JSON lines",
+		ValidArgsFunction: positionalAlwaysFlags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := co.Validate(); err != nil {
+				return err
+			}
+
+			return co.Run(cmd)
+		},
+	}
+
+	co.addFlags(cmd)
+
+	return cmd
+}
+
+type chunkOptions struct {
+	source    resultSource
+	output    string
+	maxChars  int
+	maxTokens int
+	name      string
+	docUID    string
+	opts      *cliOptions
+}
+
+func (o *chunkOptions) addFlags(cmd *cobra.Command) {
+	o.source.addFlags(cmd)
+	cmd.Flags().StringVarP(&o.output, "output", "o", "", "JSONL output path (defaults to <input name>.chunks.jsonl)")
+	cmd.Flags().IntVar(&o.maxChars, "max-chars", chunk.DefaultMaxSize, "Chunk budget in characters")
+	cmd.Flags().IntVar(&o.maxTokens, "max-tokens", 0, "Chunk budget in approximate tokens; overrides --max-chars when set")
+	cmd.Flags().StringVar(&o.name, "source-name", "", "Source file name recorded in chunk metadata (defaults to the input name)")
+	cmd.Flags().StringVar(&o.docUID, "source-uid", "", "Task UID recorded in chunk metadata when reading --input")
+}
+
+func (o *chunkOptions) Validate() error {
+	if err := o.source.validate(); err != nil {
+		return err
+	}
+	if o.maxChars <= 0 && o.maxTokens <= 0 {
+		return errors.New("flag --max-chars or --max-tokens must be positive")
+	}
+	return nil
+}
+
+func (o *chunkOptions) Run(cmd *cobra.Command) error {
+	result, err := o.source.load(cmd, o.opts)
+	if err != nil {
+		return err
+	}
+
+	chunkOpts := chunk.Options{
+		MaxSize: o.maxChars,
+		Source:  o.name,
+		UID:     o.docUID,
+	}
+	if o.maxTokens > 0 {
+		chunkOpts.MaxSize, chunkOpts.Measure = o.maxTokens, chunk.ApproxTokens
+	}
+	if chunkOpts.Source == "" {
+		chunkOpts.Source = o.source.name()
+	}
+	if chunkOpts.UID == "" {
+		chunkOpts.UID = o.source.uid
+	}
+
+	chunks := chunk.Split(result.Document(), chunkOpts)
+
+	target := o.output
+	if target == "" {
+		target = o.source.name() + ".chunks.jsonl"
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("create output dir: %w", err)
+	}
+
+	if err := writeFileWith(target, func(f *os.File) error {
+		return chunk.WriteJSONL(f, chunks)
+	}); err != nil {
+		return err
+	}
+
+	return printOut(cmd, "Saved chunks",
+		slog.Int("chunks", len(chunks)),
+		slog.String("path", target),
+	)
+}
@@ -22,7 +22,7 @@ func newConvertCmd(opts *cliOptions) *cobra.Command {
 		ValidArgsFunction: positionalAlwaysFlags,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := co.Complete(); err != nil {
-				if logErr := logFailure(co.opts.failLogPath, "", co.uid, err); logErr != nil {
+				if logErr := logFailure(co.opts.failLogPath, failureEntry{Stage: failStageInput, UID: co.uid}, err); logErr != nil {
 					return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
 				}
 				return err
@@ -52,14 +52,14 @@ type convertOptions struct {
 	download       bool
 	output         string
 	opts           *cliOptions
-	targetFormat   client.ConvertFormat
+	targetFormats  []client.ConvertFormat
 	targetFormula  client.FormulaMode
 	apiKey         string
 }
 
 func (o *convertOptions) addFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.uid, "uid", "", "UID of the parsed document")
-	cmd.Flags().StringVar(&o.to, "to", string(client.FormatMarkdown), "Target format: md|tex|docx|md_dollar")
+	cmd.Flags().StringVar(&o.to, "to", string(client.FormatMarkdown), "Target formats, comma-separated: md|tex|docx|md_dollar (e.g. md,docx,tex)")
 	cmd.Flags().StringVar(&o.formulaMode, "formula-mode", string(client.FormulaModeNormal), "Formula mode: normal|dollar")
 	cmd.Flags().StringVar(&o.filename, "filename", "", "Optional output filename for md/tex without extension")
 	cmd.Flags().BoolVar(&o.mergeCrossPage, "merge-cross-page-forms", false, "Merge cross page tables")
@@ -70,11 +70,11 @@ func (o *convertOptions) addFlags(cmd *cobra.Command) {
 }
 
 func (o *convertOptions) Complete() error {
-	format, err := parseConvertFormat(o.to)
+	formats, err := parseConvertFormats(o.to)
 	if err != nil {
 		return err
 	}
-	o.targetFormat = format
+	o.targetFormats = formats
 
 	mode, err := parseFormulaMode(o.formulaMode)
 	if err != nil {
@@ -93,84 +93,65 @@ func (o *convertOptions) Validate() error {
 	if o.uid == "" {
 		return errors.New("flag --uid is required")
 	}
+	if len(o.targetFormats) > 1 && !o.wait {
+		return errors.New("converting to several formats requires --wait")
+	}
+	if len(o.targetFormats) > 1 && o.output != "" {
+		return errors.New("flag --output cannot be used with several --to formats")
+	}
 	return nil
 }
 
 func (o *convertOptions) Run(cmd *cobra.Command) error {
 	apiKey, err := resolveAPIKey(o.opts)
 	if err != nil {
-		if logErr := logFailure(o.opts.failLogPath, "", o.uid, err); logErr != nil {
+		if logErr := logFailure(o.opts.failLogPath, failureEntry{Stage: failStageInput, UID: o.uid}, err); logErr != nil {
 			return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
 		}
 		return err
 	}
 	o.apiKey = apiKey
 
-	cli := buildClient(o.apiKey, o.opts)
+	cli, err := buildClient(cmd, o.apiKey, o.opts)
+	if err != nil {
+		return err
+	}
 	ctx := cmd.Context()
 
-	req := client.ConvertRequest{
-		UID:                 o.uid,
-		To:                  o.targetFormat,
+	sink := &cliSink{cmd: cmd, output: o.output, multi: len(o.targetFormats) > 1}
+	pipelineOpts := client.PipelineOptions{
+		Formats:             o.targetFormats,
 		FormulaMode:         o.targetFormula,
 		Filename:            o.filename,
 		MergeCrossPageForms: o.mergeCrossPage,
+		PollInterval:        o.interval,
 	}
-
-	resp, err := cli.ConvertParse(ctx, req)
-	if err != nil {
-		if logErr := logFailure(o.opts.failLogPath, "", o.uid, err); logErr != nil {
-			return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
-		}
-		return err
-	}
-
-	if err := printWithTrace(cmd, slog.LevelInfo, resp.TraceID, "Convert requested",
-		slog.String("uid", o.uid),
-		slog.String("status", string(resp.Data.Status)),
-	); err != nil {
-		return err
+	if o.download {
+		pipelineOpts.Sink = sink
 	}
+	hooks := &cliHooks{cmd: cmd, failLog: o.opts.failLogPath, sink: sink, convert: failedConvertFor(pipelineOpts, sink), record: noRecord}
+	pipelineOpts.Hooks = client.PipelineHooks{AfterStage: hooks.after}
 
 	if !o.wait {
-		return nil
-	}
-
-	result, err := cli.WaitForConversion(ctx, o.uid, o.interval)
-	if err != nil {
-		if logErr := logFailure(o.opts.failLogPath, resp.TraceID, o.uid, err); logErr != nil {
-			return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
-		}
-		return err
-	}
-
-	if err := printWithTrace(cmd, slog.LevelInfo, result.TraceID, "Conversion finished",
-		slog.String("status", string(result.Data.Status)),
-		slog.String("uid", o.uid),
-		slog.String("url", result.Data.URL),
-	); err != nil {
-		return err
-	}
-
-	if o.download {
-		outPath := o.output
-		if outPath == "" {
-			outPath = defaultDownloadName(result.Data.URL, o.uid)
+		req := client.ConvertRequest{
+			UID:                 o.uid,
+			To:                  o.targetFormats[0],
+			FormulaMode:         o.targetFormula,
+			Filename:            o.filename,
+			MergeCrossPageForms: o.mergeCrossPage,
 		}
 
-		if err := downloadToFile(ctx, cli, result.Data.URL, outPath); err != nil {
-			if logErr := logFailure(o.opts.failLogPath, result.TraceID, o.uid, err); logErr != nil {
-				return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
-			}
-			return err
+		resp, err := cli.ConvertParse(ctx, req)
+		if err != nil {
+			return hooks.failed(client.StageEvent{Stage: client.StageConvert, UID: o.uid}, err)
 		}
 
-		if err := printWithTrace(cmd, slog.LevelInfo, result.TraceID, "Downloaded converted file",
-			slog.String("path", outPath),
-		); err != nil {
-			return err
-		}
+		return printWithTrace(cmd, slog.LevelInfo, resp.TraceID, "Convert requested",
+			slog.String("uid", o.uid),
+			slog.String("status", string(resp.Data.Status)),
+		)
 	}
 
-	return nil
+	_, err = client.NewPipeline(cli, pipelineOpts).Convert(ctx, "", o.uid)
+	return err
 }
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+func newDownloadCmd(opts *cliOptions) *cobra.Command {
+	do := &downloadOptions{
+		opts: opts,
+	}
+
+	cmd := &cobra.Command{
+		Use:               "download",
+		Short:             "Download a converted file by UID or URL, resuming partial downloads",
+		ValidArgsFunction: positionalAlwaysFlags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := do.Validate(); err != nil {
+				return err
+			}
+
+			return do.Run(cmd)
+		},
+	}
+
+	do.addFlags(cmd)
+
+	return cmd
+}
+
+type downloadOptions struct {
+	uid         string
+	url         string
+	output      string
+	downloadDir string
+	resume      bool
+	verifyETag  bool
+	progress    string
+	opts        *cliOptions
+	apiKey      string
+}
+
+func (o *downloadOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.uid, "uid", "", "UID of a converted document; the URL is resolved via the conversion result")
+	cmd.Flags().StringVar(&o.url, "url", "", "Download URL to fetch directly")
+	cmd.Flags().StringVarP(&o.output, "output", "o", "", "Download path (defaults to a name derived from the UID or URL under --download-dir)")
+	cmd.Flags().StringVar(&o.downloadDir, "download-dir", ".", "Directory to store the downloaded file when --output is not set")
+	cmd.Flags().BoolVar(&o.resume, "resume", true, "Resume from a previous partial download (<output>.part) using HTTP Range requests")
+	cmd.Flags().BoolVar(&o.verifyETag, "verify-etag", false, "Also verify the file against an MD5 ETag, as OSS sends for files uploaded in one piece")
+	cmd.Flags().StringVar(&o.progress, "progress", progressAuto, "Download progress display: auto|bar|log|off (auto draws a bar when stderr is a terminal)")
+}
+
+func (o *downloadOptions) Validate() error {
+	if o.uid == "" && o.url == "" {
+		return errors.New("flag --uid or --url is required")
+	}
+	if o.uid != "" && o.url != "" {
+		return errors.New("flags --uid and --url are mutually exclusive")
+	}
+	switch o.progress {
+	case progressAuto, progressBar, progressLog, progressOff:
+	default:
+		return fmt.Errorf("unsupported progress mode: %s", o.progress)
+	}
+	return nil
+}
+
+func (o *downloadOptions) Run(cmd *cobra.Command) error {
+	ctx := cmd.Context()
+	downloadURL := o.url
+	traceID := ""
+	outPath := o.output
+
+	fail := func(err error) error {
+		entry := failureEntry{
+			Stage:   string(client.StageDownload),
+			UID:     o.uid,
+			URL:     downloadURL,
+			TraceID: traceID,
+			Convert: &failedConvert{Download: true, Dir: o.downloadDir, Output: outPath},
+		}
+		if logErr := logFailure(o.opts.failLogPath, entry, err); logErr != nil {
+			return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
+		}
+		return err
+	}
+
+	apiKey, err := resolveAPIKey(o.opts)
+	if err != nil && o.uid != "" {
+		return fail(err)
+	}
+	o.apiKey = apiKey
+
+	cli, err := buildClient(cmd, o.apiKey, o.opts)
+	if err != nil {
+		return err
+	}
+
+	if o.uid != "" {
+		result, err := cli.GetConvertResult(ctx, o.uid)
+		if err != nil {
+			return fail(err)
+		}
+		traceID = result.TraceID
+
+		if result.Data.Status != client.ConvertStatusSuccess || result.Data.URL == "" {
+			return fail(fmt.Errorf("conversion for UID %s is %s, no download URL yet (trace-id: %s)", o.uid, result.Data.Status, traceID))
+		}
+		downloadURL = result.Data.URL
+	}
+
+	if outPath == "" {
+		outPath = filepath.Join(o.downloadDir, downloadFileName(downloadURL, o.uid))
+	}
+
+	progress, err := newTransferProgress(cmd, o.progress)
+	if err != nil {
+		return err
+	}
+	if fn := progress.track(filepath.Base(outPath)); fn != nil {
+		ctx = client.ContextWithTransferProgress(ctx, fn)
+	}
+
+	var downloadOpts []client.DownloadOption
+	if o.verifyETag {
+		downloadOpts = append(downloadOpts, client.WithETagChecksum())
+	}
+
+	resumed, err := resumableDownload(ctx, cli, downloadURL, outPath, o.resume, downloadOpts...)
+	if err != nil {
+		return fail(err)
+	}
+
+	return printWithTrace(cmd, slog.LevelInfo, traceID, "Downloaded file",
+		slog.String("path", outPath),
+		slog.Int64("resumed_from", resumed),
+	)
+}
+
+// downloadFileName derives a local name from the UID when known, otherwise from the URL path.
+func downloadFileName(downloadURL, uid string) string {
+	if uid != "" {
+		return defaultDownloadName(downloadURL, uid)
+	}
+
+	parsed, err := url.Parse(downloadURL)
+	if err == nil {
+		if base := path.Base(parsed.Path); base != "" && base != "/" && base != "." {
+			return base
+		}
+	}
+	return "download"
+}
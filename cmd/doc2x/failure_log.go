@@ -1,26 +1,126 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
+
+	client "github.com/hsn0918/doc2x-client"
 )
 
 var failureLogMu sync.Mutex
 
-func logFailure(path, traceID, target string, err error) error {
+// Fail log stages besides the pipeline stages (client.StageUpload and friends).
+const (
+	failStageInput = "input" // Invalid flags or input before any request; not retried
+	failStageImage = "image" // doc2x image; not retried
+)
+
+// failureEntry is one JSON line of the fail log. Besides describing the failure it keeps what
+// doc2x retry needs to run the failed stage again.
+type failureEntry struct {
+	Time       time.Time `json:"time"`
+	Stage      string    `json:"stage"`
+	File       string    `json:"file,omitempty"` // Input file
+	UID        string    `json:"uid,omitempty"`
+	TraceID    string    `json:"trace_id,omitempty"`
+	ErrorClass string    `json:"error_class"`
+	Error      string    `json:"error"`
+
+	URL        string          `json:"url,omitempty"`         // Download URL of a failed download
+	ResultPath string          `json:"result_path,omitempty"` // Where the parse result was to be saved
+	Formulas   *failedFormulas `json:"formulas,omitempty"`    // Formula post-processing of the parse result
+	Convert    *failedConvert  `json:"convert,omitempty"`     // Conversions still to run after the failed stage
+}
+
+// failedFormulas mirrors formulaDumpConfig.
+type failedFormulas struct {
+	Dir       string `json:"dir,omitempty"`
+	Format    string `json:"format,omitempty"`
+	Normalize string `json:"normalize,omitempty"`
+}
+
+// failedConvert holds the conversion settings and download location of a failed run.
+type failedConvert struct {
+	Formats             []client.ConvertFormat `json:"formats,omitempty"`
+	FormulaMode         client.FormulaMode     `json:"formula_mode,omitempty"`
+	Filename            string                 `json:"filename,omitempty"`
+	MergeCrossPageForms bool                   `json:"merge_cross_page_forms,omitempty"`
+	Download            bool                   `json:"download"`
+	Dir                 string                 `json:"download_dir,omitempty"`
+	Name                string                 `json:"download_name,omitempty"`
+	Output              string                 `json:"output,omitempty"`
+	Multi               bool                   `json:"multi,omitempty"`
+}
+
+// only narrows the settings to one format, keeping the file naming of the original run.
+func (c *failedConvert) only(format client.ConvertFormat) *failedConvert {
+	if c == nil {
+		return nil
+	}
+	narrowed := *c
+	narrowed.Formats = []client.ConvertFormat{format}
+	return &narrowed
+}
+
+func newFailedFormulas(cfg formulaDumpConfig) *failedFormulas {
+	if cfg.dir == "" && cfg.normalize == "" {
+		return nil
+	}
+	return &failedFormulas{Dir: cfg.dir, Format: cfg.format, Normalize: cfg.normalize}
+}
+
+func (f *failedFormulas) config() (formulaDumpConfig, error) {
+	if f == nil {
+		return formulaDumpConfig{}, nil
+	}
+	cfg := formulaDumpConfig{dir: f.Dir, format: f.Format, normalize: f.Normalize}
+	if cfg.format == "" {
+		cfg.format = "json"
+	}
+	if cfg.normalize != "" {
+		mode, err := parseFormulaMode(cfg.normalize)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.mode = mode
+	}
+	return cfg, nil
+}
+
+// logFailure appends entry, completed with the time and err, to the fail log at path.
+func logFailure(path string, entry failureEntry, err error) error {
 	if path == "" {
 		return nil
 	}
 
-	if traceID == "" {
-		traceID = "unknown"
+	entry.Time = time.Now().UTC()
+	entry.Error = err.Error()
+	entry.ErrorClass = errorClass(err)
+	if entry.TraceID == "" {
+		var apiErr *client.APIError
+		if errors.As(err, &apiErr) {
+			entry.TraceID = apiErr.TraceID
+		}
+	}
+
+	line, marshalErr := json.Marshal(entry)
+	if marshalErr != nil {
+		return marshalErr
 	}
-	timestamp := time.Now().Format(time.RFC3339)
-	line := fmt.Sprintf("%s\tlevel=ERROR\ttrace-id=%s\ttarget=%s\tmessage=%v\n", timestamp, traceID, target, err)
+	return appendFailureLines(path, string(line))
+}
 
+func appendFailureLines(path string, lines ...string) error {
 	failureLogMu.Lock()
 	defer failureLogMu.Unlock()
 
@@ -36,6 +136,86 @@ func logFailure(path, traceID, target string, err error) error {
 	}
 	defer f.Close()
 
-	_, writeErr := f.WriteString(line)
-	return writeErr
+	for _, line := range lines {
+		if _, writeErr := f.WriteString(line + "\n"); writeErr != nil {
+			return writeErr
+		}
+	}
+	return nil
+}
+
+// errorClass names the kind of failure so fail logs can be filtered without parsing messages.
+func errorClass(err error) string {
+	var (
+		apiErr  *client.APIError
+		pathErr *fs.PathError
+		netErr  net.Error
+	)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, client.ErrQuotaExceeded):
+		return "quota_exceeded"
+	case errors.Is(err, client.ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, client.ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, client.ErrTaskExpired):
+		return "task_expired"
+	case errors.Is(err, client.ErrParseFailed):
+		return "parse_failed"
+	case errors.Is(err, client.ErrNotPDF):
+		return "not_pdf"
+	case errors.Is(err, client.ErrFileTooLarge):
+		return "file_too_large"
+	case errors.Is(err, client.ErrIncompleteDownload):
+		return "incomplete_download"
+	case errors.Is(err, client.ErrChecksumMismatch):
+		return "checksum_mismatch"
+	case errors.As(err, &apiErr):
+		return "api"
+	case errors.As(err, &pathErr):
+		return "file"
+	case errors.As(err, &netErr):
+		return "network"
+	default:
+		return "other"
+	}
+}
+
+// failureLine is a fail log line; entry is nil for lines that are not JSON, such as those written by
+// older versions.
+type failureLine struct {
+	raw   string
+	entry *failureEntry
+}
+
+func readFailureLog(path string) ([]failureLine, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open fail log: %w", err)
+	}
+	defer f.Close()
+
+	var lines []failureLine
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+		line := failureLine{raw: raw}
+		var entry failureEntry
+		if json.Unmarshal([]byte(raw), &entry) == nil && entry.Stage != "" {
+			line.entry = &entry
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read fail log: %w", err)
+	}
+	return lines, nil
 }
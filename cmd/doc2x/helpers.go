@@ -11,6 +11,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -19,13 +20,24 @@ import (
 	client "github.com/hsn0918/doc2x-client"
 )
 
-func buildClient(apiKey string, opts *cliOptions) client.Client {
+func buildClient(cmd *cobra.Command, apiKey string, opts *cliOptions) (client.Client, error) {
+	strategy, err := parsePollStrategy(opts.pollStrategy)
+	if err != nil {
+		return nil, err
+	}
+
 	options := []client.Option{
 		client.WithBaseURL(opts.baseURL),
 		client.WithTimeout(opts.timeout),
 		client.WithProcessingTimeout(opts.processingTimeout),
+		client.WithProgress(progressLogger(cmd)),
+		client.WithPollStrategy(strategy),
+		client.WithRateLimit(opts.rateLimit, opts.rateBurst),
+		client.WithMaxInFlight(opts.maxInFlight),
+		client.WithTransferRateLimit(opts.transferRateLimit, opts.rateBurst),
+		client.WithTransferMaxInFlight(opts.transferInFlight),
 	}
-	return client.NewClient(apiKey, options...)
+	return client.NewClient(apiKey, options...), nil
 }
 
 func resolveAPIKey(opts *cliOptions) (string, error) {
@@ -61,6 +73,27 @@ func parseConvertFormat(to string) (client.ConvertFormat, error) {
 	}
 }
 
+// parseConvertFormats parses a comma-separated --convert-to/--to list, dropping duplicates.
+func parseConvertFormats(list string) ([]client.ConvertFormat, error) {
+	var formats []client.ConvertFormat
+	for _, item := range strings.Split(list, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		format, err := parseConvertFormat(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(formats, format) {
+			formats = append(formats, format)
+		}
+	}
+	if len(formats) == 0 {
+		return nil, errors.New("at least one target format is required")
+	}
+	return formats, nil
+}
+
 func parseFormulaMode(mode string) (client.FormulaMode, error) {
 	switch strings.ToLower(mode) {
 	case string(client.FormulaModeNormal):
@@ -74,6 +107,20 @@ func parseFormulaMode(mode string) (client.FormulaMode, error) {
 	}
 }
 
+// parsePollStrategy maps the --poll-strategy flag to a client strategy; the --interval flag stays the base delay.
+func parsePollStrategy(name string) (client.PollStrategy, error) {
+	switch strings.ToLower(name) {
+	case "", "fixed":
+		return client.FixedPoll(0), nil
+	case "exponential":
+		return client.ExponentialPoll{Jitter: 0.2}, nil
+	case "progress":
+		return client.ProgressAwarePoll{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported poll strategy: %s", name)
+	}
+}
+
 func writeJSON(path string, data any) error {
 	content, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
@@ -85,6 +132,23 @@ func writeJSON(path string, data any) error {
 	return nil
 }
 
+// writeFileWith creates path and lets write fill it, closing the file afterwards.
+func writeFileWith(path string, write func(*os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close file: %w", err)
+	}
+	return nil
+}
+
 func defaultDownloadName(urlStr, uid string) string {
 	parsed, err := url.Parse(urlStr)
 	if err != nil {
@@ -99,7 +163,20 @@ func defaultDownloadName(urlStr, uid string) string {
 	return uid + ext
 }
 
-func downloadToFile(ctx context.Context, cli client.Client, downloadURL, targetPath string) error {
+// convertDownloadName names a converted file; with several formats per UID the format is appended
+// so md and tex archives do not overwrite each other.
+func convertDownloadName(urlStr, uid string, format client.ConvertFormat, multi bool) string {
+	name := defaultDownloadName(urlStr, uid)
+	if !multi {
+		return name
+	}
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "_" + string(format) + ext
+}
+
+// writeDownload lets download fill a temporary file next to targetPath and renames it into place
+// on success, so a failed download never leaves a truncated file at targetPath.
+func writeDownload(targetPath string, download func(io.Writer) error) error {
 	dir := filepath.Dir(targetPath)
 	if dir != "." {
 		if err := os.MkdirAll(dir, 0o755); err != nil {
@@ -107,19 +184,103 @@ func downloadToFile(ctx context.Context, cli client.Client, downloadURL, targetP
 		}
 	}
 
-	file, err := os.Create(targetPath)
+	file, err := os.CreateTemp(dir, "."+filepath.Base(targetPath)+".*.part")
 	if err != nil {
 		return fmt.Errorf("create file: %w", err)
 	}
-	defer file.Close()
+	defer os.Remove(file.Name())
 
-	if err := cli.DownloadFileTo(ctx, downloadURL, file); err != nil {
+	if err := download(file); err != nil {
+		file.Close()
 		return err
 	}
-
+	// CreateTemp opens the file as 0600; give the result the usual permissions of a created file.
+	if err := file.Chmod(0o644); err != nil {
+		file.Close()
+		return fmt.Errorf("chmod file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close file: %w", err)
+	}
+	if err := os.Rename(file.Name(), targetPath); err != nil {
+		return fmt.Errorf("rename download: %w", err)
+	}
 	return nil
 }
 
+// resumableDownload writes downloadURL to targetPath through targetPath+".part" and renames it into place
+// on success. With resume set, an existing part file is continued with a Range request instead of restarted;
+// a failed download leaves the part file behind for the next attempt, along with the validator of the
+// response it came from, so a file that changed in between is downloaded from the start again rather than
+// spliced. The part file is read back so the whole file can be verified. It returns the offset resumed from.
+func resumableDownload(ctx context.Context, cli client.Client, downloadURL, targetPath string, resume bool, opts ...client.DownloadOption) (int64, error) {
+	dir := filepath.Dir(targetPath)
+	if dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return 0, fmt.Errorf("create download dir: %w", err)
+		}
+	}
+
+	partPath := targetPath + ".part"
+	validatorPath := partPath + ".validator"
+	flags := os.O_CREATE | os.O_RDWR | os.O_TRUNC
+
+	var (
+		offset    int64
+		validator string
+	)
+	if resume {
+		if info, err := os.Stat(partPath); err == nil {
+			offset = info.Size()
+		}
+		if data, err := os.ReadFile(validatorPath); err == nil {
+			validator = strings.TrimSpace(string(data))
+		}
+		flags = os.O_CREATE | os.O_RDWR | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(partPath, flags, 0o644)
+	if err != nil {
+		return offset, fmt.Errorf("create file: %w", err)
+	}
+
+	// The validator is saved before the body is written, so an interrupted download can be resumed.
+	saveValidator := client.WithValidatorFunc(func(v string) {
+		_ = os.WriteFile(validatorPath, []byte(v+"\n"), 0o644)
+	})
+
+	opts = append(opts, saveValidator)
+	resumeOpts := append(slices.Clip(opts), client.WithResumeFrom(file), client.WithIfRange(validator))
+	downloadErr := cli.DownloadFileTo(ctx, downloadURL, file, resumeOpts...)
+	if errors.Is(downloadErr, client.ErrResumeMismatch) {
+		// The part file belongs to another version of the file or is longer than it: start over.
+		offset = 0
+		if err := file.Truncate(0); err != nil {
+			file.Close()
+			return offset, fmt.Errorf("truncate part file: %w", err)
+		}
+		downloadErr = cli.DownloadFileTo(ctx, downloadURL, file, opts...)
+	}
+	if errors.Is(downloadErr, client.ErrChecksumMismatch) {
+		// A corrupt part file would fail every later resume as well.
+		_ = file.Truncate(0)
+	}
+	closeErr := file.Close()
+	if downloadErr != nil {
+		return offset, downloadErr
+	}
+	if closeErr != nil {
+		return offset, fmt.Errorf("close file: %w", closeErr)
+	}
+
+	if err := os.Rename(partPath, targetPath); err != nil {
+		return offset, fmt.Errorf("rename download: %w", err)
+	}
+	os.Remove(validatorPath)
+
+	return offset, nil
+}
+
 func printOut(cmd *cobra.Command, msg string, attrs ...slog.Attr) error {
 	return logWith(cmd, slog.LevelInfo, "", msg, attrs...)
 }
@@ -139,7 +300,9 @@ func logWith(cmd *cobra.Command, level slog.Level, traceID string, msg string, a
 	}
 	allAttrs = append(allAttrs, attrs...)
 
-	logger.LogAttrs(cmd.Context(), level, message, allAttrs...)
+	withBarsSuspended(func() {
+		logger.LogAttrs(cmd.Context(), level, message, allAttrs...)
+	})
 	return nil
 }
 
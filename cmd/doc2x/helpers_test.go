@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+var remoteFile = []byte(strings.Repeat("doc2x converted file\n", 50))
+
+// rangeRequest is the resume headers of a request received by fileServer.
+type rangeRequest struct {
+	rangeHeader string
+	ifRange     string
+}
+
+// fileServer serves data with etag, honouring Range and If-Range, and records the resume headers it receives.
+// With truncate set, the first response ends after that many bytes.
+func fileServer(t *testing.T, data []byte, etag string, truncate int) (*httptest.Server, func() []rangeRequest) {
+	t.Helper()
+	var (
+		mu       sync.Mutex
+		requests []rangeRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, rangeRequest{r.Header.Get("Range"), r.Header.Get("If-Range")})
+		first := len(requests) == 1
+		mu.Unlock()
+
+		w.Header().Set("ETag", etag)
+		if first && truncate > 0 {
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Write(data[:truncate])
+			return
+		}
+		http.ServeContent(w, r, "file.zip", time.Time{}, bytes.NewReader(data))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []rangeRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]rangeRequest(nil), requests...)
+	}
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkDownloaded(t *testing.T, target string) {
+	t.Helper()
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, remoteFile) {
+		t.Errorf("%s holds %d bytes, want the %d byte remote file", target, len(data), len(remoteFile))
+	}
+	for _, leftover := range []string{target + ".part", target + ".part.validator"} {
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Errorf("%s left behind after a successful download", leftover)
+		}
+	}
+}
+
+func TestResumableDownloadAppendsWithMatchingValidator(t *testing.T) {
+	srv, requests := fileServer(t, remoteFile, `"v1"`, 0)
+	target := filepath.Join(t.TempDir(), "out.zip")
+	writeFile(t, target+".part", string(remoteFile[:100]))
+	writeFile(t, target+".part.validator", "\"v1\"\n")
+
+	offset, err := resumableDownload(context.Background(), client.NewClient("sk-test"), srv.URL, target, true)
+	if err != nil {
+		t.Fatalf("resumableDownload: %v", err)
+	}
+	if offset != 100 {
+		t.Errorf("resumed from byte %d, want 100", offset)
+	}
+	if got := requests(); len(got) != 1 || got[0] != (rangeRequest{"bytes=100-", `"v1"`}) {
+		t.Errorf("requests = %+v, want one ranged request conditional on \"v1\"", got)
+	}
+	checkDownloaded(t, target)
+}
+
+func TestResumableDownloadRestartsStalePart(t *testing.T) {
+	srv, requests := fileServer(t, remoteFile, `"v2"`, 0)
+	target := filepath.Join(t.TempDir(), "out.zip")
+	stale := "bytes of an older version of the file"
+	writeFile(t, target+".part", stale)
+	writeFile(t, target+".part.validator", "\"v1\"\n")
+
+	offset, err := resumableDownload(context.Background(), client.NewClient("sk-test"), srv.URL, target, true)
+	if err != nil {
+		t.Fatalf("resumableDownload: %v", err)
+	}
+	if offset != 0 {
+		t.Errorf("reported resuming from byte %d, want a restart from 0", offset)
+	}
+	want := []rangeRequest{{"bytes=" + strconv.Itoa(len(stale)) + "-", `"v1"`}, {"", ""}}
+	if got := requests(); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("requests = %+v, want %+v", got, want)
+	}
+	checkDownloaded(t, target)
+}
+
+func TestResumableDownloadResumesInterruptedDownload(t *testing.T) {
+	srv, requests := fileServer(t, remoteFile, `"v1"`, 300)
+	target := filepath.Join(t.TempDir(), "out.zip")
+	cli := client.NewClient("sk-test")
+
+	if _, err := resumableDownload(context.Background(), cli, srv.URL, target, false); err == nil {
+		t.Fatal("truncated download reported success")
+	}
+	if info, err := os.Stat(target + ".part"); err != nil || info.Size() != 300 {
+		t.Fatalf("part file after the interruption = %v, %v, want 300 bytes", info, err)
+	}
+	if data, err := os.ReadFile(target + ".part.validator"); err != nil || strings.TrimSpace(string(data)) != `"v1"` {
+		t.Fatalf("validator file = %q, %v, want the ETag of the interrupted response", data, err)
+	}
+
+	offset, err := resumableDownload(context.Background(), cli, srv.URL, target, true)
+	if err != nil {
+		t.Fatalf("resumableDownload: %v", err)
+	}
+	if offset != 300 {
+		t.Errorf("resumed from byte %d, want 300", offset)
+	}
+	if got := requests(); len(got) != 2 || got[1] != (rangeRequest{"bytes=300-", `"v1"`}) {
+		t.Errorf("requests = %+v", got)
+	}
+	checkDownloaded(t, target)
+}
+
+func TestResumableDownloadWithoutResumeStartsOver(t *testing.T) {
+	srv, requests := fileServer(t, remoteFile, `"v1"`, 0)
+	target := filepath.Join(t.TempDir(), "out.zip")
+	writeFile(t, target+".part", string(remoteFile[:100]))
+
+	if _, err := resumableDownload(context.Background(), client.NewClient("sk-test"), srv.URL, target, false); err != nil {
+		t.Fatalf("resumableDownload: %v", err)
+	}
+	if got := requests(); len(got) != 1 || got[0].rangeHeader != "" {
+		t.Errorf("requests = %+v, want one unranged request", got)
+	}
+	checkDownloaded(t, target)
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+var imageExtensions = []string{".png", ".jpg", ".jpeg"}
+
+func newImageCmd(opts *cliOptions) *cobra.Command {
+	imo := &imageOptions{
+		opts: opts,
+	}
+
+	cmd := &cobra.Command{
+		Use:               "image",
+		Short:             "Run layout OCR on an image (single file or directory)",
+		ValidArgsFunction: positionalAlwaysFlags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := imo.Complete(); err != nil {
+				if logErr := logFailure(imo.opts.failLogPath, failureEntry{Stage: failStageInput, File: imo.inputPath}, err); logErr != nil {
+					return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
+				}
+				return err
+			}
+
+			if err := imo.Validate(); err != nil {
+				return err
+			}
+
+			return imo.Run(cmd)
+		},
+	}
+
+	imo.addFlags(cmd)
+
+	return cmd
+}
+
+type imageOptions struct {
+	inputPath   string
+	async       bool
+	interval    time.Duration
+	concurrency int
+	outputDir   string
+	extractDir  string
+	opts        *cliOptions
+	files       []string
+	apiKey      string
+}
+
+type imageJobConfig struct {
+	async      bool
+	interval   time.Duration
+	outputDir  string
+	extractDir string
+	failLog    string
+}
+
+func (o *imageOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.inputPath, "path", "p", "", "Path to an image (png/jpg) or a directory containing images")
+	cmd.Flags().BoolVar(&o.async, "async", false, "Use the async layout API and poll for the result")
+	cmd.Flags().DurationVar(&o.interval, "interval", 2*time.Second, "Polling interval when using --async")
+	cmd.Flags().IntVar(&o.concurrency, "concurrency", 3, "Number of concurrent images when using a directory")
+	cmd.Flags().StringVar(&o.outputDir, "output-dir", "", "Directory for per-image Markdown (defaults to the image's directory)")
+	cmd.Flags().StringVar(&o.extractDir, "extract-dir", "", "Directory to unpack convert_zip payloads into, one sub-directory per image (defaults to the Markdown directory)")
+}
+
+func (o *imageOptions) Complete() error {
+	if o.inputPath == "" {
+		return errors.New("flag --path is required")
+	}
+
+	if o.concurrency <= 0 {
+		o.concurrency = 3
+	}
+
+	if o.interval <= 0 {
+		o.interval = 2 * time.Second
+	}
+
+	files, err := collectImageFiles(o.inputPath)
+	if err != nil {
+		return err
+	}
+	o.files = files
+
+	return nil
+}
+
+func (o *imageOptions) Validate() error {
+	if len(o.files) == 0 {
+		return fmt.Errorf("no image files found in %s", o.inputPath)
+	}
+
+	for _, file := range o.files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("stat image: %w", err)
+		}
+		if info.Size() > client.MaxImageLayoutSize {
+			return fmt.Errorf("image %s is %d bytes, exceeding the %d byte limit", file, info.Size(), client.MaxImageLayoutSize)
+		}
+	}
+
+	return nil
+}
+
+func (o *imageOptions) Run(cmd *cobra.Command) error {
+	apiKey, err := resolveAPIKey(o.opts)
+	if err != nil {
+		if logErr := logFailure(o.opts.failLogPath, failureEntry{Stage: failStageInput}, err); logErr != nil {
+			return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
+		}
+		return err
+	}
+	o.apiKey = apiKey
+
+	cli, err := buildClient(cmd, o.apiKey, o.opts)
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+
+	jobCfg := imageJobConfig{
+		async:      o.async,
+		interval:   o.interval,
+		outputDir:  o.outputDir,
+		extractDir: o.extractDir,
+		failLog:    o.opts.failLogPath,
+	}
+
+	if len(o.files) == 1 {
+		return handleImageFile(ctx, cmd, cli, o.files[0], jobCfg)
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(o.concurrency)
+
+	var (
+		errs []error
+		mu   sync.Mutex
+	)
+
+	for _, img := range o.files {
+		img := img
+		eg.Go(func() error {
+			if err := handleImageFile(ctx, cmd, cli, img, jobCfg); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("batch completed with %d errors, first: %w", len(errs), errs[0])
+	}
+
+	return nil
+}
+
+func collectImageFiles(p string) ([]string, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, fmt.Errorf("stat path: %w", err)
+	}
+
+	if info.Mode().IsRegular() {
+		if isImageFile(p) {
+			return []string{p}, nil
+		}
+		return nil, fmt.Errorf("file is not a png/jpg image: %s", p)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("path is neither file nor directory: %s", p)
+	}
+
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		return nil, fmt.Errorf("read dir: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if isImageFile(entry.Name()) {
+			files = append(files, filepath.Join(p, entry.Name()))
+		}
+	}
+
+	return files, nil
+}
+
+func isImageFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, candidate := range imageExtensions {
+		if ext == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+func handleImageFile(ctx context.Context, cmd *cobra.Command, cli client.Client, img string, job imageJobConfig) error {
+	fileLabel := filepath.Base(img)
+
+	data, err := os.ReadFile(img)
+	if err != nil {
+		if logErr := logFailure(job.failLog, failureEntry{Stage: failStageImage, File: img}, err); logErr != nil {
+			return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
+		}
+		return fmt.Errorf("read image %s: %w", img, err)
+	}
+
+	var (
+		uid        string
+		traceID    string
+		result     *client.ImageLayoutResult
+		convertZIP string
+	)
+
+	if job.async {
+		submitted, err := cli.AsyncParseImageLayout(ctx, data)
+		if err != nil {
+			return failImage(img, "", err, job.failLog)
+		}
+		if err := printWithTrace(cmd, slog.LevelInfo, submitted.TraceID, "Image layout submitted",
+			slog.String("file", fileLabel),
+			slog.String("uid", submitted.Data.UID),
+		); err != nil {
+			return err
+		}
+		uid = submitted.Data.UID
+
+		status, err := cli.WaitForImageLayout(ctx, uid, job.interval)
+		if err != nil {
+			return failImage(img, submitted.TraceID, err, job.failLog)
+		}
+		traceID = status.TraceID
+		if status.Data != nil {
+			result, convertZIP = status.Data.Result, status.Data.ConvertZIP
+		}
+	} else {
+		resp, err := cli.ParseImageLayout(ctx, data)
+		if err != nil {
+			return failImage(img, "", err, job.failLog)
+		}
+		uid, traceID = resp.Data.UID, resp.TraceID
+		result, convertZIP = resp.Data.Result, resp.Data.ConvertZIP
+	}
+
+	outDir := job.outputDir
+	if outDir == "" {
+		outDir = filepath.Dir(img)
+	}
+	mdPath := filepath.Join(outDir, changeExt(fileLabel, ".md"))
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return failImage(img, traceID, fmt.Errorf("create output dir: %w", err), job.failLog)
+	}
+	if err := os.WriteFile(mdPath, []byte(layoutMarkdown(result)), 0o644); err != nil {
+		return failImage(img, traceID, fmt.Errorf("write markdown: %w", err), job.failLog)
+	}
+
+	if err := printWithTrace(cmd, slog.LevelInfo, traceID, "Image layout finished",
+		slog.String("file", fileLabel),
+		slog.String("uid", uid),
+		slog.String("path", mdPath),
+	); err != nil {
+		return err
+	}
+
+	if convertZIP == "" {
+		return nil
+	}
+
+	extractRoot := job.extractDir
+	if extractRoot == "" {
+		extractRoot = outDir
+	}
+	extractDir := filepath.Join(extractRoot, changeExt(fileLabel, ""))
+
+	bundle, err := cli.FetchConvertBundle(ctx, convertZIP)
+	if err != nil {
+		return failImage(img, traceID, err, job.failLog)
+	}
+	if err := bundle.ExtractTo(extractDir); err != nil {
+		return failImage(img, traceID, err, job.failLog)
+	}
+
+	return printWithTrace(cmd, slog.LevelInfo, traceID, "Extracted convert_zip",
+		slog.String("file", fileLabel),
+		slog.String("path", extractDir),
+		slog.String("markdown", bundle.MarkdownName),
+		slog.Int("images", len(bundle.Images)),
+	)
+}
+
+func failImage(img, traceID string, err error, failLog string) error {
+	if logErr := logFailure(failLog, failureEntry{Stage: failStageImage, File: img, TraceID: traceID}, err); logErr != nil {
+		return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
+	}
+	return fmt.Errorf("[%s] %w", filepath.Base(img), err)
+}
+
+// layoutMarkdown joins the Markdown of every page in an image layout result.
+func layoutMarkdown(result *client.ImageLayoutResult) string {
+	if result == nil {
+		return ""
+	}
+
+	pages := make([]string, 0, len(result.Pages))
+	for _, page := range result.Pages {
+		pages = append(pages, strings.TrimSpace(page.Md))
+	}
+	return strings.Join(pages, "\n\n") + "\n"
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Parse and convert states recorded in the job store.
+const (
+	jobStateUploaded = "uploaded"
+	jobStateSuccess  = "success"
+	jobStateFailed   = "failed"
+)
+
+// jobRecord is the persisted state of one input file.
+type jobRecord struct {
+	Path          string    `json:"path"`
+	Hash          string    `json:"sha256"`
+	UID           string    `json:"uid,omitempty"`
+	ParseState    string    `json:"parse_state,omitempty"`
+	ConvertState  string    `json:"convert_state,omitempty"`
+	ResultPath    string    `json:"result_path,omitempty"`
+	DownloadPaths []string  `json:"download_paths,omitempty"`
+	TraceID       string    `json:"trace_id,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// finished reports whether the record needs no further work.
+func (r jobRecord) finished(convert bool) bool {
+	if r.ParseState != jobStateSuccess {
+		return false
+	}
+	return !convert || r.ConvertState == jobStateSuccess
+}
+
+// jobStore is an append-only JSON-lines file of job records; the last line for a path wins and
+// older lines are dropped when the store is next opened. A nil store records nothing.
+type jobStore struct {
+	mu      sync.Mutex
+	file    *os.File
+	records map[string]jobRecord
+}
+
+// defaultJobStorePath returns ~/.doc2x/jobs.jsonl, or an empty path when the home directory is unknown.
+func defaultJobStorePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".doc2x", "jobs.jsonl")
+}
+
+// openJobStore loads the records stored at path and opens it for appending. A file holding
+// superseded lines is compacted to the last record of each path first.
+func openJobStore(path string) (*jobStore, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	if dir := filepath.Dir(path); dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create state dir: %w", err)
+		}
+	}
+
+	records, lines, err := readJobRecords(path)
+	if err != nil {
+		return nil, err
+	}
+	if lines > len(records) {
+		if err := writeJobRecords(path, records); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open state file: %w", err)
+	}
+	return &jobStore{file: f, records: records}, nil
+}
+
+// readJobRecords returns the last record of every path in the state file and the number of
+// non-empty lines read. A missing file holds no records.
+func readJobRecords(path string) (map[string]jobRecord, int, error) {
+	records := make(map[string]jobRecord)
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return records, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("open state file: %w", err)
+	}
+	defer f.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		lines++
+		var rec jobRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil || rec.Path == "" {
+			continue
+		}
+		records[rec.Path] = rec
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("read state file: %w", err)
+	}
+	return records, lines, nil
+}
+
+// writeJobRecords replaces the state file with one line per record, sorted by path.
+func writeJobRecords(path string, records map[string]jobRecord) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("compact state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for _, key := range slices.Sorted(maps.Keys(records)) {
+		line, err := json.Marshal(records[key])
+		if err != nil {
+			tmp.Close()
+			return fmt.Errorf("marshal job record: %w", err)
+		}
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("compact state file: %w", err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("compact state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("compact state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("compact state file: %w", err)
+	}
+	return nil
+}
+
+// Close releases the underlying file.
+func (s *jobStore) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+// lookup returns the record for path when its content hash still matches.
+func (s *jobStore) lookup(path, hash string) (jobRecord, bool) {
+	if s == nil {
+		return jobRecord{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.records[storeKey(path)]
+	if !ok || rec.Hash != hash {
+		return jobRecord{}, false
+	}
+	return rec, true
+}
+
+// update applies fn to the record for path and appends the result to the store.
+func (s *jobStore) update(path, hash string, fn func(*jobRecord)) error {
+	if s == nil {
+		return nil
+	}
+
+	key := storeKey(path)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.records[key]
+	if !ok || rec.Hash != hash {
+		rec = jobRecord{Path: key, Hash: hash}
+	}
+	fn(&rec)
+	rec.UpdatedAt = time.Now()
+	s.records[key] = rec
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal job record: %w", err)
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write state file: %w", err)
+	}
+	return nil
+}
+
+func storeKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
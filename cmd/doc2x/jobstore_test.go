@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	client "github.com/hsn0918/doc2x-client"
+	"github.com/hsn0918/doc2x-client/doc2xtest"
+)
+
+const samplePDF = "%PDF-1.4\n%%EOF\n"
+
+// runCLI runs the doc2x command line with args, discarding its output.
+func runCLI(ctx context.Context, args ...string) error {
+	cmd := newRootCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(args)
+	return cmd.ExecuteContext(ctx)
+}
+
+func countUploads(srv *doc2xtest.Server) int {
+	n := 0
+	for _, req := range srv.Requests() {
+		if req.Path == client.EndpointParsePDF || req.Path == client.EndpointPreUpload {
+			n++
+		}
+	}
+	return n
+}
+
+func fileHash(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	hash, err := client.ContentHash(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestOpenJobStoreCompacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "jobs.jsonl")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{
+		`{"path":"/in/b.pdf","sha256":"h1","uid":"b1","parse_state":"uploaded","updated_at":"2026-01-01T00:00:00Z"}`,
+		`{"path":"/in/a.pdf","sha256":"h1","uid":"a1","parse_state":"uploaded","updated_at":"2026-01-01T00:00:00Z"}`,
+		``,
+		`not json`,
+		`{"sha256":"h1"}`,
+		`{"path":"/in/b.pdf","sha256":"h1","uid":"b1","parse_state":"success","updated_at":"2026-01-01T00:01:00Z"}`,
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := openJobStore(path)
+	if err != nil {
+		t.Fatalf("openJobStore: %v", err)
+	}
+	if rec, ok := store.lookup("/in/b.pdf", "h1"); !ok || rec.ParseState != jobStateSuccess {
+		t.Errorf("b.pdf = %+v, %v, want its last record", rec, ok)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := lines[1] + "\n" + lines[5] + "\n"
+	if string(data) != want {
+		t.Errorf("compacted store =\n%s\nwant the last record of each path, sorted:\n%s", data, want)
+	}
+
+	// A compact store is left as it is.
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err = openJobStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(before, after) {
+		t.Error("compact store was rewritten")
+	}
+}
+
+func TestJobStoreLookup(t *testing.T) {
+	dir := t.TempDir()
+	pdf := filepath.Join(dir, "a.pdf")
+	if err := os.WriteFile(pdf, []byte(samplePDF), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hash := fileHash(t, pdf)
+	path := filepath.Join(dir, "jobs.jsonl")
+
+	store, err := openJobStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.lookup(pdf, hash); ok {
+		t.Error("empty store returned a record")
+	}
+	if err := store.update(pdf, hash, func(r *jobRecord) { r.UID, r.ParseState = "uid-1", jobStateUploaded }); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.update(pdf, hash, func(r *jobRecord) { r.ParseState = jobStateSuccess }); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	// Records survive reopening and are keyed by absolute path and content hash.
+	store, err = openJobStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	t.Chdir(dir)
+	rec, ok := store.lookup("a.pdf", hash)
+	if !ok || rec.UID != "uid-1" || rec.ParseState != jobStateSuccess || rec.Path != pdf {
+		t.Errorf("lookup = %+v, %v, want the updated record of %s", rec, ok, pdf)
+	}
+
+	if err := os.WriteFile(pdf, []byte(samplePDF+"% edited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	edited := fileHash(t, pdf)
+	if _, ok := store.lookup(pdf, edited); ok {
+		t.Error("record of the old content returned for an edited file")
+	}
+	// Updating an edited file starts a fresh record.
+	if err := store.update(pdf, edited, func(r *jobRecord) { r.ParseState = jobStateFailed }); err != nil {
+		t.Fatal(err)
+	}
+	if rec, _ := store.lookup(pdf, edited); rec.UID != "" || rec.Hash != edited {
+		t.Errorf("record after the edit = %+v, want a fresh one", rec)
+	}
+}
+
+func TestNilJobStore(t *testing.T) {
+	store, err := openJobStore("")
+	if err != nil || store != nil {
+		t.Fatalf("openJobStore(\"\") = %v, %v, want no store", store, err)
+	}
+	if _, ok := store.lookup("a.pdf", "h"); ok {
+		t.Error("nil store returned a record")
+	}
+	if err := store.update("a.pdf", "h", func(*jobRecord) {}); err != nil {
+		t.Error(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+// parseResume runs doc2x parse --resume on pdf against srv.
+func parseResume(t *testing.T, srv *doc2xtest.Server, pdf, state string, extra ...string) {
+	t.Helper()
+	args := []string{
+		"--api-key", "sk-test", "--base-url", srv.URL, "--fail-log", filepath.Join(filepath.Dir(state), "fail.log"),
+		"parse", "-f", pdf, "--state-file", state, "--resume", "--interval", "1ms", "--convert=false",
+		"-o", filepath.Join(filepath.Dir(state), "out.json"),
+	}
+	if err := runCLI(context.Background(), append(args, extra...)...); err != nil {
+		t.Fatalf("parse %q: %v", extra, err)
+	}
+}
+
+func TestParseResumeSkipsFinishedFiles(t *testing.T) {
+	t.Setenv("HOME", "") // No home directory, so no default result cache answers instead of the store
+	srv := doc2xtest.NewServer()
+	t.Cleanup(srv.Close)
+	dir := t.TempDir()
+	pdf := filepath.Join(dir, "a.pdf")
+	state := filepath.Join(dir, "jobs.jsonl")
+	if err := os.WriteFile(pdf, []byte(samplePDF), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	parseResume(t, srv, pdf, state)
+	if n := countUploads(srv); n != 1 {
+		t.Fatalf("first run uploaded %d times, want 1", n)
+	}
+
+	requests := len(srv.Requests())
+	parseResume(t, srv, pdf, state)
+	if n := len(srv.Requests()); n != requests {
+		t.Errorf("resumed run of a finished file sent %d requests, want none", n-requests)
+	}
+
+	// An edited file no longer matches its record and is parsed again.
+	if err := os.WriteFile(pdf, []byte(samplePDF+"% edited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	parseResume(t, srv, pdf, state)
+	if n := countUploads(srv); n != 2 {
+		t.Errorf("edited file uploaded %d times in total, want 2", n)
+	}
+}
+
+func TestParseResumeRepollsUploadedTask(t *testing.T) {
+	t.Setenv("HOME", "") // No home directory, so no default result cache answers instead of the store
+	srv := doc2xtest.NewServer()
+	t.Cleanup(srv.Close)
+	dir := t.TempDir()
+	pdf := filepath.Join(dir, "a.pdf")
+	state := filepath.Join(dir, "jobs.jsonl")
+	if err := os.WriteFile(pdf, []byte(samplePDF), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	parseResume(t, srv, pdf, state, "--wait=false")
+	parseResume(t, srv, pdf, state)
+	if n := countUploads(srv); n != 1 {
+		t.Errorf("uploaded %d times, want the submitted task re-polled", n)
+	}
+
+	store, err := openJobStore(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	rec, ok := store.lookup(pdf, fileHash(t, pdf))
+	if want := srv.UIDs(); !ok || rec.ParseState != jobStateSuccess || !reflect.DeepEqual([]string{rec.UID}, want) {
+		t.Errorf("record = %+v, %v, want a finished parse of %v", rec, ok, want)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// manifestEntry is one line of a --manifest file. Empty fields fall back to the parse flags.
+type manifestEntry struct {
+	Path        string `json:"path"`         // PDF path, relative to the manifest's directory unless absolute
+	ConvertTo   string `json:"convert_to"`   // Comma- or semicolon-separated formats, like --convert-to
+	FormulaMode string `json:"formula_mode"` // Like --convert-formula-mode
+	Output      string `json:"output"`       // Name of the result JSON and converted files, without extension
+}
+
+// manifestColumns are the CSV header names; path is required, the others are optional.
+var manifestColumns = []string{"path", "convert_to", "formula_mode", "output"}
+
+// loadManifest reads a CSV (with a header row) or JSON-lines manifest, chosen by extension.
+func loadManifest(manifest string) ([]inputFile, error) {
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+
+	var entries []manifestEntry
+	switch strings.ToLower(filepath.Ext(manifest)) {
+	case ".csv":
+		entries, err = readManifestCSV(bytes.NewReader(data))
+	case ".jsonl", ".ndjson":
+		entries, err = readManifestJSONL(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unsupported manifest format %q: use .csv or .jsonl", filepath.Ext(manifest))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read manifest %s: %w", manifest, err)
+	}
+
+	// Reports and the job store are keyed by path, so each file may be listed only once.
+	base := filepath.Dir(manifest)
+	files := make([]inputFile, 0, len(entries))
+	seen := make(map[string]int, len(entries))
+	for i, entry := range entries {
+		in, err := entry.inputFile(base)
+		if err != nil {
+			return nil, fmt.Errorf("manifest %s entry %d: %w", manifest, i+1, err)
+		}
+		key := storeKey(in.path)
+		if first, ok := seen[key]; ok {
+			return nil, fmt.Errorf("manifest %s entry %d: path %s is already listed in entry %d", manifest, i+1, in.path, first)
+		}
+		seen[key] = i + 1
+		files = append(files, in)
+	}
+	return files, nil
+}
+
+func readManifestCSV(r io.Reader) ([]manifestEntry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(manifestColumns, name) {
+			return nil, fmt.Errorf("unknown column %q (expected %s)", name, strings.Join(manifestColumns, ", "))
+		}
+		index[name] = i
+	}
+	if _, ok := index["path"]; !ok {
+		return nil, errors.New("missing path column")
+	}
+
+	var entries []manifestEntry
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		field := func(name string) string {
+			if i, ok := index[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		entries = append(entries, manifestEntry{
+			Path:        field("path"),
+			ConvertTo:   field("convert_to"),
+			FormulaMode: field("formula_mode"),
+			Output:      field("output"),
+		})
+	}
+}
+
+func readManifestJSONL(r io.Reader) ([]manifestEntry, error) {
+	var entries []manifestEntry
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var entry manifestEntry
+		dec := json.NewDecoder(strings.NewReader(text))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// inputFile checks the entry and resolves it against the manifest directory base. Files inside base
+// keep their relative path as output name, like a scanned directory; others use their base name.
+func (e manifestEntry) inputFile(base string) (inputFile, error) {
+	if e.Path == "" {
+		return inputFile{}, errors.New("path is required")
+	}
+
+	p := e.Path
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(base, p)
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		return inputFile{}, fmt.Errorf("stat path: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return inputFile{}, fmt.Errorf("path is not a file: %s", p)
+	}
+
+	rel := filepath.Base(p)
+	if r, err := filepath.Rel(base, p); err == nil && filepath.IsLocal(r) {
+		rel = filepath.ToSlash(r)
+	}
+
+	in := inputFile{path: p, rel: rel}
+	if e.ConvertTo != "" {
+		list := strings.ReplaceAll(e.ConvertTo, ";", ",")
+		if _, err := parseConvertFormats(list); err != nil {
+			return inputFile{}, err
+		}
+		in.convertTo = list
+	}
+	if e.FormulaMode != "" {
+		if _, err := parseFormulaMode(e.FormulaMode); err != nil {
+			return inputFile{}, err
+		}
+		in.formulaMode = e.FormulaMode
+	}
+	if e.Output != "" {
+		output := path.Clean(filepath.ToSlash(e.Output))
+		if !filepath.IsLocal(filepath.FromSlash(output)) {
+			return inputFile{}, fmt.Errorf("output %q must be a relative path inside the output dirs", e.Output)
+		}
+		in.output = output
+	}
+	return in, nil
+}
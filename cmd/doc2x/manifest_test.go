@@ -0,0 +1,179 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	a := filepath.Join(dir, "a.pdf")
+	b := filepath.Join(dir, "sub", "b.pdf")
+	outside := filepath.Join(t.TempDir(), "c.pdf")
+	for _, p := range []string{a, b, outside} {
+		writeFile(t, p, samplePDF)
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []inputFile
+		wantErr string
+	}{
+		{
+			name:    "csv",
+			file:    "list.csv",
+			content: "path,convert_to,formula_mode,output\na.pdf,md;docx,dollar,out/a\n sub/b.pdf ,,,\n",
+			want: []inputFile{
+				{path: a, rel: "a.pdf", convertTo: "md,docx", formulaMode: "dollar", output: "out/a"},
+				{path: b, rel: "sub/b.pdf"},
+			},
+		},
+		{
+			name:    "csv columns in any order and case",
+			file:    "list.CSV",
+			content: "Output, PATH\nx/./y,a.pdf\n",
+			want:    []inputFile{{path: a, rel: "a.pdf", output: "x/y"}},
+		},
+		{
+			name:    "csv short rows",
+			file:    "list.csv",
+			content: "path,output\na.pdf\n",
+			want:    []inputFile{{path: a, rel: "a.pdf"}},
+		},
+		{
+			name:    "csv empty",
+			file:    "list.csv",
+			content: "",
+			want:    []inputFile{},
+		},
+		{
+			name:    "csv unknown column",
+			file:    "list.csv",
+			content: "path,format\na.pdf,md\n",
+			wantErr: `unknown column "format"`,
+		},
+		{
+			name:    "csv without path column",
+			file:    "list.csv",
+			content: "output\nx\n",
+			wantErr: "missing path column",
+		},
+		{
+			name: "jsonl",
+			file: "list.jsonl",
+			content: `{"path":"sub/b.pdf","convert_to":"docx"}` + "\n\n" +
+				`{"path":"` + filepath.ToSlash(outside) + `","formula_mode":"latex"}` + "\n",
+			want: []inputFile{
+				{path: b, rel: "sub/b.pdf", convertTo: "docx"},
+				{path: outside, rel: "c.pdf", formulaMode: "latex"},
+			},
+		},
+		{
+			name:    "ndjson",
+			file:    "list.ndjson",
+			content: `{"path":"a.pdf"}`,
+			want:    []inputFile{{path: a, rel: "a.pdf"}},
+		},
+		{
+			name:    "jsonl unknown field",
+			file:    "list.jsonl",
+			content: `{"path":"a.pdf"}` + "\n" + `{"path":"sub/b.pdf","to":"md"}` + "\n",
+			wantErr: "line 2",
+		},
+		{
+			name:    "jsonl malformed",
+			file:    "list.jsonl",
+			content: `{"path":`,
+			wantErr: "line 1",
+		},
+		{
+			name:    "duplicate path",
+			file:    "list.csv",
+			content: "path\na.pdf\nsub/b.pdf\n./sub/../a.pdf\n",
+			wantErr: "entry 3: path " + a + " is already listed in entry 1",
+		},
+		{
+			name:    "duplicate of an absolute path",
+			file:    "list.jsonl",
+			content: `{"path":"a.pdf"}` + "\n" + `{"path":"` + filepath.ToSlash(a) + `"}`,
+			wantErr: "is already listed in entry 1",
+		},
+		{
+			name:    "missing path",
+			file:    "list.csv",
+			content: "path,output\n,x\n",
+			wantErr: "entry 1: path is required",
+		},
+		{
+			name:    "nonexistent file",
+			file:    "list.csv",
+			content: "path\nmissing.pdf\n",
+			wantErr: "stat path",
+		},
+		{
+			name:    "directory",
+			file:    "list.csv",
+			content: "path\nsub\n",
+			wantErr: "path is not a file",
+		},
+		{
+			name:    "unsupported format",
+			file:    "list.csv",
+			content: "path,convert_to\na.pdf,pptx\n",
+			wantErr: "pptx",
+		},
+		{
+			name:    "unsupported formula mode",
+			file:    "list.csv",
+			content: "path,formula_mode\na.pdf,mathml\n",
+			wantErr: "unsupported formula mode",
+		},
+		{
+			name:    "output outside the output dirs",
+			file:    "list.csv",
+			content: "path,output\na.pdf,../a\n",
+			wantErr: "must be a relative path",
+		},
+		{
+			name:    "unsupported manifest extension",
+			file:    "list.txt",
+			content: "a.pdf\n",
+			wantErr: "unsupported manifest format",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest := filepath.Join(dir, tt.file)
+			writeFile(t, manifest, tt.content)
+			t.Cleanup(func() { os.Remove(manifest) })
+
+			got, err := loadManifest(manifest)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadManifest error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadManifest: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadManifest =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadManifestMissingFile(t *testing.T) {
+	if _, err := loadManifest(filepath.Join(t.TempDir(), "missing.csv")); err == nil || !strings.Contains(err.Error(), "read manifest") {
+		t.Errorf("loadManifest error = %v, want a read error", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -32,7 +33,7 @@ func newParseCmd(opts *cliOptions) *cobra.Command {
 				if target == "" {
 					target = po.filePath
 				}
-				if logErr := logFailure(po.opts.failLogPath, "", target, err); logErr != nil {
+				if logErr := logFailure(po.opts.failLogPath, failureEntry{Stage: failStageInput, File: target}, err); logErr != nil {
 					return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
 				}
 				return err
@@ -54,15 +55,33 @@ func newParseCmd(opts *cliOptions) *cobra.Command {
 type parseOptions struct {
 	filePath    string
 	inputPath   string
+	manifest    string
+	reportPath  string
 	wait        bool
 	interval    time.Duration
 	output      string
 	outputDir   string
 	concurrency int
 	opts        *cliOptions
-	files       []string
+	files       []inputFile
+	scan        inputScan
 	apiKey      string
 	auto        autoConvertConfig
+	stateFile   string
+	resume      bool
+	formulas    formulaDumpConfig
+	progress    string
+	cacheDir    string
+	cacheTTL    time.Duration
+	noCache     bool
+}
+
+// formulaDumpConfig controls local formula post-processing of parse results.
+type formulaDumpConfig struct {
+	dir       string
+	format    string
+	normalize string
+	mode      client.FormulaMode
 }
 
 type autoConvertConfig struct {
@@ -82,40 +101,74 @@ type parseJobConfig struct {
 	outputDir string
 	failLog   string
 	auto      autoConvertConfig
+	store     *jobStore
+	resume    bool
+	formulas  formulaDumpConfig
+	progress  *transferProgress
+	cache     *client.FileCache
+	report    *parseReport
 }
 
 func (o *parseOptions) addFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.filePath, "file", "f", "", "PDF file path to upload")
 	cmd.Flags().StringVarP(&o.inputPath, "path", "p", "", "Path to a PDF file or a directory containing PDFs")
+	cmd.Flags().StringVar(&o.manifest, "manifest", "", "CSV (with header) or JSON-lines file listing PDFs with optional per-file path, convert_to, formula_mode and output")
+	cmd.Flags().StringVar(&o.reportPath, "report", "", "Write a JSON report with the UID, per-stage trace IDs and durations, pages, outputs and error of every file")
 	cmd.Flags().BoolVar(&o.wait, "wait", true, "Wait for parsing to finish")
 	cmd.Flags().DurationVar(&o.interval, "interval", 3*time.Second, "Polling interval for parsing status")
 	cmd.Flags().StringVarP(&o.output, "output", "o", "", "Optional path to save parsed result JSON")
 	cmd.Flags().StringVar(&o.outputDir, "output-dir", "", "Directory to store JSON results when parsing multiple files")
 	cmd.Flags().IntVar(&o.concurrency, "concurrency", 3, "Number of concurrent uploads when using --path")
 	cmd.Flags().BoolVar(&o.auto.enabled, "convert", true, "After parse success, trigger conversion and download")
-	cmd.Flags().StringVar(&o.auto.to, "convert-to", string(client.FormatMarkdown), "Target format for auto conversion: md|tex|docx|md_dollar")
+	cmd.Flags().StringVar(&o.auto.to, "convert-to", string(client.FormatMarkdown), "Target formats for auto conversion, comma-separated: md|tex|docx|md_dollar (e.g. md,docx,tex)")
 	cmd.Flags().StringVar(&o.auto.formula, "convert-formula-mode", string(client.FormulaModeNormal), "Formula mode for auto conversion: normal|dollar")
 	cmd.Flags().StringVar(&o.auto.downloadDir, "download-dir", ".", "Directory to store auto-downloaded converted files")
 	cmd.Flags().StringVar(&o.auto.filename, "convert-filename", "", "Optional output filename (md/tex) without extension during auto conversion")
 	cmd.Flags().BoolVar(&o.auto.mergeCrossPage, "convert-merge-cross-page-forms", false, "Merge cross page tables during auto conversion")
-	cmd.Flags().StringVar(&o.auto.output, "convert-output", "", "Override download path for auto conversion (defaults to UID-based name under download-dir)")
+	cmd.Flags().StringVar(&o.auto.output, "convert-output", "", "Override download path for auto conversion with a single format (defaults to UID-based name under download-dir)")
+	cmd.Flags().StringVar(&o.stateFile, "state-file", "", "JSON-lines job state file recording each file's progress for --resume (defaults to ~/.doc2x/jobs.jsonl with --resume)")
+	cmd.Flags().BoolVar(&o.resume, "resume", false, "Skip files finished in a previous run and re-poll uploaded tasks instead of re-uploading")
+	cmd.Flags().StringVar(&o.formulas.dir, "formulas-dir", "", "Directory to dump extracted formulas into, one file per PDF")
+	cmd.Flags().StringVar(&o.formulas.format, "formulas-format", "json", "Formula dump format: json|latex")
+	cmd.Flags().StringVar(&o.formulas.normalize, "normalize-formulas", "", "Rewrite formula delimiters in the saved result locally: normal|dollar|latex")
+	o.scan.addFlags(cmd)
+	cmd.Flags().StringVar(&o.progress, "progress", progressAuto, "Upload and download progress display: auto|bar|log|off (auto draws bars when stderr is a terminal)")
+	cmd.Flags().StringVar(&o.cacheDir, "cache-dir", defaultCacheDir(), "Directory of the local result cache keyed by PDF content (empty disables caching)")
+	cmd.Flags().DurationVar(&o.cacheTTL, "cache-ttl", client.DefaultCacheTTL, "How long cached results are reused; Doc2X expires tasks after 24h")
+	cmd.Flags().BoolVar(&o.noCache, "no-cache", false, "Neither reuse nor store cached results")
 }
 
 func (o *parseOptions) Complete() error {
-	if o.filePath == "" && o.inputPath == "" {
-		return errors.New("flag --file or --path is required")
+	if o.filePath == "" && o.inputPath == "" && o.manifest == "" {
+		return errors.New("flag --file, --path or --manifest is required")
 	}
 
 	if o.concurrency <= 0 {
 		o.concurrency = 3
 	}
 
+	if o.resume && o.stateFile == "" {
+		o.stateFile = defaultJobStorePath()
+	}
+
+	if o.manifest != "" {
+		if o.filePath != "" || o.inputPath != "" {
+			return errors.New("flag --manifest cannot be used with --file or --path")
+		}
+		files, err := loadManifest(o.manifest)
+		if err != nil {
+			return err
+		}
+		o.files = files
+		return nil
+	}
+
 	targetPath := o.filePath
 	if targetPath == "" {
 		targetPath = o.inputPath
 	}
 
-	files, err := collectInputFiles(targetPath)
+	files, err := collectInputFiles(targetPath, o.scan)
 	if err != nil {
 		return err
 	}
@@ -126,24 +179,81 @@ func (o *parseOptions) Complete() error {
 
 func (o *parseOptions) Validate() error {
 	if len(o.files) == 0 {
+		if o.manifest != "" {
+			return fmt.Errorf("no pdf files listed in %s", o.manifest)
+		}
 		return fmt.Errorf("no pdf files found in %s", o.inputPath)
 	}
+	if err := o.scan.validate(); err != nil {
+		return err
+	}
+	if o.resume && o.stateFile == "" {
+		return errors.New("flag --resume requires --state-file")
+	}
+	if o.auto.enabled {
+		formats, err := parseConvertFormats(o.auto.to)
+		if err != nil {
+			return err
+		}
+		if len(formats) > 1 && o.auto.output != "" {
+			return errors.New("flag --convert-output cannot be used with several --convert-to formats")
+		}
+		if o.manifest != "" && o.auto.output != "" {
+			return errors.New("flag --convert-output cannot be used with --manifest; set output per entry instead")
+		}
+	}
+
+	o.formulas.format = strings.ToLower(o.formulas.format)
+	if o.formulas.format != "json" && o.formulas.format != "latex" {
+		return fmt.Errorf("unsupported formulas format: %s", o.formulas.format)
+	}
+	if o.formulas.normalize != "" {
+		mode, err := parseFormulaMode(o.formulas.normalize)
+		if err != nil {
+			return err
+		}
+		o.formulas.mode = mode
+	}
+
+	switch o.progress {
+	case progressAuto, progressBar, progressLog, progressOff:
+	default:
+		return fmt.Errorf("unsupported progress mode: %s", o.progress)
+	}
+
+	if o.cacheTTL <= 0 {
+		return errors.New("flag --cache-ttl must be positive")
+	}
 	return nil
 }
 
 func (o *parseOptions) Run(cmd *cobra.Command) error {
 	apiKey, err := resolveAPIKey(o.opts)
 	if err != nil {
-		if logErr := logFailure(o.opts.failLogPath, "", "", err); logErr != nil {
+		if logErr := logFailure(o.opts.failLogPath, failureEntry{Stage: failStageInput}, err); logErr != nil {
 			return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
 		}
 		return err
 	}
 	o.apiKey = apiKey
 
-	cli := buildClient(o.apiKey, o.opts)
+	cli, err := buildClient(cmd, o.apiKey, o.opts)
+	if err != nil {
+		return err
+	}
 	ctx := cmd.Context()
 
+	store, err := openJobStore(o.stateFile)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	progress, err := newTransferProgress(cmd, o.progress)
+	if err != nil {
+		return err
+	}
+
 	jobCfg := parseJobConfig{
 		wait:      o.wait,
 		interval:  o.interval,
@@ -151,162 +261,280 @@ func (o *parseOptions) Run(cmd *cobra.Command) error {
 		outputDir: o.outputDir,
 		failLog:   o.opts.failLogPath,
 		auto:      o.auto,
+		store:     store,
+		resume:    o.resume,
+		formulas:  o.formulas,
+		progress:  progress,
+	}
+	if !o.noCache && o.cacheDir != "" {
+		jobCfg.cache = &client.FileCache{Dir: o.cacheDir, TTL: o.cacheTTL}
+	}
+	if o.reportPath != "" {
+		jobCfg.report = newParseReport(o.files)
 	}
 
 	if len(o.files) == 1 {
-		return handleParseFile(ctx, cmd, cli, o.files[0], jobCfg)
+		err = handleParseFile(ctx, cmd, cli, o.files[0], jobCfg)
+	} else {
+		err = runParseBatch(ctx, cmd, cli, o.files, o.concurrency, jobCfg)
 	}
 
-	return runParseBatch(ctx, cmd, cli, o.files, o.concurrency, jobCfg)
-}
-
-func collectInputFiles(p string) ([]string, error) {
-	info, err := os.Stat(p)
-	if err != nil {
-		return nil, fmt.Errorf("stat path: %w", err)
+	if jobCfg.report == nil {
+		return err
 	}
-
-	if info.Mode().IsRegular() {
-		if strings.EqualFold(filepath.Ext(p), ".pdf") {
-			return []string{p}, nil
+	if reportErr := jobCfg.report.write(o.reportPath); reportErr != nil {
+		if err != nil {
+			return fmt.Errorf("%w; also failed to write report: %v", err, reportErr)
 		}
-		return nil, fmt.Errorf("file is not a pdf: %s", p)
+		return reportErr
 	}
+	return err
+}
 
-	if !info.IsDir() {
-		return nil, fmt.Errorf("path is neither file nor directory: %s", p)
+func handleParseFile(ctx context.Context, cmd *cobra.Command, cli client.Client, in inputFile, job parseJobConfig) (err error) {
+	pdf, fileLabel := in.path, in.rel
+
+	report := job.report.file(in)
+	var outcome string // Report status when the run ends early without an error
+	defer func() {
+		report.finish(outcome, err)
+	}()
+
+	var hash string
+	record := func(fn func(*jobRecord)) error {
+		return job.store.update(pdf, hash, fn)
 	}
 
-	entries, err := os.ReadDir(p)
+	pipeline, sink, hooks, err := newParsePipeline(cmd, cli, in, job, record)
 	if err != nil {
-		return nil, fmt.Errorf("read dir: %w", err)
+		return err
 	}
+	hooks.report = report
 
-	var files []string
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
+	// The content hash keys the job store and the cache, which is only consulted when waiting.
+	var (
+		file *os.File
+		size int64
+	)
+	if job.store != nil || (job.cache != nil && job.wait) {
+		file, size, err = openPDF(pdf)
+		if err != nil {
+			return hooks.failed(client.StageEvent{Stage: client.StageUpload}, err)
 		}
-		if strings.EqualFold(filepath.Ext(entry.Name()), ".pdf") {
-			files = append(files, filepath.Join(p, entry.Name()))
+		defer file.Close()
+		hash, err = client.ContentHash(file)
+		if err != nil {
+			return hooks.failed(client.StageEvent{Stage: client.StageUpload}, fmt.Errorf("hash file %s: %w", pdf, err))
 		}
 	}
 
-	return files, nil
-}
+	uid, traceID, done, err := resumeParseJob(cmd, in, hash, job)
+	if err != nil || done {
+		outcome = reportSkipped
+		return err
+	}
 
-func handleParseFile(ctx context.Context, cmd *cobra.Command, cli client.Client, pdf string, job parseJobConfig) error {
-	fileLabel := filepath.Base(pdf)
+	if fn := job.progress.track(fileLabel); fn != nil {
+		ctx = client.ContextWithTransferProgress(ctx, fn)
+	}
+	if job.cache != nil && hash != "" {
+		ctx = client.ContextWithContentHash(ctx, hash)
+	}
 
-	file, err := os.Open(pdf)
-	if err != nil {
-		if logErr := logFailure(job.failLog, "", pdf, err); logErr != nil {
-			return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
+	if job.wait {
+		result, ok, err := pipeline.Cached(ctx, fileLabel)
+		if ok || err != nil {
+			return finishConvert(job, record, sink, result, err)
 		}
-		return fmt.Errorf("open file %s: %w", pdf, err)
 	}
-	defer file.Close()
 
-	preUpload, err := cli.PreUpload(ctx)
-	if err != nil {
-		if logErr := logFailure(job.failLog, "", pdf, err); logErr != nil {
-			return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
+	if uid != "" {
+		if !job.wait {
+			outcome = reportSubmitted
+			return printWithTrace(cmd, slog.LevelInfo, traceID, "Parse job already submitted",
+				slog.String("file", fileLabel),
+				slog.String("uid", uid),
+			)
 		}
-		return fmt.Errorf("preupload failed for %s: %w", pdf, err)
-	}
 
-	if err := printWithTrace(cmd, slog.LevelInfo, preUpload.TraceID, "Preupload completed",
-		slog.String("file", fileLabel),
-		slog.String("uid", preUpload.Data.UID),
-	); err != nil {
-		return err
+		hooks.resumed = true
+		result, err := pipeline.Continue(ctx, fileLabel, uid)
+		if !isExpiredParse(err) {
+			return finishConvert(job, record, sink, result, err)
+		}
+		if err := printWithTrace(cmd, slog.LevelWarn, traceID, "Resumed task expired, uploading again",
+			slog.String("file", fileLabel),
+			slog.String("uid", uid),
+		); err != nil {
+			return err
+		}
+		hooks.resumed = false
 	}
 
-	if err := cli.UploadToPresignedURLFrom(ctx, preUpload.Data.URL, file); err != nil {
-		if logErr := logFailure(job.failLog, preUpload.TraceID, pdf, err); logErr != nil {
-			return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
+	if file == nil {
+		file, size, err = openPDF(pdf)
+		if err != nil {
+			return hooks.failed(client.StageEvent{Stage: client.StageUpload}, err)
 		}
-		return fmt.Errorf("[%s] upload failed (trace-id: %s): %w", fileLabel, preUpload.TraceID, err)
+		defer file.Close()
+	} else if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return hooks.failed(client.StageEvent{Stage: client.StageUpload}, fmt.Errorf("rewind file %s: %w", pdf, err))
 	}
 
-	if err := printWithTrace(cmd, slog.LevelInfo, preUpload.TraceID, "Upload success",
-		slog.String("file", fileLabel),
-	); err != nil {
+	uid, traceID, err = pipeline.Upload(ctx, fileLabel, file, size)
+	if err != nil {
 		return err
 	}
 
 	if !job.wait {
-		return printWithTrace(cmd, slog.LevelInfo, preUpload.TraceID, "Submitted parse job",
+		outcome = reportSubmitted
+		return printWithTrace(cmd, slog.LevelInfo, traceID, "Submitted parse job",
 			slog.String("file", fileLabel),
-			slog.String("uid", preUpload.Data.UID),
+			slog.String("uid", uid),
 		)
 	}
 
-	status, err := cli.WaitForParsing(ctx, preUpload.Data.UID, job.interval)
+	result, err := pipeline.Continue(ctx, fileLabel, uid)
+	return finishConvert(job, record, sink, result, err)
+}
+
+// openPDF opens pdf for upload and returns its size.
+func openPDF(pdf string) (*os.File, int64, error) {
+	file, err := os.Open(pdf)
 	if err != nil {
-		if logErr := logFailure(job.failLog, preUpload.TraceID, pdf, err); logErr != nil {
-			return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
-		}
-		return err
+		return nil, 0, fmt.Errorf("open file %s: %w", pdf, err)
 	}
-
-	if status.Data == nil {
-		msgErr := fmt.Errorf("parse finished uid=%s but data is nil", preUpload.Data.UID)
-		if logErr := logFailure(job.failLog, status.TraceID, pdf, msgErr); logErr != nil {
-			return fmt.Errorf("%w; also failed to write fail log: %v", msgErr, logErr)
-		}
-		return printWithTrace(cmd, slog.LevelError, status.TraceID, "Parse finished without data",
-			slog.String("file", fileLabel),
-			slog.String("uid", preUpload.Data.UID),
-		)
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("stat file %s: %w", pdf, err)
 	}
+	return file, info.Size(), nil
+}
 
-	pageCount := 0
-	if status.Data.Result != nil {
-		pageCount = len(status.Data.Result.Pages)
-	}
+// newParsePipeline builds the SDK pipeline for one input file from the parse flags and its manifest
+// entry. Results and downloads mirror the file's place under the scanned directory, or the output
+// name given by the manifest.
+func newParsePipeline(cmd *cobra.Command, cli client.Client, in inputFile, job parseJobConfig, record func(func(*jobRecord)) error) (*client.Pipeline, *cliSink, *cliHooks, error) {
+	name := filepath.FromSlash(in.outputName())
 
-	if err := printWithTrace(cmd, slog.LevelInfo, status.TraceID, "Parse success",
-		slog.String("file", fileLabel),
-		slog.String("uid", preUpload.Data.UID),
-		slog.Int("pages", pageCount),
-	); err != nil {
-		return err
+	resultPath := job.output
+	if job.outputDir != "" {
+		resultPath = filepath.Join(job.outputDir, name+".json")
 	}
 
-	target := job.output
-	if job.outputDir != "" {
-		target = filepath.Join(job.outputDir, changeExt(filepath.Base(pdf), ".json"))
+	sink := &cliSink{
+		cmd:        cmd,
+		resultPath: resultPath,
+		formulas:   job.formulas,
+		output:     job.auto.output,
+		dir:        filepath.Join(job.auto.downloadDir, filepath.Dir(name)),
+	}
+	if in.output != "" {
+		sink.name = filepath.Base(name)
+	}
+	hooks := &cliHooks{
+		cmd:      cmd,
+		file:     in.path,
+		label:    in.rel,
+		failLog:  job.failLog,
+		sink:     sink,
+		formulas: newFailedFormulas(job.formulas),
+		record:   record,
+	}
+	opts := client.PipelineOptions{
+		PollInterval: job.interval,
+		Sink:         sink,
+		Cache:        job.cache,
+		Hooks:        client.PipelineHooks{AfterStage: hooks.after},
 	}
 
-	if target != "" && status.Data.Result != nil {
-		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
-			if logErr := logFailure(job.failLog, status.TraceID, pdf, err); logErr != nil {
-				return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
-			}
-			return fmt.Errorf("create output dir: %w", err)
+	if job.auto.enabled {
+		to, formula := job.auto.to, job.auto.formula
+		if in.convertTo != "" {
+			to = in.convertTo
 		}
-		if err := writeJSON(target, status.Data.Result); err != nil {
-			if logErr := logFailure(job.failLog, status.TraceID, pdf, err); logErr != nil {
-				return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
-			}
-			return err
+		if in.formulaMode != "" {
+			formula = in.formulaMode
 		}
-		if err := printWithTrace(cmd, slog.LevelInfo, status.TraceID, "Saved parse result",
-			slog.String("file", fileLabel),
-			slog.String("path", target),
-		); err != nil {
-			return err
+		formats, err := parseConvertFormats(to)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		mode, err := parseFormulaMode(formula)
+		if err != nil {
+			return nil, nil, nil, err
 		}
+		opts.Formats = formats
+		opts.FormulaMode = mode
+		opts.Filename = job.auto.filename
+		opts.MergeCrossPageForms = job.auto.mergeCrossPage
+		sink.multi = len(formats) > 1
 	}
+	hooks.convert = failedConvertFor(opts, sink)
 
-	if job.auto.enabled {
-		if err := autoConvertAndDownload(ctx, cmd, cli, preUpload.Data.UID, job.auto, job.interval, job.failLog, filepath.Base(pdf)); err != nil {
-			return err
+	return client.NewPipeline(cli, opts), sink, hooks, nil
+}
+
+// isExpiredParse reports whether a resumed task could not be polled because it no longer exists.
+func isExpiredParse(err error) bool {
+	var stageErr *client.StageError
+	return errors.As(err, &stageErr) && stageErr.Stage == client.StageParse && errors.Is(err, client.ErrTaskExpired)
+}
+
+// finishConvert records the conversion outcome of a pipeline run that got past parsing.
+func finishConvert(job parseJobConfig, record func(func(*jobRecord)) error, sink *cliSink, result *client.PipelineResult, err error) error {
+	if !job.auto.enabled || result == nil || result.Status == nil {
+		return err
+	}
+
+	if recErr := record(func(rec *jobRecord) {
+		rec.DownloadPaths = sink.paths
+		if err != nil {
+			rec.ConvertState = jobStateFailed
+			rec.Error = err.Error()
+			return
+		}
+		rec.ConvertState = jobStateSuccess
+	}); recErr != nil {
+		if err != nil {
+			return fmt.Errorf("%w; also failed to update state file: %v", err, recErr)
 		}
+		return recErr
 	}
+	return err
+}
 
-	return nil
+// resumeParseJob consults the job store when --resume is set. It reports done for files that need no
+// further work and returns the UID of an already uploaded task so it can be re-polled instead of re-uploaded.
+func resumeParseJob(cmd *cobra.Command, in inputFile, hash string, job parseJobConfig) (uid, traceID string, done bool, err error) {
+	if !job.resume {
+		return "", "", false, nil
+	}
+
+	rec, ok := job.store.lookup(in.path, hash)
+	if !ok {
+		return "", "", false, nil
+	}
+
+	if rec.finished(job.auto.enabled) {
+		err := printWithTrace(cmd, slog.LevelInfo, rec.TraceID, "Skipped finished file",
+			slog.String("file", in.rel),
+			slog.String("uid", rec.UID),
+		)
+		return "", "", true, err
+	}
+
+	if rec.UID == "" || rec.ParseState == jobStateFailed {
+		return "", "", false, nil
+	}
+
+	err = printWithTrace(cmd, slog.LevelInfo, rec.TraceID, "Resuming parse job",
+		slog.String("file", in.rel),
+		slog.String("uid", rec.UID),
+		slog.String("state", rec.ParseState),
+	)
+	return rec.UID, rec.TraceID, false, err
 }
 
 func changeExt(name, ext string) string {
@@ -314,7 +542,7 @@ func changeExt(name, ext string) string {
 	return base + ext
 }
 
-func runParseBatch(ctx context.Context, cmd *cobra.Command, cli client.Client, files []string, concurrency int, job parseJobConfig) error {
+func runParseBatch(ctx context.Context, cmd *cobra.Command, cli client.Client, files []inputFile, concurrency int, job parseJobConfig) error {
 	eg, ctx := errgroup.WithContext(ctx)
 	if concurrency > 0 {
 		eg.SetLimit(concurrency)
@@ -325,10 +553,10 @@ func runParseBatch(ctx context.Context, cmd *cobra.Command, cli client.Client, f
 		mu   sync.Mutex
 	)
 
-	for _, pdf := range files {
-		pdf := pdf
+	for _, in := range files {
+		in := in
 		eg.Go(func() error {
-			if err := handleParseFile(ctx, cmd, cli, pdf, job); err != nil {
+			if err := handleParseFile(ctx, cmd, cli, in, job); err != nil {
 				mu.Lock()
 				errs = append(errs, err)
 				mu.Unlock()
@@ -348,92 +576,23 @@ func runParseBatch(ctx context.Context, cmd *cobra.Command, cli client.Client, f
 	return nil
 }
 
-func autoConvertAndDownload(ctx context.Context, cmd *cobra.Command, cli client.Client, uid string, cfg autoConvertConfig, interval time.Duration, failLog string, label string) error {
-	format, err := parseConvertFormat(cfg.to)
-	if err != nil {
-		if logErr := logFailure(failLog, "", uid, err); logErr != nil {
-			return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
-		}
-		return err
-	}
-
-	mode, err := parseFormulaMode(cfg.formula)
-	if err != nil {
-		if logErr := logFailure(failLog, "", uid, err); logErr != nil {
-			return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
-		}
-		return err
-	}
-
-	req := client.ConvertRequest{
-		UID:                 uid,
-		To:                  format,
-		FormulaMode:         mode,
-		Filename:            cfg.filename,
-		MergeCrossPageForms: cfg.mergeCrossPage,
-	}
-
-	resp, err := cli.ConvertParse(ctx, req)
-	if err != nil {
-		if logErr := logFailure(failLog, "", uid, err); logErr != nil {
-			return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
-		}
-		return err
-	}
-
-	if err := printWithTrace(cmd, slog.LevelInfo, resp.TraceID, "Convert requested",
-		slog.String("file", label),
-		slog.String("uid", uid),
-		slog.String("status", string(resp.Data.Status)),
-	); err != nil {
-		return err
-	}
-
-	result, err := cli.WaitForConversion(ctx, uid, interval)
-	if err != nil {
-		if logErr := logFailure(failLog, resp.TraceID, uid, err); logErr != nil {
-			return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
-		}
-		return err
-	}
-
-	if err := printWithTrace(cmd, slog.LevelInfo, result.TraceID, "Conversion finished",
-		slog.String("file", label),
-		slog.String("status", string(result.Data.Status)),
-		slog.String("uid", uid),
-		slog.String("url", result.Data.URL),
-	); err != nil {
-		return err
-	}
-
-	if cfg.output != "" {
-		if err := downloadToFile(ctx, cli, result.Data.URL, cfg.output); err != nil {
-			if logErr := logFailure(failLog, result.TraceID, uid, err); logErr != nil {
-				return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
-			}
-			return err
-		}
-		return printWithTrace(cmd, slog.LevelInfo, result.TraceID, "Downloaded converted file",
-			slog.String("file", label),
-			slog.String("path", cfg.output),
-		)
-	}
-
-	downloadDir := cfg.downloadDir
-	if downloadDir == "" {
-		downloadDir = "."
+// dumpFormulas writes the formulas of result to <dir>/<name>.formulas.{json,tex} and returns the path.
+func dumpFormulas(result *client.ParseResult, cfg formulaDumpConfig, name string) (string, error) {
+	name = filepath.FromSlash(name)
+	if err := os.MkdirAll(filepath.Join(cfg.dir, filepath.Dir(name)), 0o755); err != nil {
+		return "", fmt.Errorf("create formulas dir: %w", err)
 	}
-	outPath := filepath.Join(downloadDir, defaultDownloadName(result.Data.URL, uid))
 
-	if err := downloadToFile(ctx, cli, result.Data.URL, outPath); err != nil {
-		if logErr := logFailure(failLog, result.TraceID, uid, err); logErr != nil {
-			return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
-		}
-		return err
+	formulas := client.ExtractFormulas(result)
+	if cfg.format == "latex" {
+		target := filepath.Join(cfg.dir, changeExt(name, ".formulas.tex"))
+		return target, writeFileWith(target, func(f *os.File) error {
+			return client.WriteFormulasLatex(f, formulas)
+		})
 	}
 
-	return printWithTrace(cmd, slog.LevelInfo, result.TraceID, "Downloaded converted file",
-		slog.String("file", label),
-		slog.String("path", outPath),
-	)
+	target := filepath.Join(cfg.dir, changeExt(name, ".formulas.json"))
+	return target, writeFileWith(target, func(f *os.File) error {
+		return client.WriteFormulasJSON(f, formulas)
+	})
 }
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+// cliSink stores pipeline outputs where the parse and convert flags point.
+type cliSink struct {
+	cmd        *cobra.Command
+	resultPath string // Parse result JSON path; empty skips writing it
+	formulas   formulaDumpConfig
+	output     string // Download path when a single format is converted
+	dir        string // Download directory otherwise
+	name       string // Download file name without extension; empty derives it from the UID
+	multi      bool
+	paths      []string // Downloaded files, in order
+}
+
+func (s *cliSink) WriteResult(ctx context.Context, name string, status *client.StatusResponse) error {
+	if s.formulas.mode != "" {
+		normalized, err := status.Data.Result.NormalizeFormulas(s.formulas.mode)
+		if err != nil {
+			return err
+		}
+		status.Data.Result = normalized
+	}
+
+	if s.resultPath != "" {
+		if err := os.MkdirAll(filepath.Dir(s.resultPath), 0o755); err != nil {
+			return fmt.Errorf("create output dir: %w", err)
+		}
+		if err := writeJSON(s.resultPath, status.Data.Result); err != nil {
+			return err
+		}
+		if err := printWithTrace(s.cmd, slog.LevelInfo, status.TraceID, "Saved parse result",
+			slog.String("file", name),
+			slog.String("path", s.resultPath),
+		); err != nil {
+			return err
+		}
+	}
+
+	if s.formulas.dir != "" {
+		formulasPath, err := dumpFormulas(status.Data.Result, s.formulas, name)
+		if err != nil {
+			return err
+		}
+		return printWithTrace(s.cmd, slog.LevelInfo, status.TraceID, "Saved formulas",
+			slog.String("file", name),
+			slog.String("path", formulasPath),
+		)
+	}
+
+	return nil
+}
+
+func (s *cliSink) WriteFile(ctx context.Context, name string, out client.ConvertOutput, download func(io.Writer) error) error {
+	target := s.output
+	if target == "" || s.multi {
+		name := convertDownloadName(out.URL, out.Request.UID, out.Request.To, s.multi)
+		if s.name != "" {
+			ext := filepath.Ext(name)
+			name = s.name + ext
+			if s.multi {
+				name = s.name + "_" + string(out.Request.To) + ext
+			}
+		}
+		target = filepath.Join(s.dir, name)
+	}
+
+	if err := writeDownload(target, download); err != nil {
+		return err
+	}
+	s.paths = append(s.paths, target)
+	return nil
+}
+
+// lastPath returns the most recently downloaded file.
+func (s *cliSink) lastPath() string {
+	if len(s.paths) == 0 {
+		return ""
+	}
+	return s.paths[len(s.paths)-1]
+}
+
+// cliHooks logs pipeline stages, writes the fail log and keeps the job store in sync.
+type cliHooks struct {
+	cmd      *cobra.Command
+	file     string // Input file; empty for UID-only runs
+	label    string // Input name for log lines; empty for UID-only runs
+	failLog  string
+	sink     *cliSink
+	convert  *failedConvert // Conversion settings kept in the fail log for doc2x retry; nil for none
+	formulas *failedFormulas
+	record   func(func(*jobRecord)) error
+	report   *fileReport // Nil without --report
+	resumed  bool        // Expired parse tasks are re-uploaded instead of reported
+}
+
+func (h *cliHooks) after(ctx context.Context, ev client.StageEvent) error {
+	h.report.stage(ev)
+
+	switch ev.Stage {
+	case client.StageUpload:
+		if ev.Err != nil {
+			return h.fail(h.entry(ev), ev.Err)
+		}
+		if err := printWithTrace(h.cmd, slog.LevelInfo, ev.TraceID, "Upload success", h.attrs(
+			slog.String("uid", ev.UID),
+		)...); err != nil {
+			return err
+		}
+		return h.record(func(rec *jobRecord) {
+			rec.UID = ev.UID
+			rec.ParseState = jobStateUploaded
+			rec.ConvertState = ""
+			rec.TraceID = ev.TraceID
+			rec.Error = ""
+		})
+
+	case client.StageParse:
+		if ev.Err != nil {
+			if h.resumed && errors.Is(ev.Err, client.ErrTaskExpired) {
+				return nil
+			}
+			if err := h.fail(h.entry(ev), ev.Err); err != nil {
+				return err
+			}
+			// Only definitive parse failures mark the record as failed; timeouts and cancellations
+			// keep the UID so --resume can poll it again.
+			return h.record(func(rec *jobRecord) {
+				if errors.Is(ev.Err, client.ErrParseFailed) {
+					rec.ParseState = jobStateFailed
+				}
+				rec.Error = ev.Err.Error()
+			})
+		}
+		if h.report != nil && h.sink.resultPath != "" {
+			h.report.ResultPath = h.sink.resultPath
+		}
+		msg := "Parse success"
+		if ev.Cached {
+			msg = "Using cached parse result"
+		}
+		if err := printWithTrace(h.cmd, slog.LevelInfo, ev.TraceID, msg, h.attrs(
+			slog.String("uid", ev.UID),
+			slog.Int("pages", len(ev.Status.Data.Result.Pages)),
+		)...); err != nil {
+			return err
+		}
+		return h.record(func(rec *jobRecord) {
+			rec.UID = ev.UID
+			rec.ParseState = jobStateSuccess
+			rec.ResultPath = h.sink.resultPath
+			rec.TraceID = ev.TraceID
+			rec.Error = ""
+		})
+
+	case client.StageConvert:
+		for _, out := range ev.Outputs {
+			if out.Err != nil {
+				entry := h.entry(ev)
+				entry.TraceID, entry.Convert = out.TraceID, h.convert.only(out.Request.To)
+				if err := h.fail(entry, fmt.Errorf("convert %s: %w", out.Request.To, out.Err)); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := printWithTrace(h.cmd, slog.LevelInfo, out.TraceID, "Conversion finished", h.attrs(
+				slog.String("uid", ev.UID),
+				slog.String("format", string(out.Request.To)),
+				slog.String("url", out.URL),
+				slog.Bool("cached", out.Cached),
+			)...); err != nil {
+				return err
+			}
+		}
+		if ev.Err != nil && len(ev.Outputs) == 0 {
+			return h.fail(h.entry(ev), ev.Err)
+		}
+		return nil
+
+	case client.StageDownload:
+		if ev.Err != nil {
+			entry := h.entry(ev)
+			entry.URL, entry.Convert = ev.Output.URL, h.convert.only(ev.Output.Request.To)
+			return h.fail(entry, ev.Err)
+		}
+		if h.report != nil {
+			h.report.Outputs = append(h.report.Outputs, h.sink.lastPath())
+		}
+		return printWithTrace(h.cmd, slog.LevelInfo, ev.TraceID, "Downloaded converted file", h.attrs(
+			slog.String("format", string(ev.Output.Request.To)),
+			slog.String("path", h.sink.lastPath()),
+			slog.Bool("cached", ev.Cached),
+		)...)
+	}
+
+	return nil
+}
+
+// entry describes a failure in stage for the fail log, with what doc2x retry needs to run the
+// stage and the ones after it again.
+func (h *cliHooks) entry(ev client.StageEvent) failureEntry {
+	entry := failureEntry{
+		Stage:   string(ev.Stage),
+		File:    h.file,
+		UID:     ev.UID,
+		TraceID: ev.TraceID,
+		Convert: h.convert,
+	}
+	if ev.Stage == client.StageUpload || ev.Stage == client.StageParse {
+		entry.ResultPath = h.sink.resultPath
+		entry.Formulas = h.formulas
+	}
+	return entry
+}
+
+// fail appends err to the fail log; only a failure to write the log is returned, the stage error
+// itself is returned by the pipeline.
+func (h *cliHooks) fail(entry failureEntry, err error) error {
+	if logErr := logFailure(h.failLog, entry, err); logErr != nil {
+		return fmt.Errorf("%w; also failed to write fail log: %v", err, logErr)
+	}
+	return nil
+}
+
+// failed logs err, raised in the stage of ev outside the pipeline, and returns it.
+func (h *cliHooks) failed(ev client.StageEvent, err error) error {
+	if logErr := h.fail(h.entry(ev), err); logErr != nil {
+		return logErr
+	}
+	return err
+}
+
+// failedConvertFor records the conversion settings of opts and where sink puts the files.
+func failedConvertFor(opts client.PipelineOptions, sink *cliSink) *failedConvert {
+	if len(opts.Formats) == 0 {
+		return nil
+	}
+	return &failedConvert{
+		Formats:             opts.Formats,
+		FormulaMode:         opts.FormulaMode,
+		Filename:            opts.Filename,
+		MergeCrossPageForms: opts.MergeCrossPageForms,
+		Download:            opts.Sink != nil,
+		Dir:                 sink.dir,
+		Name:                sink.name,
+		Output:              sink.output,
+		Multi:               sink.multi,
+	}
+}
+
+func (h *cliHooks) attrs(extra ...slog.Attr) []slog.Attr {
+	if h.label == "" {
+		return extra
+	}
+	return append([]slog.Attr{slog.String("file", h.label)}, extra...)
+}
+
+func noRecord(func(*jobRecord)) error {
+	return nil
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+// progressLogger logs status transitions, progress changes and transient retries reported by the client.
+func progressLogger(cmd *cobra.Command) client.ProgressFunc {
+	var (
+		mu   sync.Mutex
+		last = make(map[string]int)
+	)
+
+	return func(event client.ProgressEvent) {
+		key := string(event.Operation) + "/" + event.UID
+
+		if event.Kind == client.ProgressEventRetry {
+			_ = printWithTrace(cmd, slog.LevelWarn, event.TraceID, "Retrying status poll",
+				slog.String("operation", string(event.Operation)),
+				slog.String("uid", event.UID),
+				slog.Int("retry", event.Retries),
+				slog.String("error", event.Err.Error()),
+			)
+			return
+		}
+
+		mu.Lock()
+		prev, seen := last[key]
+		last[key] = event.Progress
+		mu.Unlock()
+
+		if seen && event.Kind != client.ProgressEventStatus && prev == event.Progress {
+			return
+		}
+
+		_ = printWithTrace(cmd, slog.LevelInfo, event.TraceID, "Task progress",
+			slog.String("operation", string(event.Operation)),
+			slog.String("uid", event.UID),
+			slog.String("status", event.Status),
+			slog.Int("progress", event.Progress),
+			slog.Int("poll", event.Poll),
+		)
+	}
+}
+
+// Transfer progress display modes for --progress.
+const (
+	progressAuto = "auto" // Bars when stderr is a terminal, nothing otherwise
+	progressBar  = "bar"
+	progressLog  = "log"
+	progressOff  = "off"
+)
+
+const (
+	progressBarWidth = 24
+	// progressLogStep is the percentage between two "Transfer progress" log lines.
+	progressLogStep = 10
+)
+
+// activeBars is the bar display currently on the terminal, if any; logWith clears it around log lines
+// so bars and logs do not overwrite each other.
+var (
+	activeBarsMu sync.Mutex
+	activeBars   *transferBars
+)
+
+// transferProgress hands out per-file transfer callbacks for the chosen --progress mode.
+type transferProgress struct {
+	cmd  *cobra.Command
+	bars *transferBars // nil in log mode
+}
+
+// newTransferProgress returns nil when progress is disabled or mode is auto without a terminal.
+func newTransferProgress(cmd *cobra.Command, mode string) (*transferProgress, error) {
+	switch mode {
+	case progressAuto:
+		if !isTerminal(cmd.ErrOrStderr()) {
+			return nil, nil
+		}
+	case progressBar:
+	case progressLog:
+		return &transferProgress{cmd: cmd}, nil
+	case progressOff:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported progress mode: %s", mode)
+	}
+
+	bars := &transferBars{w: cmd.ErrOrStderr()}
+	activeBarsMu.Lock()
+	activeBars = bars
+	activeBarsMu.Unlock()
+	return &transferProgress{cmd: cmd, bars: bars}, nil
+}
+
+// track returns the callback for one file. It is nil-safe and returns nil when progress is disabled.
+func (p *transferProgress) track(label string) client.TransferProgressFunc {
+	if p == nil {
+		return nil
+	}
+	if p.bars != nil {
+		return p.bars.track(label)
+	}
+
+	next := 0
+	return func(ev client.TransferProgress) {
+		percent := int(ev.Percent())
+		if ev.Done {
+			next = 0
+			return
+		}
+		if percent < next {
+			return
+		}
+		next = percent - percent%progressLogStep + progressLogStep
+
+		attrs := []slog.Attr{
+			slog.String("file", label),
+			slog.String("operation", string(ev.Operation)),
+			slog.Int64("bytes", ev.Bytes),
+			slog.Int64("total", ev.Total),
+			slog.Int("percent", percent),
+			slog.String("rate", formatBytes(int64(ev.Rate))+"/s"),
+		}
+		if ev.ETA > 0 {
+			attrs = append(attrs, slog.Duration("eta", ev.ETA.Round(time.Second)))
+		}
+		_ = printOut(p.cmd, "Transfer progress", attrs...)
+	}
+}
+
+// transferBars draws one line per active transfer below the log output.
+type transferBars struct {
+	mu    sync.Mutex
+	w     io.Writer
+	bars  []*transferBar
+	drawn int // Lines currently on screen
+}
+
+type transferBar struct {
+	label string
+	last  client.TransferProgress
+}
+
+func (b *transferBars) track(label string) client.TransferProgressFunc {
+	bar := &transferBar{label: label}
+
+	// The same bar is reused for every transfer of the file: shown while one runs, removed when it is done.
+	return func(ev client.TransferProgress) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		bar.last = ev
+		b.clearLocked()
+		if !slices.Contains(b.bars, bar) {
+			b.bars = append(b.bars, bar)
+		}
+		if ev.Done {
+			b.bars = slices.DeleteFunc(b.bars, func(other *transferBar) bool { return other == bar })
+		}
+		b.drawLocked()
+	}
+}
+
+// suspend clears the bars while fn writes to the terminal and draws them again afterwards.
+func (b *transferBars) suspend(fn func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.clearLocked()
+	fn()
+	b.drawLocked()
+}
+
+func (b *transferBars) clearLocked() {
+	if b.drawn > 0 {
+		// Move to the first bar line and erase everything below it.
+		fmt.Fprintf(b.w, "\x1b[%dF\x1b[J", b.drawn)
+	}
+	b.drawn = 0
+}
+
+func (b *transferBars) drawLocked() {
+	for _, bar := range b.bars {
+		fmt.Fprintln(b.w, bar.render())
+		b.drawn++
+	}
+}
+
+func (bar *transferBar) render() string {
+	ev := bar.last
+	label := bar.label
+	if runes := []rune(label); len(runes) > 30 {
+		label = string(runes[:29]) + "…"
+	}
+
+	direction := "upload"
+	if ev.Operation == client.OperationDownloadFile {
+		direction = "download"
+	}
+	label = fmt.Sprintf("%-8s %-30s", direction, label)
+
+	if ev.Total <= 0 {
+		return fmt.Sprintf("%s %10s %10s/s", label, formatBytes(ev.Bytes), formatBytes(int64(ev.Rate)))
+	}
+
+	filled := min(int(ev.Bytes*progressBarWidth/ev.Total), progressBarWidth)
+	eta := "--"
+	if ev.ETA > 0 {
+		eta = ev.ETA.Round(time.Second).String()
+	}
+	return fmt.Sprintf("%s [%s%s] %5.1f%% %10s/s ETA %s",
+		label,
+		strings.Repeat("=", filled),
+		strings.Repeat(" ", progressBarWidth-filled),
+		ev.Percent(),
+		formatBytes(int64(ev.Rate)),
+		eta,
+	)
+}
+
+// withBarsSuspended runs fn with any active progress bars cleared from the terminal.
+func withBarsSuspended(fn func()) {
+	activeBarsMu.Lock()
+	bars := activeBars
+	activeBarsMu.Unlock()
+
+	if bars == nil {
+		fn()
+		return
+	}
+	bars.suspend(fn)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// formatBytes renders n with a binary unit, e.g. "12.3 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
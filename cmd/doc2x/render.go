@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hsn0918/doc2x-client/render"
+)
+
+// KaTeX distribution files inlined by --katex-dir, relative to the directory.
+var katexAssets = []string{"katex.min.css", "katex.min.js", "contrib/auto-render.min.js"}
+
+var (
+	cssURLPattern = regexp.MustCompile(`url\(\s*['"]?([^'")]+?)['"]?\s*\)`)
+	fontMIMETypes = map[string]string{".woff2": "font/woff2", ".woff": "font/woff", ".ttf": "font/ttf", ".otf": "font/otf"}
+)
+
+func newRenderCmd(opts *cliOptions) *cobra.Command {
+	ro := &renderOptions{
+		opts: opts,
+	}
+
+	cmd := &cobra.Command{
+		Use:               "render",
+		Short:             "Render a parse result into a self-contained local document",
+		ValidArgsFunction: positionalAlwaysFlags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ro.Validate(); err != nil {
+				return err
+			}
+
+			return ro.Run(cmd)
+		},
+	}
+
+	ro.addFlags(cmd)
+
+	return cmd
+}
+
+type renderOptions struct {
+	source   resultSource
+	format   string
+	output   string
+	title    string
+	katexDir string
+	opts     *cliOptions
+}
+
+func (o *renderOptions) addFlags(cmd *cobra.Command) {
+	o.source.addFlags(cmd)
+	cmd.Flags().StringVar(&o.format, "format", "html", "Output format: html")
+	cmd.Flags().StringVarP(&o.output, "output", "o", "", "Output path (defaults to <input name>.html in the current directory)")
+	cmd.Flags().StringVar(&o.title, "title", "", "Document title (defaults to the input name)")
+	cmd.Flags().StringVar(&o.katexDir, "katex-dir", "", "Local KaTeX dist directory whose stylesheet, scripts and fonts are inlined so math renders offline")
+}
+
+func (o *renderOptions) Validate() error {
+	if err := o.source.validate(); err != nil {
+		return err
+	}
+
+	o.format = strings.ToLower(o.format)
+	if o.format != "html" {
+		return fmt.Errorf("unsupported render format: %s", o.format)
+	}
+	return nil
+}
+
+func (o *renderOptions) Run(cmd *cobra.Command) error {
+	result, err := o.source.load(cmd, o.opts)
+	if err != nil {
+		return err
+	}
+
+	renderOpts := render.Options{Title: o.title}
+	if renderOpts.Title == "" {
+		renderOpts.Title = o.source.name()
+	}
+	if o.katexDir != "" {
+		head, err := katexHead(o.katexDir)
+		if err != nil {
+			return err
+		}
+		renderOpts.Head = head
+	}
+
+	target := o.output
+	if target == "" {
+		target = o.source.name() + ".html"
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("create output dir: %w", err)
+	}
+
+	if err := writeFileWith(target, func(f *os.File) error {
+		return render.HTML(f, result.Document(), renderOpts)
+	}); err != nil {
+		return err
+	}
+
+	return printOut(cmd, "Rendered document",
+		slog.String("format", o.format),
+		slog.Int("pages", len(result.Pages)),
+		slog.String("path", target),
+	)
+}
+
+// katexHead inlines the KaTeX stylesheet and scripts from dir and enables auto-render for
+// \( \) and \[ \] delimiters. Fonts referenced by the stylesheet are embedded as data URIs, so the
+// HTML file renders math offline wherever it is moved.
+func katexHead(dir string) (template.HTML, error) {
+	var sb strings.Builder
+	for _, name := range katexAssets {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return "", fmt.Errorf("read katex asset: %w", err)
+		}
+		if strings.HasSuffix(name, ".css") {
+			css, err := inlineCSSFonts(string(content), path.Dir(name), dir)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&sb, "<style>\n%s\n</style>\n", css)
+		} else {
+			fmt.Fprintf(&sb, "<script>\n%s\n</script>\n", strings.ReplaceAll(string(content), "</script", `<\/script`))
+		}
+	}
+	sb.WriteString(`<script>
+document.addEventListener("DOMContentLoaded", function () {
+  renderMathInElement(document.body, {
+    delimiters: [
+      {left: "\\[", right: "\\]", display: true},
+      {left: "\\(", right: "\\)", display: false}
+    ],
+    throwOnError: false
+  });
+});
+</script>
+`)
+	return template.HTML(sb.String()), nil
+}
+
+// inlineCSSFonts replaces the url() references to font files in css with data URIs. References
+// resolve against base, the stylesheet's directory relative to dir; other URLs are left alone.
+func inlineCSSFonts(css, base, dir string) (string, error) {
+	var err error
+	out := cssURLPattern.ReplaceAllStringFunc(css, func(m string) string {
+		ref := cssURLPattern.FindStringSubmatch(m)[1]
+		if i := strings.IndexAny(ref, "?#"); i >= 0 {
+			ref = ref[:i]
+		}
+		mimeType, ok := fontMIMETypes[strings.ToLower(path.Ext(ref))]
+		if !ok || err != nil || strings.Contains(ref, ":") || path.IsAbs(ref) {
+			return m
+		}
+		data, readErr := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path.Join(base, ref))))
+		if readErr != nil {
+			err = fmt.Errorf("read katex font: %w", readErr)
+			return m
+		}
+		return "url(data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data) + ")"
+	})
+	return out, err
+}
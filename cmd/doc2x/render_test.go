@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// katexDist lays out a minimal KaTeX dist directory with a stylesheet referencing its fonts.
+func katexDist(t *testing.T, css string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "contrib"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "fonts"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "katex.min.css"), css)
+	writeFile(t, filepath.Join(dir, "katex.min.js"), `var katex="</script>";`)
+	writeFile(t, filepath.Join(dir, "contrib", "auto-render.min.js"), "function renderMathInElement(){}")
+	writeFile(t, filepath.Join(dir, "fonts", "KaTeX_Main-Regular.woff2"), "woff2 font")
+	writeFile(t, filepath.Join(dir, "fonts", "KaTeX_Main-Regular.ttf"), "ttf font")
+	return dir
+}
+
+func TestKatexHeadInlinesFonts(t *testing.T) {
+	dir := katexDist(t, `@font-face{font-family:KaTeX_Main;src:url(fonts/KaTeX_Main-Regular.woff2) format("woff2"),`+
+		`url("fonts/KaTeX_Main-Regular.ttf?v=1") format("truetype")}.katex{background:url(https://cdn.example/bg.png)}`)
+
+	head, err := katexHead(dir)
+	if err != nil {
+		t.Fatalf("katexHead: %v", err)
+	}
+	out := string(head)
+	for _, want := range []string{
+		"url(data:font/woff2;base64," + base64.StdEncoding.EncodeToString([]byte("woff2 font")) + `) format("woff2")`,
+		"url(data:font/ttf;base64," + base64.StdEncoding.EncodeToString([]byte("ttf font")) + `) format("truetype")`,
+		"url(https://cdn.example/bg.png)",
+		`var katex="<\/script>";`,
+		"renderMathInElement(document.body",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("head lacks %q", want)
+		}
+	}
+	if strings.Contains(out, "url(fonts/") || strings.Contains(out, `url("fonts/`) {
+		t.Error("head still references fonts relative to the HTML file")
+	}
+}
+
+func TestKatexHeadMissingFont(t *testing.T) {
+	dir := katexDist(t, `@font-face{src:url(fonts/KaTeX_AMS-Regular.woff2) format("woff2")}`)
+	if _, err := katexHead(dir); err == nil || !strings.Contains(err.Error(), "read katex font") {
+		t.Errorf("katexHead error = %v, want the missing font reported", err)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+// Outcomes of a file in the parse report.
+const (
+	reportSuccess   = "success"
+	reportFailed    = "failed"
+	reportSkipped   = "skipped"   // Finished in an earlier run (--resume)
+	reportSubmitted = "submitted" // Uploaded without waiting (--wait=false)
+)
+
+// parseReport is the --report document of a parse run, so CI jobs can act on partial failure.
+type parseReport struct {
+	StartedAt  time.Time    `json:"started_at"`
+	FinishedAt time.Time    `json:"finished_at"`
+	DurationMS int64        `json:"duration_ms"`
+	Total      int          `json:"total"`
+	Succeeded  int          `json:"succeeded"`
+	Failed     int          `json:"failed"`
+	Skipped    int          `json:"skipped"`
+	Files      []fileReport `json:"files"`
+
+	byPath map[string]*fileReport
+}
+
+// fileReport records one input file. Each file is only updated by the goroutine parsing it.
+type fileReport struct {
+	Path       string        `json:"path"`
+	Name       string        `json:"name"`
+	Status     string        `json:"status"`
+	UID        string        `json:"uid,omitempty"`
+	Pages      int           `json:"pages,omitempty"`
+	Cached     bool          `json:"cached,omitempty"`
+	ResultPath string        `json:"result_path,omitempty"`
+	Outputs    []string      `json:"outputs,omitempty"` // Downloaded converted files
+	DurationMS int64         `json:"duration_ms"`
+	Stages     []stageReport `json:"stages,omitempty"`
+	Error      string        `json:"error,omitempty"`
+
+	started time.Time
+}
+
+type stageReport struct {
+	Stage      client.Stage   `json:"stage"`
+	Format     string         `json:"format,omitempty"` // Set for downloads
+	TraceID    string         `json:"trace_id,omitempty"`
+	DurationMS int64          `json:"duration_ms"`
+	Cached     bool           `json:"cached,omitempty"`
+	Formats    []formatReport `json:"formats,omitempty"` // Per-format outcome of a conversion
+	Error      string         `json:"error,omitempty"`
+}
+
+type formatReport struct {
+	Format  string `json:"format"`
+	TraceID string `json:"trace_id,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Cached  bool   `json:"cached,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// newParseReport prepares an entry per input file, in input order.
+func newParseReport(files []inputFile) *parseReport {
+	r := &parseReport{
+		StartedAt: time.Now().UTC(),
+		Files:     make([]fileReport, len(files)),
+		byPath:    make(map[string]*fileReport, len(files)),
+	}
+	for i, in := range files {
+		r.Files[i] = fileReport{Path: in.path, Name: in.rel}
+		r.byPath[in.path] = &r.Files[i]
+	}
+	return r
+}
+
+// file returns the entry of in and starts its clock. A nil report returns nil, which records nothing.
+func (r *parseReport) file(in inputFile) *fileReport {
+	if r == nil {
+		return nil
+	}
+	f := r.byPath[in.path]
+	if f != nil {
+		f.started = time.Now()
+	}
+	return f
+}
+
+// write totals the report and stores it at path.
+func (r *parseReport) write(path string) error {
+	r.FinishedAt = time.Now().UTC()
+	r.DurationMS = r.FinishedAt.Sub(r.StartedAt).Milliseconds()
+	r.Total = len(r.Files)
+	for _, f := range r.Files {
+		switch f.Status {
+		case reportSuccess, reportSubmitted:
+			r.Succeeded++
+		case reportSkipped:
+			r.Skipped++
+		default:
+			r.Failed++
+		}
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("create report dir: %w", err)
+		}
+	}
+	return writeJSON(path, r)
+}
+
+// stage records a finished pipeline stage.
+func (f *fileReport) stage(ev client.StageEvent) {
+	if f == nil {
+		return
+	}
+
+	if ev.UID != "" {
+		f.UID = ev.UID
+	}
+	st := stageReport{
+		Stage:      ev.Stage,
+		TraceID:    ev.TraceID,
+		DurationMS: ev.Elapsed.Milliseconds(),
+		Cached:     ev.Cached,
+	}
+	if ev.Err != nil {
+		st.Error = ev.Err.Error()
+	}
+
+	switch ev.Stage {
+	case client.StageParse:
+		if ev.Err == nil && ev.Status != nil && ev.Status.Data != nil && ev.Status.Data.Result != nil {
+			f.Pages = len(ev.Status.Data.Result.Pages)
+			f.Cached = ev.Cached
+		}
+	case client.StageConvert:
+		for _, out := range ev.Outputs {
+			format := formatReport{
+				Format:  string(out.Request.To),
+				TraceID: out.TraceID,
+				URL:     out.URL,
+				Cached:  out.Cached,
+			}
+			if out.Err != nil {
+				format.Error = out.Err.Error()
+			}
+			st.Formats = append(st.Formats, format)
+		}
+	case client.StageDownload:
+		if ev.Output != nil {
+			st.Format = string(ev.Output.Request.To)
+		}
+	}
+	f.Stages = append(f.Stages, st)
+}
+
+// finish records the outcome of the file; status overrides the one derived from err when set.
+func (f *fileReport) finish(status string, err error) {
+	if f == nil {
+		return
+	}
+
+	f.DurationMS = time.Since(f.started).Milliseconds()
+	switch {
+	case err != nil:
+		f.Status = reportFailed
+		f.Error = err.Error()
+		var stageErr *client.StageError
+		if errors.As(err, &stageErr) && stageErr.UID != "" {
+			f.UID = stageErr.UID
+		}
+	case status != "":
+		f.Status = status
+	default:
+		f.Status = reportSuccess
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+func TestParseReport(t *testing.T) {
+	files := []inputFile{
+		{path: "/in/ok.pdf", rel: "ok.pdf"},
+		{path: "/in/bad.pdf", rel: "bad.pdf"},
+		{path: "/in/done.pdf", rel: "done.pdf"},
+		{path: "/in/queued.pdf", rel: "queued.pdf"},
+	}
+	report := newParseReport(files)
+
+	var parsed client.StatusResponse
+	if err := json.Unmarshal([]byte(`{"code":"success","data":{"status":"success","result":{"pages":[{},{},{}]}}}`), &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	ok := report.file(files[0])
+	ok.stage(client.StageEvent{Stage: client.StageUpload, UID: "uid-ok", TraceID: "t-up", Elapsed: 1500 * time.Millisecond})
+	ok.stage(client.StageEvent{
+		Stage:  client.StageParse,
+		Status: &parsed,
+		Cached: true,
+	})
+	ok.stage(client.StageEvent{Stage: client.StageConvert, Outputs: []client.ConvertOutput{
+		{Request: client.ConvertRequest{To: client.FormatMarkdown}, URL: "https://cdn.example/ok.zip", TraceID: "t-md"},
+		{Request: client.ConvertRequest{To: client.FormatDocx}, Err: errors.New("convert failed")},
+	}})
+	ok.stage(client.StageEvent{Stage: client.StageDownload, Output: &client.ConvertOutput{Request: client.ConvertRequest{To: client.FormatMarkdown}}})
+	ok.ResultPath, ok.Outputs = "/out/ok.json", []string{"/out/ok.zip"}
+	ok.finish("", nil)
+
+	bad := report.file(files[1])
+	bad.stage(client.StageEvent{Stage: client.StageUpload, Err: errors.New("boom")})
+	bad.finish("", &client.StageError{Stage: client.StageUpload, Name: "bad.pdf", UID: "uid-bad", Err: errors.New("boom")})
+
+	report.file(files[2]).finish(reportSkipped, nil)
+	report.file(files[3]).finish(reportSubmitted, nil)
+
+	// A nil report records nothing.
+	var none *parseReport
+	none.file(files[0]).stage(client.StageEvent{Stage: client.StageUpload})
+	none.file(files[0]).finish("", nil)
+
+	path := filepath.Join(t.TempDir(), "reports", "run.json")
+	if err := report.write(path); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The schema is consumed by CI jobs; keys are part of the contract.
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	wantKeys := []string{"duration_ms", "failed", "files", "finished_at", "skipped", "started_at", "succeeded", "total"}
+	if keys := sortedKeys(doc); !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("report keys = %q, want %q", keys, wantKeys)
+	}
+	for key, want := range map[string]float64{"total": 4, "succeeded": 2, "failed": 1, "skipped": 1} {
+		if doc[key] != want {
+			t.Errorf("%s = %v, want %v", key, doc[key], want)
+		}
+	}
+	entries := doc["files"].([]any)
+	if len(entries) != 4 {
+		t.Fatalf("got %d file entries, want 4", len(entries))
+	}
+	okKeys := []string{"cached", "duration_ms", "name", "outputs", "pages", "path", "result_path", "stages", "status", "uid"}
+	if keys := sortedKeys(entries[0].(map[string]any)); !reflect.DeepEqual(keys, okKeys) {
+		t.Errorf("file entry keys = %q, want %q", keys, okKeys)
+	}
+	if keys := sortedKeys(entries[2].(map[string]any)); !reflect.DeepEqual(keys, []string{"duration_ms", "name", "path", "status"}) {
+		t.Errorf("skipped entry keys = %q, want only the required ones", keys)
+	}
+
+	var got parseReport
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.StartedAt.IsZero() || got.FinishedAt.Before(got.StartedAt) {
+		t.Errorf("run window %v - %v", got.StartedAt, got.FinishedAt)
+	}
+	want := []fileReport{
+		{
+			Path: "/in/ok.pdf", Name: "ok.pdf", Status: reportSuccess, UID: "uid-ok", Pages: 3, Cached: true,
+			ResultPath: "/out/ok.json", Outputs: []string{"/out/ok.zip"},
+			Stages: []stageReport{
+				{Stage: client.StageUpload, TraceID: "t-up", DurationMS: 1500},
+				{Stage: client.StageParse, Cached: true},
+				{Stage: client.StageConvert, Formats: []formatReport{
+					{Format: "md", TraceID: "t-md", URL: "https://cdn.example/ok.zip"},
+					{Format: "docx", Error: "convert failed"},
+				}},
+				{Stage: client.StageDownload, Format: "md"},
+			},
+		},
+		{
+			Path: "/in/bad.pdf", Name: "bad.pdf", Status: reportFailed, UID: "uid-bad", Error: "bad.pdf upload (uid uid-bad): boom",
+			Stages: []stageReport{{Stage: client.StageUpload, Error: "boom"}},
+		},
+		{Path: "/in/done.pdf", Name: "done.pdf", Status: reportSkipped},
+		{Path: "/in/queued.pdf", Name: "queued.pdf", Status: reportSubmitted},
+	}
+	for i := range got.Files {
+		got.Files[i].DurationMS = 0
+	}
+	if !reflect.DeepEqual(got.Files, want) {
+		t.Errorf("files =\n%+v\nwant\n%+v", got.Files, want)
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+// resultSource locates a parse result either in a JSON file written by `doc2x parse --output`
+// (or `doc2x status --output`) or on the server by UID.
+type resultSource struct {
+	input string
+	uid   string
+}
+
+func (s *resultSource) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&s.input, "input", "i", "", "Parse result JSON written by doc2x parse --output or doc2x status --output")
+	cmd.Flags().StringVar(&s.uid, "uid", "", "UID of a parsed document to fetch instead of --input")
+}
+
+func (s *resultSource) validate() error {
+	if s.input == "" && s.uid == "" {
+		return errors.New("flag --input or --uid is required")
+	}
+	if s.input != "" && s.uid != "" {
+		return errors.New("flags --input and --uid are mutually exclusive")
+	}
+	return nil
+}
+
+// name returns a base name for derived output files.
+func (s *resultSource) name() string {
+	if s.input != "" {
+		return changeExt(filepath.Base(s.input), "")
+	}
+	return s.uid
+}
+
+// load reads the parse result from the file or fetches it from the API.
+func (s *resultSource) load(cmd *cobra.Command, opts *cliOptions) (*client.ParseResult, error) {
+	if s.input != "" {
+		return loadParseResult(s.input)
+	}
+
+	apiKey, err := resolveAPIKey(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cli, err := buildClient(cmd, apiKey, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := cli.GetStatus(cmd.Context(), s.uid)
+	if err != nil {
+		return nil, err
+	}
+	if status.Data == nil || status.Data.Result == nil {
+		return nil, fmt.Errorf("parse result for UID %s is not available (trace-id: %s)", s.uid, status.TraceID)
+	}
+	return status.Data.Result, nil
+}
+
+// loadParseResult reads a bare parse result or a full status response from path.
+func loadParseResult(path string) (*client.ParseResult, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read parse result: %w", err)
+	}
+
+	var status client.StatusResponse
+	if err := json.Unmarshal(content, &status); err == nil && status.Data != nil && status.Data.Result != nil {
+		return status.Data.Result, nil
+	}
+
+	var result client.ParseResult
+	if err := json.Unmarshal(content, &result); err != nil {
+		return nil, fmt.Errorf("decode parse result: %w", err)
+	}
+	return &result, nil
+}
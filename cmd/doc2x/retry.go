@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+func newRetryCmd(opts *cliOptions) *cobra.Command {
+	ro := &retryOptions{
+		opts: opts,
+	}
+
+	cmd := &cobra.Command{
+		Use:               "retry",
+		Short:             "Re-run the failed stage of every entry in the fail log",
+		Long:              "Re-run the failed stage of every entry in --fail-log: failed uploads are uploaded again, parse failures are re-polled after timeouts, rate limits and network errors and re-uploaded otherwise, conversions are requested again and downloads are fetched again, each followed by the stages that did not run. The fail log is rewritten to hold only what failed again, plus entries that cannot be retried.",
+		ValidArgsFunction: positionalAlwaysFlags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ro.Validate(); err != nil {
+				return err
+			}
+
+			return ro.Run(cmd)
+		},
+	}
+
+	ro.addFlags(cmd)
+
+	return cmd
+}
+
+type retryOptions struct {
+	interval time.Duration
+	progress string
+	opts     *cliOptions
+	apiKey   string
+}
+
+func (o *retryOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&o.interval, "interval", 3*time.Second, "Polling interval for parsing and conversion status")
+	cmd.Flags().StringVar(&o.progress, "progress", progressAuto, "Upload and download progress display: auto|bar|log|off (auto draws bars when stderr is a terminal)")
+}
+
+func (o *retryOptions) Validate() error {
+	if o.opts.failLogPath == "" {
+		return errors.New("flag --fail-log is required")
+	}
+	if o.interval <= 0 {
+		return errors.New("flag --interval must be positive")
+	}
+	switch o.progress {
+	case progressAuto, progressBar, progressLog, progressOff:
+	default:
+		return fmt.Errorf("unsupported progress mode: %s", o.progress)
+	}
+	return nil
+}
+
+func (o *retryOptions) Run(cmd *cobra.Command) error {
+	failLog := o.opts.failLogPath
+	lines, err := readFailureLog(failLog)
+	if err != nil {
+		return err
+	}
+
+	apiKey, err := resolveAPIKey(o.opts)
+	if err != nil {
+		return err
+	}
+	o.apiKey = apiKey
+
+	cli, err := buildClient(cmd, o.apiKey, o.opts)
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+
+	progress, err := newTransferProgress(cmd, o.progress)
+	if err != nil {
+		return err
+	}
+
+	// Failures of this run go to a new log that replaces the old one at the end, so entries that
+	// succeed disappear and entries that fail again are not duplicated.
+	next := failLog + ".retry"
+	if err := os.Remove(next); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove stale retry log: %w", err)
+	}
+
+	var kept []string
+	for _, line := range lines {
+		if !retryable(line.entry) {
+			kept = append(kept, line.raw)
+		}
+	}
+	if len(kept) > 0 {
+		if err := appendFailureLines(next, kept...); err != nil {
+			return fmt.Errorf("write retry log: %w", err)
+		}
+	}
+
+	var retried, failed int
+	var firstErr error
+	for _, line := range lines {
+		if !retryable(line.entry) {
+			continue
+		}
+		retried++
+		if err := o.retry(ctx, cmd, cli, *line.entry, next, progress); err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+	}
+
+	// Keep entries that were not reached, e.g. after an interrupt.
+	if ctx.Err() != nil {
+		if err := o.keepRemaining(lines, retried, next); err != nil {
+			return err
+		}
+	}
+
+	if err := replaceFailureLog(failLog, next); err != nil {
+		return err
+	}
+
+	if err := printOut(cmd, "Retried failures",
+		slog.String("fail_log", failLog),
+		slog.Int("retried", retried),
+		slog.Int("succeeded", retried-failed),
+		slog.Int("failed", failed),
+		slog.Int("kept", len(kept)),
+	); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("retry completed with %d errors, first: %w", failed, firstErr)
+	}
+	return nil
+}
+
+// retryable reports whether doc2x retry can run the failed stage of entry again.
+func retryable(entry *failureEntry) bool {
+	if entry == nil {
+		return false
+	}
+	switch client.Stage(entry.Stage) {
+	case client.StageUpload:
+		return entry.File != ""
+	case client.StageParse:
+		return entry.UID != "" || entry.File != ""
+	case client.StageConvert:
+		return entry.UID != "" && entry.Convert != nil && len(entry.Convert.Formats) > 0
+	case client.StageDownload:
+		return entry.URL != "" || entry.UID != ""
+	default:
+		return false
+	}
+}
+
+// retry runs the failed stage of entry and the stages after it, logging new failures to failLog.
+func (o *retryOptions) retry(ctx context.Context, cmd *cobra.Command, cli client.Client, entry failureEntry, failLog string, progress *transferProgress) error {
+	label := filepath.Base(entry.File)
+	if entry.File == "" {
+		label = entry.UID
+	}
+
+	formulas, err := entry.Formulas.config()
+	if err != nil {
+		return err
+	}
+	sink := &cliSink{cmd: cmd, resultPath: entry.ResultPath, formulas: formulas}
+	opts := client.PipelineOptions{PollInterval: o.interval}
+	if c := entry.Convert; c != nil {
+		opts.Formats = c.Formats
+		opts.FormulaMode = c.FormulaMode
+		opts.Filename = c.Filename
+		opts.MergeCrossPageForms = c.MergeCrossPageForms
+		sink.dir, sink.name, sink.output, sink.multi = c.Dir, c.Name, c.Output, c.Multi
+	}
+	if entry.Convert == nil || entry.Convert.Download {
+		opts.Sink = sink
+	}
+
+	hooks := &cliHooks{
+		cmd:      cmd,
+		file:     entry.File,
+		label:    label,
+		failLog:  failLog,
+		sink:     sink,
+		convert:  entry.Convert,
+		formulas: entry.Formulas,
+		record:   noRecord,
+	}
+	opts.Hooks = client.PipelineHooks{AfterStage: hooks.after}
+	pipeline := client.NewPipeline(cli, opts)
+
+	if fn := progress.track(label); fn != nil {
+		ctx = client.ContextWithTransferProgress(ctx, fn)
+	}
+
+	if err := printWithTrace(cmd, slog.LevelInfo, entry.TraceID, "Retrying failed stage",
+		slog.String("stage", entry.Stage),
+		slog.String("file", entry.File),
+		slog.String("uid", entry.UID),
+		slog.String("error_class", entry.ErrorClass),
+	); err != nil {
+		return err
+	}
+
+	switch client.Stage(entry.Stage) {
+	case client.StageUpload:
+		return retryUpload(ctx, pipeline, hooks, label, entry.File)
+
+	case client.StageParse:
+		if entry.UID == "" || (entry.File != "" && !transientClass(entry.ErrorClass)) {
+			return retryUpload(ctx, pipeline, hooks, label, entry.File)
+		}
+		hooks.resumed = entry.File != ""
+		_, err := pipeline.Continue(ctx, label, entry.UID)
+		if !hooks.resumed || !isExpiredParse(err) {
+			return err
+		}
+		hooks.resumed = false
+		if err := printWithTrace(cmd, slog.LevelWarn, entry.TraceID, "Task expired, uploading again",
+			slog.String("file", entry.File),
+			slog.String("uid", entry.UID),
+		); err != nil {
+			return err
+		}
+		return retryUpload(ctx, pipeline, hooks, label, entry.File)
+
+	case client.StageConvert:
+		_, err := pipeline.Convert(ctx, label, entry.UID)
+		return err
+
+	case client.StageDownload:
+		return retryDownload(ctx, cli, pipeline, hooks, label, entry)
+	}
+
+	return fmt.Errorf("stage %s cannot be retried", entry.Stage)
+}
+
+// transientClass reports whether a failure of the given error class may clear up by itself, so a
+// failed parse is polled again instead of uploading the file again.
+func transientClass(class string) bool {
+	switch class {
+	case "timeout", "rate_limited", "network", "canceled":
+		return true
+	}
+	return false
+}
+
+func retryUpload(ctx context.Context, pipeline *client.Pipeline, hooks *cliHooks, label, pdf string) error {
+	file, size, err := openPDF(pdf)
+	if err != nil {
+		return hooks.failed(client.StageEvent{Stage: client.StageUpload}, err)
+	}
+	defer file.Close()
+
+	uid, _, err := pipeline.Upload(ctx, label, file, size)
+	if err != nil {
+		return err
+	}
+	_, err = pipeline.Continue(ctx, label, uid)
+	return err
+}
+
+// retryDownload fetches a converted file again. Without a recorded URL, as when doc2x download --uid
+// could not resolve it, the URL is resolved through the conversion result.
+func retryDownload(ctx context.Context, cli client.Client, pipeline *client.Pipeline, hooks *cliHooks, label string, entry failureEntry) error {
+	out := client.ConvertOutput{
+		Request: client.ConvertRequest{UID: entry.UID},
+		URL:     entry.URL,
+		TraceID: entry.TraceID,
+	}
+	if entry.Convert != nil && len(entry.Convert.Formats) > 0 {
+		out.Request.To = entry.Convert.Formats[0]
+	}
+
+	if out.URL == "" {
+		result, err := cli.GetConvertResult(ctx, entry.UID)
+		if err != nil {
+			return hooks.failed(client.StageEvent{Stage: client.StageDownload, UID: entry.UID}, err)
+		}
+		if result.Data.Status != client.ConvertStatusSuccess || result.Data.URL == "" {
+			err := fmt.Errorf("conversion for UID %s is %s, no download URL yet (trace-id: %s)", entry.UID, result.Data.Status, result.TraceID)
+			return hooks.failed(client.StageEvent{Stage: client.StageDownload, UID: entry.UID, TraceID: result.TraceID}, err)
+		}
+		out.URL, out.TraceID = result.Data.URL, result.TraceID
+	}
+
+	return pipeline.Download(ctx, label, out)
+}
+
+// keepRemaining copies the entries after the first retried ones into the new log unchanged.
+func (o *retryOptions) keepRemaining(lines []failureLine, retried int, next string) error {
+	var remaining []string
+	seen := 0
+	for _, line := range lines {
+		if !retryable(line.entry) {
+			continue
+		}
+		seen++
+		if seen > retried {
+			remaining = append(remaining, line.raw)
+		}
+	}
+	if len(remaining) == 0 {
+		return nil
+	}
+	if err := appendFailureLines(next, remaining...); err != nil {
+		return fmt.Errorf("write retry log: %w", err)
+	}
+	return nil
+}
+
+// replaceFailureLog moves the log of this run over the old one, or removes the old one when
+// nothing failed.
+func replaceFailureLog(failLog, next string) error {
+	if _, err := os.Stat(next); errors.Is(err, fs.ErrNotExist) {
+		if err := os.Remove(failLog); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove fail log: %w", err)
+		}
+		return nil
+	}
+	if err := os.Rename(next, failLog); err != nil {
+		return fmt.Errorf("replace fail log: %w", err)
+	}
+	return nil
+}
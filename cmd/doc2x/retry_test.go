@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	client "github.com/hsn0918/doc2x-client"
+	"github.com/hsn0918/doc2x-client/doc2xtest"
+)
+
+func failureLineJSON(t *testing.T, entry failureEntry) string {
+	t.Helper()
+	if entry.Time.IsZero() {
+		entry.Time = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func writeFailLog(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fail.log")
+	writeFile(t, path, strings.Join(lines, "\n")+"\n")
+	return path
+}
+
+func readLogLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+// runRetry runs doc2x retry against srv with failLog.
+func runRetry(ctx context.Context, srv *doc2xtest.Server, failLog string) error {
+	return runCLI(ctx, "--api-key", "sk-test", "--base-url", srv.URL, "--fail-log", failLog,
+		"retry", "--interval", "1ms", "--progress", "off")
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry *failureEntry
+		want  bool
+	}{
+		{"not json", nil, false},
+		{"upload", &failureEntry{Stage: "upload", File: "a.pdf"}, true},
+		{"upload without file", &failureEntry{Stage: "upload", UID: "u1"}, false},
+		{"parse by uid", &failureEntry{Stage: "parse", UID: "u1"}, true},
+		{"parse by file", &failureEntry{Stage: "parse", File: "a.pdf"}, true},
+		{"parse without either", &failureEntry{Stage: "parse"}, false},
+		{"convert", &failureEntry{Stage: "convert", UID: "u1", Convert: &failedConvert{Formats: []client.ConvertFormat{client.FormatMarkdown}}}, true},
+		{"convert without formats", &failureEntry{Stage: "convert", UID: "u1", Convert: &failedConvert{}}, false},
+		{"convert without settings", &failureEntry{Stage: "convert", UID: "u1"}, false},
+		{"download by url", &failureEntry{Stage: "download", URL: "https://cdn.example/a.zip"}, true},
+		{"download by uid", &failureEntry{Stage: "download", UID: "u1"}, true},
+		{"download without either", &failureEntry{Stage: "download"}, false},
+		{"unknown stage", &failureEntry{Stage: "render", File: "a.pdf"}, false},
+	}
+	for _, tt := range tests {
+		if got := retryable(tt.entry); got != tt.want {
+			t.Errorf("%s: retryable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRetryRewritesFailLog(t *testing.T) {
+	srv := doc2xtest.NewServer()
+	t.Cleanup(srv.Close)
+	dir := t.TempDir()
+	pdf := filepath.Join(dir, "a.pdf")
+	writeFile(t, pdf, samplePDF)
+
+	succeeds := failureLineJSON(t, failureEntry{Stage: "upload", File: pdf, ErrorClass: "network", ResultPath: filepath.Join(dir, "a.json")})
+	failsAgain := failureLineJSON(t, failureEntry{Stage: "parse", UID: "uid-gone", ErrorClass: "timeout"})
+	notJSON := "2026/01/02 parse failed: legacy log line"
+	unsupported := failureLineJSON(t, failureEntry{Stage: "convert", UID: "uid-1", ErrorClass: "api"})
+	failLog := writeFailLog(t, succeeds, notJSON, failsAgain, unsupported)
+
+	err := runRetry(context.Background(), srv, failLog)
+	if err == nil || !strings.Contains(err.Error(), "1 errors") {
+		t.Fatalf("retry error = %v, want one failed entry", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.json")); err != nil {
+		t.Errorf("retried upload did not save its result: %v", err)
+	}
+
+	got := readLogLines(t, failLog)
+	if len(got) != 3 || got[0] != notJSON || got[1] != unsupported {
+		t.Fatalf("fail log = %q, want the two non-retryable lines verbatim and one new failure", got)
+	}
+	var entry failureEntry
+	if err := json.Unmarshal([]byte(got[2]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Stage != "parse" || entry.UID != "uid-gone" {
+		t.Errorf("new failure = %+v, want the parse of uid-gone", entry)
+	}
+	if _, err := os.Stat(failLog + ".retry"); !os.IsNotExist(err) {
+		t.Error("retry log left behind")
+	}
+}
+
+func TestRetryRemovesFailLogWhenEverythingSucceeds(t *testing.T) {
+	srv := doc2xtest.NewServer()
+	t.Cleanup(srv.Close)
+	pdf := filepath.Join(t.TempDir(), "a.pdf")
+	writeFile(t, pdf, samplePDF)
+	failLog := writeFailLog(t, failureLineJSON(t, failureEntry{Stage: "upload", File: pdf, ErrorClass: "network"}))
+
+	if err := runRetry(context.Background(), srv, failLog); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if _, err := os.Stat(failLog); !os.IsNotExist(err) {
+		t.Errorf("fail log still exists after every entry succeeded: %v", err)
+	}
+}
+
+func TestRetryInterruptedKeepsUnreachedEntries(t *testing.T) {
+	srv := doc2xtest.NewServer()
+	t.Cleanup(srv.Close)
+	pdf := filepath.Join(t.TempDir(), "a.pdf")
+	writeFile(t, pdf, samplePDF)
+
+	notJSON := "legacy log line"
+	first := failureLineJSON(t, failureEntry{Stage: "parse", UID: "uid-1", ErrorClass: "timeout"})
+	second := failureLineJSON(t, failureEntry{Stage: "upload", File: pdf, ErrorClass: "network"})
+	third := failureLineJSON(t, failureEntry{Stage: "parse", UID: "uid-3", ErrorClass: "network"})
+	failLog := writeFailLog(t, first, notJSON, second, third)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := runRetry(ctx, srv, failLog); err == nil {
+		t.Fatal("interrupted retry reported success")
+	}
+
+	got := readLogLines(t, failLog)
+	if len(got) != 4 || got[0] != notJSON || got[2] != second || got[3] != third {
+		t.Fatalf("fail log = %q, want the kept line, the new failure of uid-1 and the two entries not reached", got)
+	}
+	var entry failureEntry
+	if err := json.Unmarshal([]byte(got[1]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.UID != "uid-1" || entry.ErrorClass != "canceled" {
+		t.Errorf("failure of the interrupted entry = %+v", entry)
+	}
+	if n := countUploads(srv); n != 0 {
+		t.Errorf("server received %d uploads after the interrupt", n)
+	}
+}
+
+func TestKeepRemaining(t *testing.T) {
+	a := failureLineJSON(t, failureEntry{Stage: "parse", UID: "a"})
+	b := failureLineJSON(t, failureEntry{Stage: "parse", UID: "b"})
+	c := failureLineJSON(t, failureEntry{Stage: "parse", UID: "c"})
+	lines, err := readFailureLog(writeFailLog(t, a, "not json", b, failureLineJSON(t, failureEntry{Stage: "render"}), c))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		retried int
+		want    []string
+	}{
+		{0, []string{a, b, c}},
+		{1, []string{b, c}},
+		{2, []string{c}},
+		{3, nil},
+	}
+	for _, tt := range tests {
+		next := filepath.Join(t.TempDir(), "fail.log.retry")
+		if err := (&retryOptions{}).keepRemaining(lines, tt.retried, next); err != nil {
+			t.Fatalf("keepRemaining(%d): %v", tt.retried, err)
+		}
+		data, err := os.ReadFile(next)
+		if tt.want == nil {
+			if !os.IsNotExist(err) {
+				t.Errorf("keepRemaining(%d) wrote %q, want no log", tt.retried, data)
+			}
+			continue
+		}
+		if got := strings.Split(strings.TrimSpace(string(data)), "\n"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("keepRemaining(%d) kept %q, want %q", tt.retried, got, tt.want)
+		}
+	}
+}
+
+func TestReplaceFailureLog(t *testing.T) {
+	dir := t.TempDir()
+	failLog := filepath.Join(dir, "fail.log")
+	next := failLog + ".retry"
+
+	writeFile(t, failLog, "old\n")
+	writeFile(t, next, "new\n")
+	if err := replaceFailureLog(failLog, next); err != nil {
+		t.Fatalf("replaceFailureLog: %v", err)
+	}
+	if got := readLogLines(t, failLog); !reflect.DeepEqual(got, []string{"new"}) {
+		t.Errorf("fail log = %q, want the retry log", got)
+	}
+	if _, err := os.Stat(next); !os.IsNotExist(err) {
+		t.Error("retry log still exists after the move")
+	}
+
+	if err := replaceFailureLog(failLog, next); err != nil {
+		t.Fatalf("replaceFailureLog without a retry log: %v", err)
+	}
+	if _, err := os.Stat(failLog); !os.IsNotExist(err) {
+		t.Error("fail log kept although nothing failed again")
+	}
+	if err := replaceFailureLog(failLog, next); err != nil {
+		t.Errorf("replaceFailureLog with neither log: %v", err)
+	}
+}
+
+func TestRetryParsePollsOrUploads(t *testing.T) {
+	tests := []struct {
+		class  string
+		upload bool
+	}{
+		{"timeout", false},
+		{"rate_limited", false},
+		{"network", false},
+		{"canceled", false},
+		{"parse_failed", true},
+		{"api", true},
+		{"other", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.class, func(t *testing.T) {
+			srv := doc2xtest.NewServer()
+			t.Cleanup(srv.Close)
+			pdf := filepath.Join(t.TempDir(), "a.pdf")
+			writeFile(t, pdf, samplePDF)
+
+			resp, err := srv.Client().UploadPDF(context.Background(), []byte(samplePDF))
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := countUploads(srv)
+
+			failLog := writeFailLog(t, failureLineJSON(t, failureEntry{Stage: "parse", File: pdf, UID: resp.Data.UID, ErrorClass: tt.class}))
+			if err := runRetry(context.Background(), srv, failLog); err != nil {
+				t.Fatalf("retry: %v", err)
+			}
+			if uploaded := countUploads(srv) > before; uploaded != tt.upload {
+				t.Errorf("uploaded again = %v, want %v", uploaded, tt.upload)
+			}
+		})
+	}
+}
@@ -14,6 +14,12 @@ type cliOptions struct {
 	timeout           time.Duration
 	processingTimeout time.Duration
 	failLogPath       string
+	pollStrategy      string
+	rateLimit         float64
+	rateBurst         int
+	maxInFlight       int
+	transferRateLimit float64
+	transferInFlight  int
 }
 
 func newRootCmd() *cobra.Command {
@@ -33,10 +39,24 @@ func newRootCmd() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&opts.baseURL, "base-url", client.DefaultBaseURL, "Base URL for Doc2X API")
 	cmd.PersistentFlags().DurationVar(&opts.timeout, "timeout", client.DefaultTimeout, "HTTP timeout for API requests")
 	cmd.PersistentFlags().DurationVar(&opts.processingTimeout, "processing-timeout", client.ProcessingTimeout, "Timeout for long running operations")
-	cmd.PersistentFlags().StringVar(&opts.failLogPath, "fail-log", "fail.log", "Path to write failed task logs")
+	cmd.PersistentFlags().StringVar(&opts.failLogPath, "fail-log", "fail.log", "Path of the JSON-lines log of failed stages, replayed by doc2x retry")
+	cmd.PersistentFlags().StringVar(&opts.pollStrategy, "poll-strategy", "fixed", "Status polling strategy: fixed|exponential|progress")
+	cmd.PersistentFlags().Float64Var(&opts.rateLimit, "rate-limit", 0, "Maximum API requests per second shared by all workers (0 = unlimited)")
+	cmd.PersistentFlags().IntVar(&opts.rateBurst, "rate-burst", 1, "Burst size for --rate-limit and --transfer-rate-limit")
+	cmd.PersistentFlags().IntVar(&opts.maxInFlight, "max-in-flight", 0, "Maximum concurrent API requests (0 = unlimited)")
+	cmd.PersistentFlags().Float64Var(&opts.transferRateLimit, "transfer-rate-limit", 0, "Maximum uploads/downloads started per second (0 = unlimited)")
+	cmd.PersistentFlags().IntVar(&opts.transferInFlight, "transfer-max-in-flight", 0, "Maximum concurrent uploads/downloads (0 = unlimited)")
 
 	cmd.AddCommand(newParseCmd(opts))
 	cmd.AddCommand(newConvertCmd(opts))
+	cmd.AddCommand(newStatusCmd(opts))
+	cmd.AddCommand(newImageCmd(opts))
+	cmd.AddCommand(newDownloadCmd(opts))
+	cmd.AddCommand(newTablesCmd(opts))
+	cmd.AddCommand(newRenderCmd(opts))
+	cmd.AddCommand(newChunkCmd(opts))
+	cmd.AddCommand(newCacheCmd())
+	cmd.AddCommand(newRetryCmd(opts))
 	cmd.AddCommand(newCompletionCmd())
 
 	return cmd
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// inputScan controls how a --path directory is searched for PDFs.
+type inputScan struct {
+	recursive      bool
+	include        []string // Globs a file must match; defaults to any .pdf file
+	exclude        []string // Globs for files and directories to skip
+	followSymlinks bool
+}
+
+// inputFile is a PDF to parse. rel is its slash-separated path relative to the scanned directory, or
+// its base name when a single file was given; outputs are mirrored under the output dirs by rel.
+type inputFile struct {
+	path string
+	rel  string
+
+	// Per-file overrides from --manifest; empty fields use the parse flags.
+	convertTo   string
+	formulaMode string
+	output      string // Output name replacing rel, without extension
+}
+
+// outputName returns the slash-separated name, without extension, that outputs are stored under.
+func (in inputFile) outputName() string {
+	if in.output != "" {
+		return in.output
+	}
+	return strings.TrimSuffix(in.rel, path.Ext(in.rel))
+}
+
+func (s *inputScan) addFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&s.recursive, "recursive", false, "Search --path directories recursively")
+	cmd.Flags().StringSliceVar(&s.include, "include", nil, "Glob of files to parse, matched against the base name or, with a slash, the path relative to --path; ** spans directories (repeatable, default *.pdf)")
+	cmd.Flags().StringSliceVar(&s.exclude, "exclude", nil, "Glob of files or directories to skip, matched like --include (repeatable)")
+	cmd.Flags().BoolVar(&s.followSymlinks, "follow-symlinks", false, "Descend into symlinked directories when scanning --path")
+}
+
+func (s *inputScan) validate() error {
+	for _, pattern := range append(append([]string{}, s.include...), s.exclude...) {
+		for _, segment := range strings.Split(pattern, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return fmt.Errorf("invalid glob %q: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+func collectInputFiles(p string, scan inputScan) ([]inputFile, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, fmt.Errorf("stat path: %w", err)
+	}
+
+	if info.Mode().IsRegular() {
+		if strings.EqualFold(filepath.Ext(p), ".pdf") {
+			return []inputFile{{path: p, rel: filepath.Base(p)}}, nil
+		}
+		return nil, fmt.Errorf("file is not a pdf: %s", p)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("path is neither file nor directory: %s", p)
+	}
+
+	w := scanWalker{scan: scan, visited: make(map[string]bool)}
+	if err := w.walk(p, ""); err != nil {
+		return nil, err
+	}
+	return w.files, nil
+}
+
+type scanWalker struct {
+	scan    inputScan
+	files   []inputFile
+	visited map[string]bool // Resolved directories already walked, to stop symlink cycles
+}
+
+func (w *scanWalker) walk(dir, rel string) error {
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		if w.visited[real] {
+			return nil
+		}
+		w.visited[real] = true
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("read dir: %w", err)
+	}
+
+	for _, entry := range entries {
+		full := filepath.Join(dir, entry.Name())
+		entryRel := path.Join(rel, entry.Name())
+
+		isDir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			info, err := os.Stat(full)
+			if err != nil {
+				continue // Dangling link
+			}
+			if info.IsDir() && !w.scan.followSymlinks {
+				continue
+			}
+			isDir = info.IsDir()
+		}
+
+		if matchAny(w.scan.exclude, entryRel) {
+			continue
+		}
+
+		if isDir {
+			if !w.scan.recursive {
+				continue
+			}
+			if err := w.walk(full, entryRel); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if w.included(entryRel) {
+			w.files = append(w.files, inputFile{path: full, rel: entryRel})
+		}
+	}
+	return nil
+}
+
+func (w *scanWalker) included(rel string) bool {
+	if len(w.scan.include) == 0 {
+		return strings.EqualFold(path.Ext(rel), ".pdf")
+	}
+	return matchAny(w.scan.include, rel)
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash-separated relative path. Patterns without a slash match the base name;
+// others match the whole path segment by segment, with ** standing for any number of directories.
+func matchGlob(pattern, rel string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
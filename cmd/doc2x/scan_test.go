@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		rel     string
+		want    bool
+	}{
+		{"*.pdf", "a.pdf", true},
+		{"*.pdf", "x/y/a.pdf", true}, // Without a slash the base name is matched
+		{"draft*", "drafts/a.pdf", false},
+		{"x/*.pdf", "x/a.pdf", true},
+		{"x/*.pdf", "x/y/a.pdf", false},
+		{"x/*.pdf", "a.pdf", false},
+		{"**/a.pdf", "a.pdf", true},
+		{"**/a.pdf", "x/y/a.pdf", true},
+		{"x/**/*.pdf", "x/a.pdf", true},
+		{"x/**/*.pdf", "x/y/z/a.pdf", true},
+		{"x/**/*.pdf", "w/x/a.pdf", false},
+		{"x/**", "x/y/a.pdf", true},
+		{"/x/*.pdf", "x/a.pdf", true},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.rel); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestInputScanValidate(t *testing.T) {
+	if err := (&inputScan{include: []string{"**/*.pdf"}, exclude: []string{"tmp"}}).validate(); err != nil {
+		t.Errorf("valid globs rejected: %v", err)
+	}
+	if err := (&inputScan{exclude: []string{"a/[b"}}).validate(); err == nil {
+		t.Error("malformed glob accepted")
+	}
+}
+
+func TestCollectInputFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.pdf", "B.PDF", "notes.txt", "sub/c.pdf", "sub/deep/d.pdf", "drafts/e.pdf"} {
+		full := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("%PDF-1.4\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		scan inputScan
+		want []string
+	}{
+		{"top level", inputScan{}, []string{"B.PDF", "a.pdf"}},
+		{"recursive", inputScan{recursive: true}, []string{"B.PDF", "a.pdf", "drafts/e.pdf", "sub/c.pdf", "sub/deep/d.pdf"}},
+		{"exclude dir", inputScan{recursive: true, exclude: []string{"drafts"}}, []string{"B.PDF", "a.pdf", "sub/c.pdf", "sub/deep/d.pdf"}},
+		{"include path", inputScan{recursive: true, include: []string{"sub/**/*.pdf"}}, []string{"sub/c.pdf", "sub/deep/d.pdf"}},
+		{"include and exclude", inputScan{recursive: true, include: []string{"*.pdf", "*.txt"}, exclude: []string{"sub/deep"}}, []string{"a.pdf", "drafts/e.pdf", "notes.txt", "sub/c.pdf"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := collectInputFiles(root, tt.scan)
+			if err != nil {
+				t.Fatalf("collectInputFiles: %v", err)
+			}
+			var rels []string
+			for _, f := range files {
+				rels = append(rels, f.rel)
+				if f.path != filepath.Join(root, filepath.FromSlash(f.rel)) {
+					t.Errorf("file %s has path %s", f.rel, f.path)
+				}
+			}
+			if !reflect.DeepEqual(rels, tt.want) {
+				t.Errorf("files = %q, want %q", rels, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectInputFilesSingleFile(t *testing.T) {
+	dir := t.TempDir()
+	pdf := filepath.Join(dir, "paper.pdf")
+	txt := filepath.Join(dir, "notes.txt")
+	for _, p := range []string{pdf, txt} {
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := collectInputFiles(pdf, inputScan{})
+	if err != nil || len(files) != 1 || files[0].rel != "paper.pdf" {
+		t.Errorf("collectInputFiles(pdf) = %+v, %v", files, err)
+	}
+	if _, err := collectInputFiles(txt, inputScan{}); err == nil {
+		t.Error("non-pdf file accepted")
+	}
+}
+
+func TestCollectInputFilesSymlinkCycle(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.pdf"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(root, filepath.Join(root, "loop")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	files, err := collectInputFiles(root, inputScan{recursive: true, followSymlinks: true})
+	if err != nil {
+		t.Fatalf("collectInputFiles: %v", err)
+	}
+	if len(files) != 1 || files[0].rel != "a.pdf" {
+		t.Errorf("files = %+v, want only a.pdf", files)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+// Task kinds accepted by the status command.
+const (
+	statusKindParse   = "parse"
+	statusKindConvert = "convert"
+	statusKindImage   = "image"
+)
+
+func newStatusCmd(opts *cliOptions) *cobra.Command {
+	so := &statusOptions{
+		opts: opts,
+	}
+
+	cmd := &cobra.Command{
+		Use:               "status",
+		Short:             "Inspect the status of a parse, convert or image layout task",
+		ValidArgsFunction: positionalAlwaysFlags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := so.Complete(); err != nil {
+				return err
+			}
+
+			if err := so.Validate(); err != nil {
+				return err
+			}
+
+			return so.Run(cmd)
+		},
+	}
+
+	so.addFlags(cmd)
+
+	return cmd
+}
+
+type statusOptions struct {
+	uid      string
+	kind     string
+	watch    bool
+	interval time.Duration
+	output   string
+	opts     *cliOptions
+	apiKey   string
+}
+
+func (o *statusOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.uid, "uid", "", "UID of the task to inspect")
+	cmd.Flags().StringVar(&o.kind, "kind", statusKindParse, "Task kind: parse|convert|image")
+	cmd.Flags().BoolVar(&o.watch, "watch", false, "Keep polling until the task finishes")
+	cmd.Flags().DurationVar(&o.interval, "interval", 3*time.Second, "Polling interval used with --watch")
+	cmd.Flags().StringVarP(&o.output, "output", "o", "", "Optional path to dump the full status response JSON")
+}
+
+func (o *statusOptions) Complete() error {
+	o.kind = strings.ToLower(o.kind)
+
+	if o.interval <= 0 {
+		o.interval = 3 * time.Second
+	}
+
+	return nil
+}
+
+func (o *statusOptions) Validate() error {
+	if o.uid == "" {
+		return errors.New("flag --uid is required")
+	}
+
+	switch o.kind {
+	case statusKindParse, statusKindConvert, statusKindImage:
+		return nil
+	default:
+		return fmt.Errorf("unsupported task kind: %s", o.kind)
+	}
+}
+
+func (o *statusOptions) Run(cmd *cobra.Command) error {
+	apiKey, err := resolveAPIKey(o.opts)
+	if err != nil {
+		return err
+	}
+	o.apiKey = apiKey
+
+	cli, err := buildClient(cmd, o.apiKey, o.opts)
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+
+	var (
+		resp    any
+		traceID string
+		attrs   []slog.Attr
+	)
+
+	switch o.kind {
+	case statusKindParse:
+		var status *client.StatusResponse
+		if o.watch {
+			status, err = cli.WaitForParsing(ctx, o.uid, o.interval)
+		} else {
+			status, err = cli.GetStatus(ctx, o.uid)
+		}
+		if err != nil {
+			return err
+		}
+		resp, traceID = status, status.TraceID
+		if status.Data != nil {
+			pageCount := 0
+			if status.Data.Result != nil {
+				pageCount = len(status.Data.Result.Pages)
+			}
+			attrs = append(attrs,
+				slog.String("status", string(status.Data.Status)),
+				slog.Int("progress", status.Data.Progress),
+				slog.String("detail", status.Data.Detail),
+				slog.Int("pages", pageCount),
+			)
+		}
+	case statusKindConvert:
+		var result *client.ConvertResultResponse
+		if o.watch {
+			result, err = cli.WaitForConversion(ctx, o.uid, o.interval)
+		} else {
+			result, err = cli.GetConvertResult(ctx, o.uid)
+		}
+		if err != nil {
+			return err
+		}
+		resp, traceID = result, result.TraceID
+		attrs = append(attrs,
+			slog.String("status", string(result.Data.Status)),
+			slog.String("url", result.Data.URL),
+		)
+	case statusKindImage:
+		var status *client.ImageLayoutStatusResponse
+		if o.watch {
+			status, err = cli.WaitForImageLayout(ctx, o.uid, o.interval)
+		} else {
+			status, err = cli.GetImageLayoutStatus(ctx, o.uid)
+		}
+		if err != nil {
+			return err
+		}
+		resp, traceID = status, status.TraceID
+		if status.Data != nil {
+			pageCount := 0
+			if status.Data.Result != nil {
+				pageCount = len(status.Data.Result.Pages)
+			}
+			attrs = append(attrs,
+				slog.String("status", status.Data.Status),
+				slog.Int("progress", status.Data.Progress),
+				slog.String("detail", status.Data.Detail),
+				slog.Int("pages", pageCount),
+			)
+		}
+	}
+
+	attrs = append([]slog.Attr{
+		slog.String("kind", o.kind),
+		slog.String("uid", o.uid),
+	}, attrs...)
+	if err := printWithTrace(cmd, slog.LevelInfo, traceID, "Task status", attrs...); err != nil {
+		return err
+	}
+
+	if o.output != "" {
+		if err := writeJSON(o.output, resp); err != nil {
+			return err
+		}
+		return printWithTrace(cmd, slog.LevelInfo, traceID, "Saved status response",
+			slog.String("uid", o.uid),
+			slog.String("path", o.output),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+func newTablesCmd(opts *cliOptions) *cobra.Command {
+	to := &tablesOptions{
+		opts: opts,
+	}
+
+	cmd := &cobra.Command{
+		Use:               "tables",
+		Short:             "Extract tables from a parse result as CSV or JSON",
+		ValidArgsFunction: positionalAlwaysFlags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := to.Validate(); err != nil {
+				return err
+			}
+
+			return to.Run(cmd)
+		},
+	}
+
+	to.addFlags(cmd)
+
+	return cmd
+}
+
+type tablesOptions struct {
+	source    resultSource
+	format    string
+	outputDir string
+	noStitch  bool
+	opts      *cliOptions
+}
+
+func (o *tablesOptions) addFlags(cmd *cobra.Command) {
+	o.source.addFlags(cmd)
+	cmd.Flags().StringVar(&o.format, "format", "csv", "Output format: csv (one file per table) or json (single file)")
+	cmd.Flags().StringVar(&o.outputDir, "output-dir", ".", "Directory to write extracted tables into")
+	cmd.Flags().BoolVar(&o.noStitch, "no-stitch", false, "Keep tables split across pages as separate tables")
+}
+
+func (o *tablesOptions) Validate() error {
+	if err := o.source.validate(); err != nil {
+		return err
+	}
+
+	o.format = strings.ToLower(o.format)
+	if o.format != "csv" && o.format != "json" {
+		return fmt.Errorf("unsupported tables format: %s", o.format)
+	}
+	return nil
+}
+
+func (o *tablesOptions) Run(cmd *cobra.Command) error {
+	result, err := o.source.load(cmd, o.opts)
+	if err != nil {
+		return err
+	}
+
+	tables := client.ExtractTables(result)
+	if !o.noStitch {
+		tables = client.StitchTables(tables)
+	}
+
+	if err := os.MkdirAll(o.outputDir, 0o755); err != nil {
+		return fmt.Errorf("create output dir: %w", err)
+	}
+
+	base := o.source.name()
+
+	if o.format == "json" {
+		target := filepath.Join(o.outputDir, base+"_tables.json")
+		if err := writeFileWith(target, func(f *os.File) error {
+			return client.WriteTablesJSON(f, tables)
+		}); err != nil {
+			return err
+		}
+		return printOut(cmd, "Saved tables",
+			slog.Int("tables", len(tables)),
+			slog.String("path", target),
+		)
+	}
+
+	for i, table := range tables {
+		target := filepath.Join(o.outputDir, fmt.Sprintf("%s_table_%02d_p%d.csv", base, i+1, table.Page+1))
+		if err := writeFileWith(target, func(f *os.File) error {
+			return table.WriteCSV(f)
+		}); err != nil {
+			return err
+		}
+		if err := printOut(cmd, "Saved table",
+			slog.Int("page", table.Page+1),
+			slog.Int("rows", len(table.Rows)),
+			slog.Int("columns", table.Columns()),
+			slog.String("path", target),
+		); err != nil {
+			return err
+		}
+	}
+
+	return printOut(cmd, "Extracted tables", slog.Int("tables", len(tables)))
+}
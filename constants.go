@@ -9,6 +9,13 @@ const (
 	ProcessingTimeout = 5 * time.Minute
 	APIVersion        = "v2"
 	TraceIDHeader     = "trace-id"
+
+	// MaxImageLayoutSize is the largest image accepted by the image layout endpoints (7 MB).
+	MaxImageLayoutSize = 7 << 20
+	// MaxDirectUploadSize is the largest PDF accepted by UploadPDF (300 MB).
+	MaxDirectUploadSize = 300 << 20
+	// MaxPresignedUploadSize is the largest PDF accepted by a presigned upload (1 GB).
+	MaxPresignedUploadSize = 1 << 30
 )
 
 // Response codes and status constants
@@ -0,0 +1,236 @@
+package client
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var bundleImageExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".bmp": true, ".svg": true,
+}
+
+// ConvertBundle is an unpacked convert_zip payload. Entry names are slash-separated
+// and cleaned; they are not trusted until ExtractTo checks them.
+type ConvertBundle struct {
+	MarkdownName string            // Name of the Markdown entry, empty when the bundle has none
+	Markdown     string            // Markdown content as shipped in the bundle
+	Images       map[string][]byte // Image assets keyed by entry name
+	Sidecars     map[string][]byte // JSON sidecar files keyed by entry name
+	Files        map[string][]byte // Every regular entry keyed by name, including the above
+}
+
+// FetchConvertBundle decodes an image layout convert_zip payload and opens it as a bundle.
+func (c *client) FetchConvertBundle(ctx context.Context, convertZIP string) (*ConvertBundle, error) {
+	data, err := c.FetchConvertZIP(ctx, convertZIP)
+	if err != nil {
+		return nil, err
+	}
+	return OpenConvertBundle(data)
+}
+
+// OpenConvertBundle indexes the entries of a convert_zip archive in memory.
+// When several Markdown files exist, output.md or the shallowest one wins.
+func OpenConvertBundle(data []byte) (*ConvertBundle, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open convert_zip: %w", err)
+	}
+
+	b := &ConvertBundle{
+		Images:   make(map[string][]byte),
+		Sidecars: make(map[string][]byte),
+		Files:    make(map[string][]byte),
+	}
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		content, err := readZIPEntry(f)
+		if err != nil {
+			return nil, err
+		}
+
+		name := cleanEntryName(f.Name)
+		b.Files[name] = content
+
+		switch ext := strings.ToLower(path.Ext(name)); {
+		case ext == ".md":
+			if b.MarkdownName == "" || preferMarkdown(name, b.MarkdownName) {
+				b.MarkdownName, b.Markdown = name, string(content)
+			}
+		case ext == ".json":
+			b.Sidecars[name] = content
+		case bundleImageExtensions[ext]:
+			b.Images[name] = content
+		}
+	}
+
+	return b, nil
+}
+
+func readZIPEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open zip entry %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("read zip entry %s: %w", f.Name, err)
+	}
+	return content, nil
+}
+
+func cleanEntryName(name string) string {
+	return path.Clean(strings.ReplaceAll(name, `\`, "/"))
+}
+
+func preferMarkdown(name, current string) bool {
+	if path.Base(current) == "output.md" {
+		return false
+	}
+	if path.Base(name) == "output.md" {
+		return true
+	}
+	return strings.Count(name, "/") < strings.Count(current, "/")
+}
+
+// Image returns the image referenced by ref, matching the entry name first and the base name second.
+// ref may be a relative link from the Markdown, such as "./images/0.png".
+func (b *ConvertBundle) Image(ref string) ([]byte, bool) {
+	name, ok := b.resolveImage(ref)
+	if !ok {
+		return nil, false
+	}
+	return b.Images[name], true
+}
+
+// ImageNames returns the names of all image assets in sorted order.
+func (b *ConvertBundle) ImageNames() []string {
+	names := make([]string, 0, len(b.Images))
+	for name := range b.Images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (b *ConvertBundle) resolveImage(ref string) (string, bool) {
+	if strings.Contains(ref, "://") || strings.HasPrefix(ref, "data:") {
+		return "", false
+	}
+
+	name := strings.TrimPrefix(cleanEntryName(ref), "/")
+	if b.MarkdownName != "" && !strings.HasPrefix(ref, "/") {
+		if rel := cleanEntryName(path.Join(path.Dir(b.MarkdownName), ref)); b.Images[rel] != nil {
+			return rel, true
+		}
+	}
+	if _, ok := b.Images[name]; ok {
+		return name, true
+	}
+
+	base := path.Base(name)
+	for _, candidate := range b.ImageNames() {
+		if path.Base(candidate) == base {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+var (
+	inlineImageLinkPattern = regexp.MustCompile(`!\[[^\]]*\]\(\s*<?([^)\s>]+)>?`)
+	htmlImageSrcPattern    = regexp.MustCompile(`(?i)<img\b[^>]*?\bsrc\s*=\s*["']([^"']+)["']`)
+)
+
+// RewriteImageLinks returns the Markdown with every Markdown or HTML image link that names a bundle
+// image replaced by that image's path relative to the Markdown file, as laid out by ExtractTo.
+// Links to unknown or remote images are left unchanged.
+func (b *ConvertBundle) RewriteImageLinks() string {
+	md := b.Markdown
+	for _, pattern := range []*regexp.Regexp{inlineImageLinkPattern, htmlImageSrcPattern} {
+		md = replaceSubmatch(md, pattern, func(ref string) string {
+			name, ok := b.resolveImage(ref)
+			if !ok {
+				return ref
+			}
+			return relativeEntryPath(path.Dir(b.MarkdownName), name)
+		})
+	}
+	return md
+}
+
+// replaceSubmatch replaces the first capture group of every match of pattern in s.
+func replaceSubmatch(s string, pattern *regexp.Regexp, replace func(string) string) string {
+	var (
+		sb   strings.Builder
+		last int
+	)
+	for _, m := range pattern.FindAllStringSubmatchIndex(s, -1) {
+		sb.WriteString(s[last:m[2]])
+		sb.WriteString(replace(s[m[2]:m[3]]))
+		last = m[3]
+	}
+	sb.WriteString(s[last:])
+	return sb.String()
+}
+
+func relativeEntryPath(fromDir, name string) string {
+	if fromDir == "." || fromDir == "" {
+		return name
+	}
+	rel, err := filepath.Rel(filepath.FromSlash(fromDir), filepath.FromSlash(name))
+	if err != nil {
+		return name
+	}
+	return filepath.ToSlash(rel)
+}
+
+// ExtractTo writes every entry under dir, keeping the archive layout. The Markdown entry is written
+// with image links rewritten by RewriteImageLinks. Absolute entries and entries that would land
+// outside dir fail with ErrUnsafeZIPPath before anything is written.
+func (b *ConvertBundle) ExtractTo(dir string) error {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolve extract dir: %w", err)
+	}
+
+	targets := make(map[string]string, len(b.Files))
+	for name := range b.Files {
+		local := filepath.FromSlash(name)
+		target := filepath.Join(root, local)
+		if path.IsAbs(name) || filepath.IsAbs(local) || filepath.VolumeName(local) != "" ||
+			!strings.HasPrefix(target, root+string(os.PathSeparator)) {
+			return fmt.Errorf("%w: %s", ErrUnsafeZIPPath, name)
+		}
+		targets[name] = target
+	}
+
+	for name, target := range targets {
+		content := b.Files[name]
+		if name == b.MarkdownName {
+			content = []byte(b.RewriteImageLinks())
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return fmt.Errorf("create dir: %w", err)
+		}
+		if err := os.WriteFile(target, content, 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+	}
+	return nil
+}
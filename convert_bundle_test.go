@@ -0,0 +1,164 @@
+package client
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// zipEntry is a file of a test archive; archives keep the entry order.
+type zipEntry struct {
+	name string
+	data string
+}
+
+func buildTestZIP(t *testing.T, entries ...zipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestOpenConvertBundle(t *testing.T) {
+	bundle, err := OpenConvertBundle(buildTestZIP(t,
+		zipEntry{"doc/output.md", "# Doc"},
+		zipEntry{`doc\images\0.png`, "png0"},
+		zipEntry{"doc/layout.json", "{}"},
+		zipEntry{"doc/notes.txt", "text"},
+	))
+	if err != nil {
+		t.Fatalf("OpenConvertBundle: %v", err)
+	}
+
+	if bundle.MarkdownName != "doc/output.md" || bundle.Markdown != "# Doc" {
+		t.Errorf("markdown = %s %q", bundle.MarkdownName, bundle.Markdown)
+	}
+	if want := []string{"doc/images/0.png"}; !reflect.DeepEqual(bundle.ImageNames(), want) {
+		t.Errorf("images = %q, want %q (backslashes normalized)", bundle.ImageNames(), want)
+	}
+	if _, ok := bundle.Sidecars["doc/layout.json"]; !ok || len(bundle.Files) != 4 {
+		t.Errorf("sidecars = %v, files = %d, want the json sidecar and 4 files", bundle.Sidecars, len(bundle.Files))
+	}
+
+	if _, err := OpenConvertBundle([]byte("not a zip")); err == nil {
+		t.Error("garbage opened as a bundle")
+	}
+}
+
+func TestOpenConvertBundlePrefersMarkdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []zipEntry
+		want    string
+	}{
+		{"shallowest wins", []zipEntry{{"a/b/deep.md", ""}, {"top.md", ""}, {"a/mid.md", ""}}, "top.md"},
+		{"output.md wins over shallower", []zipEntry{{"top.md", ""}, {"sub/output.md", ""}}, "sub/output.md"},
+		{"output.md is kept", []zipEntry{{"output.md", ""}, {"other.md", ""}}, "output.md"},
+		{"first of equal depth", []zipEntry{{"a.md", ""}, {"b.md", ""}}, "a.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := OpenConvertBundle(buildTestZIP(t, tt.entries...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bundle.MarkdownName != tt.want {
+				t.Errorf("markdown entry = %s, want %s", bundle.MarkdownName, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertBundleRewriteImageLinks(t *testing.T) {
+	bundle, err := OpenConvertBundle(buildTestZIP(t,
+		zipEntry{"doc/output.md", "![a](images/0.png)\n" +
+			"<img src=\"./images/1.png\" alt=\"b\">\n" +
+			"![c](<images/0.png>)\n" +
+			"![remote](https://cdn.example/0.png)\n" +
+			"![missing](gone.png)"},
+		zipEntry{"doc/images/0.png", "png0"},
+		zipEntry{"media/1.png", "png1"},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "![a](images/0.png)\n" +
+		"<img src=\"../media/1.png\" alt=\"b\">\n" +
+		"![c](<images/0.png>)\n" +
+		"![remote](https://cdn.example/0.png)\n" +
+		"![missing](gone.png)"
+	if got := bundle.RewriteImageLinks(); got != want {
+		t.Errorf("RewriteImageLinks =\n%s\nwant\n%s", got, want)
+	}
+
+	if data, ok := bundle.Image("./images/1.png"); !ok || string(data) != "png1" {
+		t.Errorf("Image by base name = %q, %v", data, ok)
+	}
+	if _, ok := bundle.Image("https://cdn.example/0.png"); ok {
+		t.Error("remote image resolved to a bundle entry")
+	}
+}
+
+func TestConvertBundleExtractTo(t *testing.T) {
+	bundle, err := OpenConvertBundle(buildTestZIP(t,
+		zipEntry{"doc/output.md", "![a](../media/0.png)"},
+		zipEntry{"media/0.png", "png0"},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := bundle.ExtractTo(dir); err != nil {
+		t.Fatalf("ExtractTo: %v", err)
+	}
+	for name, want := range map[string]string{"doc/output.md": "![a](../media/0.png)", "media/0.png": "png0"} {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v, want %q", name, data, err, want)
+		}
+	}
+}
+
+func TestConvertBundleExtractToRejectsUnsafePaths(t *testing.T) {
+	for _, name := range []string{"../evil", "a/../../evil", "/etc/evil"} {
+		t.Run(name, func(t *testing.T) {
+			bundle, err := OpenConvertBundle(buildTestZIP(t,
+				zipEntry{"output.md", "# ok"},
+				zipEntry{name, "evil"},
+			))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parent := t.TempDir()
+			dir := filepath.Join(parent, "out")
+			if err := bundle.ExtractTo(dir); !errors.Is(err, ErrUnsafeZIPPath) {
+				t.Fatalf("ExtractTo error = %v, want ErrUnsafeZIPPath", err)
+			}
+			if _, err := os.Stat(dir); !os.IsNotExist(err) {
+				t.Error("entries were written before the unsafe one was rejected")
+			}
+			if _, err := os.Stat(filepath.Join(parent, "evil")); !os.IsNotExist(err) {
+				t.Error("unsafe entry escaped the extract dir")
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"fmt"
@@ -11,53 +12,62 @@ import (
 // FetchConvertZIP resolves an image layout convert_zip payload into raw zip bytes.
 // The payload is expected to be base64 (optionally with a data URI prefix).
 func (c *client) FetchConvertZIP(ctx context.Context, convertZIP string) ([]byte, error) {
-	_ = ctx
-	payload, err := normalizeConvertZIP(convertZIP)
-	if err != nil {
+	var buf bytes.Buffer
+	buf.Grow(base64.StdEncoding.DecodedLen(len(convertZIP)))
+	if _, err := c.FetchConvertZIPTo(ctx, convertZIP, &buf); err != nil {
 		return nil, err
 	}
-
-	data, err := decodeBase64Payload(payload)
-	if err != nil {
-		return nil, fmt.Errorf("decode convert_zip failed: %w", err)
-	}
-
-	if len(data) == 0 {
-		return nil, fmt.Errorf("convert_zip payload is empty")
-	}
-
-	return data, nil
+	return buf.Bytes(), nil
 }
 
-// FetchConvertZIPTo resolves an image layout convert_zip payload into the provided writer.
-// The payload is expected to be base64 (optionally with a data URI prefix).
-func (c *client) FetchConvertZIPTo(ctx context.Context, convertZIP string, dst io.Writer) error {
-	_ = ctx
+// FetchConvertZIPTo streams a decoded image layout convert_zip payload into dst and returns the
+// number of decoded bytes written. The payload is expected to be base64 (optionally with a data URI
+// prefix); unpadded payloads are decoded with the raw encoding. Context cancellation is checked
+// between chunks, so a partial write is possible when an error is returned.
+func (c *client) FetchConvertZIPTo(ctx context.Context, convertZIP string, dst io.Writer) (int64, error) {
 	if dst == nil {
-		return ErrNilWriter
+		return 0, ErrNilWriter
 	}
 
 	payload, err := normalizeConvertZIP(convertZIP)
 	if err != nil {
-		return err
-	}
-
-	data, err := decodeBase64Payload(payload)
-	if err != nil {
-		return fmt.Errorf("decode convert_zip failed: %w", err)
+		return 0, err
 	}
 
-	if len(data) == 0 {
-		return fmt.Errorf("convert_zip payload is empty")
+	dec := base64.NewDecoder(payloadEncoding(payload), strings.NewReader(payload))
+	buf := make([]byte, convertZIPChunkSize)
+
+	var written int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		n, readErr := dec.Read(buf)
+		if n > 0 {
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return written, fmt.Errorf("write convert_zip payload failed: %w", err)
+			}
+			written += int64(n)
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return written, fmt.Errorf("decode convert_zip failed: %w", readErr)
+		}
 	}
 
-	if _, err := dst.Write(data); err != nil {
-		return fmt.Errorf("write convert_zip payload failed: %w", err)
+	if written == 0 {
+		return 0, fmt.Errorf("convert_zip payload is empty")
 	}
 
-	return nil
+	return written, nil
 }
 
+// convertZIPChunkSize is the decode buffer size; context is checked once per chunk.
+const convertZIPChunkSize = 32 << 10
+
 func normalizeConvertZIP(convertZIP string) (payload string, err error) {
 	payload = strings.TrimSpace(convertZIP)
 	if payload == "" {
@@ -86,20 +96,21 @@ func stripBase64DataURL(value string) string {
 	return value[idx+len("base64,"):]
 }
 
-func decodeBase64Payload(payload string) ([]byte, error) {
-	if payload == "" {
-		return nil, ErrEmptyConvertZIP
+// payloadEncoding picks the padded or raw standard encoding. Line breaks are ignored by the
+// decoder, so only the remaining characters decide whether padding is present.
+func payloadEncoding(payload string) *base64.Encoding {
+	if strings.HasSuffix(payload, "=") {
+		return base64.StdEncoding
 	}
 
-	data, err := base64.StdEncoding.DecodeString(payload)
-	if err == nil {
-		return data, nil
+	n := 0
+	for i := 0; i < len(payload); i++ {
+		if payload[i] != '\r' && payload[i] != '\n' {
+			n++
+		}
 	}
-
-	data, rawErr := base64.RawStdEncoding.DecodeString(payload)
-	if rawErr == nil {
-		return data, nil
+	if n%4 != 0 {
+		return base64.RawStdEncoding
 	}
-
-	return nil, err
+	return base64.StdEncoding
 }
@@ -0,0 +1,134 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// wrapLines breaks s into lines of n characters, as MIME encoders do.
+func wrapLines(s string, n int, sep string) string {
+	var sb strings.Builder
+	for len(s) > n {
+		sb.WriteString(s[:n])
+		sb.WriteString(sep)
+		s = s[n:]
+	}
+	sb.WriteString(s)
+	return sb.String()
+}
+
+func TestFetchConvertZIPTo(t *testing.T) {
+	short := []byte("PK\x03\x04 small zip") // 14 bytes, so the encoding is padded
+	large := make([]byte, 3*convertZIPChunkSize+123)
+	for i := range large {
+		large[i] = byte(i * 31 / 7)
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		want    []byte
+	}{
+		{"padded", base64.StdEncoding.EncodeToString(short), short},
+		{"data uri", "data:application/zip;base64," + base64.StdEncoding.EncodeToString(short), short},
+		{"unpadded", base64.RawStdEncoding.EncodeToString(short), short},
+		{"surrounding whitespace", "\n  " + base64.StdEncoding.EncodeToString(short) + " \n", short},
+		{"line breaks", wrapLines(base64.StdEncoding.EncodeToString(large), 76, "\r\n"), large},
+		{"unpadded with line breaks", wrapLines(base64.RawStdEncoding.EncodeToString(short), 5, "\n"), short},
+		{"spans chunks", base64.StdEncoding.EncodeToString(large), large},
+	}
+	c := NewClient("sk-test")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := c.FetchConvertZIPTo(context.Background(), tt.payload, &buf)
+			if err != nil {
+				t.Fatalf("FetchConvertZIPTo: %v", err)
+			}
+			if n != int64(len(tt.want)) || !bytes.Equal(buf.Bytes(), tt.want) {
+				t.Errorf("decoded %d bytes (%d written), want %d", buf.Len(), n, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestFetchConvertZIPToErrors(t *testing.T) {
+	c := NewClient("sk-test")
+	ctx := context.Background()
+
+	for _, payload := range []string{"", "  \n", "data:application/zip;base64,"} {
+		if _, err := c.FetchConvertZIPTo(ctx, payload, &bytes.Buffer{}); !errors.Is(err, ErrEmptyConvertZIP) {
+			t.Errorf("FetchConvertZIPTo(%q) error = %v, want ErrEmptyConvertZIP", payload, err)
+		}
+	}
+	if _, err := c.FetchConvertZIPTo(ctx, "not base64!", &bytes.Buffer{}); err == nil {
+		t.Error("invalid base64 decoded without error")
+	}
+	if _, err := c.FetchConvertZIPTo(ctx, "UEs=", nil); !errors.Is(err, ErrNilWriter) {
+		t.Errorf("nil writer error = %v, want ErrNilWriter", err)
+	}
+}
+
+// cancelWriter cancels its context after the first write.
+type cancelWriter struct {
+	bytes.Buffer
+	cancel context.CancelFunc
+}
+
+func (w *cancelWriter) Write(p []byte) (int, error) {
+	defer w.cancel()
+	return w.Buffer.Write(p)
+}
+
+func TestFetchConvertZIPToCanceledMidStream(t *testing.T) {
+	data := make([]byte, 4*convertZIPChunkSize)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := &cancelWriter{cancel: cancel}
+	n, err := NewClient("sk-test").FetchConvertZIPTo(ctx, base64.StdEncoding.EncodeToString(data), w)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("FetchConvertZIPTo error = %v, want context.Canceled", err)
+	}
+	if n == 0 || n >= int64(len(data)) || n != int64(w.Len()) {
+		t.Errorf("wrote %d bytes (reported %d) before stopping, want a partial write", w.Len(), n)
+	}
+}
+
+func TestPayloadEncoding(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    *base64.Encoding
+	}{
+		{"UEsDBA==", base64.StdEncoding},
+		{"UEsDBA", base64.RawStdEncoding},
+		{"UEsD", base64.StdEncoding},
+		{"UEsD\r\nBA==", base64.StdEncoding},
+		{"UE\nsD\nBA", base64.RawStdEncoding},
+	}
+	for _, tt := range tests {
+		if got := payloadEncoding(tt.payload); got != tt.want {
+			t.Errorf("payloadEncoding(%q) picked the wrong encoding", tt.payload)
+		}
+	}
+}
+
+func TestStripBase64DataURL(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"data:application/zip;base64,UEs=", "UEs="},
+		{"data:;base64,UEs=", "UEs="},
+		{"data:text/plain,UEs=", "data:text/plain,UEs="},
+		{"UEs=", "UEs="},
+	}
+	for _, tt := range tests {
+		if got := stripBase64DataURL(tt.in); got != tt.want {
+			t.Errorf("stripBase64DataURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
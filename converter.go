@@ -2,7 +2,9 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -35,11 +37,11 @@ func (c *client) ConvertParse(ctx context.Context, req ConvertRequest) (*Convert
 	result.TraceID = traceID
 
 	if !resp.IsSuccess() {
-		return nil, errStatus(OperationConvertParse, resp.StatusCode(), resp.Status(), traceID)
+		return nil, errStatus(OperationConvertParse, resp.StatusCode(), resp.Status(), resp.Header())
 	}
 
 	if err := ensureAPISuccess(result.Code, result.Msg); err != nil {
-		return nil, errCode(OperationConvertParse, result.Code, result.Msg, traceID)
+		return nil, errCode(OperationConvertParse, resp.StatusCode(), result.Code, result.Msg, traceID)
 	}
 
 	return &result, nil
@@ -66,11 +68,11 @@ func (c *client) GetConvertResult(ctx context.Context, uid string) (*ConvertResu
 	result.TraceID = traceID
 
 	if !resp.IsSuccess() {
-		return nil, errStatus(OperationGetConvertResult, resp.StatusCode(), resp.Status(), traceID)
+		return nil, errStatus(OperationGetConvertResult, resp.StatusCode(), resp.Status(), resp.Header())
 	}
 
 	if err := ensureAPISuccess(result.Code, result.Msg); err != nil {
-		return nil, errCode(OperationGetConvertResult, result.Code, result.Msg, traceID)
+		return nil, errCode(OperationGetConvertResult, resp.StatusCode(), result.Code, result.Msg, traceID)
 	}
 
 	return &result, nil
@@ -82,17 +84,150 @@ func (c *client) WaitForConversion(ctx context.Context, uid string, pollInterval
 		return nil, ErrEmptyUID
 	}
 
-	return waitWithPolling(ctx, uid, pollInterval, OperationConversion, c.processingTimeout, c.GetConvertResult, func(result *ConvertResultResponse) (bool, error) {
+	return c.waitForConversion(ctx, uid, pollInterval, "", "")
+}
+
+// staleConvertPolls bounds how long a success still reporting the previous conversion's URL is ignored.
+const staleConvertPolls = 5
+
+// waitForConversion polls like WaitForConversion for the conversion to format to. When staleURL is
+// set, a success reporting that URL is treated as the previous conversion of the same UID until the
+// task has been seen processing; if it is still reported after staleConvertPolls polls, the wait
+// fails rather than returning the other format's file.
+func (c *client) waitForConversion(ctx context.Context, uid string, pollInterval time.Duration, to ConvertFormat, staleURL string) (*ConvertResultResponse, error) {
+	var (
+		polls      int
+		processing bool
+	)
+	return waitWithPolling(ctx, uid, c.pollConfig(OperationConversion, pollInterval), c.GetConvertResult, convertTaskState, func(result *ConvertResultResponse) (bool, error) {
+		polls++
 		switch result.Data.Status {
 		case ConvertStatusSuccess:
 			if result.Data.URL == "" {
 				return false, fmt.Errorf("conversion succeeded but no download URL provided")
 			}
+			if staleURL != "" && result.Data.URL == staleURL && !processing {
+				if polls < staleConvertPolls {
+					return false, nil
+				}
+				return false, fmt.Errorf("conversion to %s still reports previous URL", to)
+			}
 			return true, nil
 		case ConvertStatusFailed:
 			return false, fmt.Errorf("conversion failed for UID %s (trace-id: %s)", uid, result.TraceID)
 		default:
+			processing = true
 			return false, nil
 		}
 	})
 }
+
+// ConvertAll converts uid into every requested format. The conversion result endpoint is keyed
+// only by UID, so requests run one after another and concurrent ConvertAll calls for the same
+// UID on this client wait for each other. Each request's UID is overwritten with uid; identical
+// requests are converted once. A failed format does not stop the remaining ones: the returned
+// slice holds one output per request and the error joins every per-format failure.
+func (c *client) ConvertAll(ctx context.Context, uid string, reqs []ConvertRequest) ([]ConvertOutput, error) {
+	if uid == "" {
+		return nil, ErrEmptyUID
+	}
+	if len(reqs) == 0 {
+		return nil, ErrEmptyTargetFormat
+	}
+
+	unlock, err := c.convertLocks.lock(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var (
+		outputs  = make([]ConvertOutput, 0, len(reqs))
+		errs     []error
+		staleURL string // Result of the previous format, which the next wait must not mistake for its own
+	)
+	for _, req := range reqs {
+		req.UID = uid
+		if req.FormulaMode == "" {
+			req.FormulaMode = FormulaModeNormal
+		}
+
+		if out, ok := findConvertOutput(outputs, req); ok {
+			outputs = append(outputs, out)
+			continue
+		}
+
+		out := ConvertOutput{Request: req}
+		if err := ctx.Err(); err != nil {
+			out.Err = err
+		} else if _, err := c.ConvertParse(ctx, req); err != nil {
+			out.Err = err
+		} else if result, err := c.waitForConversion(ctx, uid, 0, req.To, staleURL); err != nil {
+			out.Err = err
+		} else {
+			out.URL, out.TraceID = result.Data.URL, result.TraceID
+			staleURL = out.URL
+		}
+
+		if out.Err != nil {
+			errs = append(errs, fmt.Errorf("convert %s to %s: %w", uid, req.To, out.Err))
+		}
+		outputs = append(outputs, out)
+	}
+
+	return outputs, errors.Join(errs...)
+}
+
+func findConvertOutput(outputs []ConvertOutput, req ConvertRequest) (ConvertOutput, bool) {
+	for _, out := range outputs {
+		if out.Request == req {
+			return out, true
+		}
+	}
+	return ConvertOutput{}, false
+}
+
+// keyedMutex serialises work per key. The zero value is ready to use.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sem  chan struct{}
+	refs int
+}
+
+// lock acquires the lock for key, giving up when ctx is done.
+func (m *keyedMutex) lock(ctx context.Context, key string) (func(), error) {
+	m.mu.Lock()
+	if m.locks == nil {
+		m.locks = make(map[string]*keyedLock)
+	}
+	l, ok := m.locks[key]
+	if !ok {
+		l = &keyedLock{sem: make(chan struct{}, 1)}
+		m.locks[key] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+
+	release := func() {
+		m.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(m.locks, key)
+		}
+		m.mu.Unlock()
+	}
+
+	select {
+	case l.sem <- struct{}{}:
+		return func() {
+			<-l.sem
+			release()
+		}, nil
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,91 @@
+package client_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	client "github.com/hsn0918/doc2x-client"
+	"github.com/hsn0918/doc2x-client/doc2xtest"
+)
+
+func convertedContent(uid string, to client.ConvertFormat) []byte {
+	return []byte(uid + " as " + string(to))
+}
+
+func countRequests(srv *doc2xtest.Server, path string) int {
+	n := 0
+	for _, r := range srv.Requests() {
+		if r.Path == path {
+			n++
+		}
+	}
+	return n
+}
+
+func convertBoth(t *testing.T, script doc2xtest.Script) (*doc2xtest.Server, string, []client.ConvertOutput, error) {
+	t.Helper()
+	srv := doc2xtest.NewServer(doc2xtest.WithConvertScript(script), doc2xtest.WithConvertContent(convertedContent))
+	t.Cleanup(srv.Close)
+
+	c := srv.Client()
+	uid := uploadSample(t, c)
+	if _, err := c.WaitForParsing(context.Background(), uid, time.Millisecond); err != nil {
+		t.Fatalf("WaitForParsing: %v", err)
+	}
+
+	ctx := client.ContextWithPollInterval(context.Background(), time.Millisecond)
+	outputs, err := c.ConvertAll(ctx, uid, []client.ConvertRequest{
+		{To: client.FormatMarkdown},
+		{To: client.FormatDocx},
+	})
+	return srv, uid, outputs, err
+}
+
+func TestConvertAllWaitsOutPreviousURL(t *testing.T) {
+	_, uid, outputs, err := convertBoth(t, doc2xtest.Script{StalePolls: 2})
+	if err != nil {
+		t.Fatalf("ConvertAll: %v", err)
+	}
+	if len(outputs) != 2 {
+		t.Fatalf("got %d outputs, want 2", len(outputs))
+	}
+
+	c := client.NewClient("sk-test")
+	for _, out := range outputs {
+		if out.Err != nil || !strings.Contains(out.URL, "/"+string(out.Request.To)+"/") {
+			t.Errorf("%s output = %+v, want its own download URL", out.Request.To, out)
+			continue
+		}
+		data, err := c.DownloadFile(context.Background(), out.URL)
+		if err != nil {
+			t.Fatalf("DownloadFile: %v", err)
+		}
+		if want := string(convertedContent(uid, out.Request.To)); string(data) != want {
+			t.Errorf("%s file = %q, want %q", out.Request.To, data, want)
+		}
+	}
+}
+
+func TestConvertAllFailsOnPersistentPreviousURL(t *testing.T) {
+	srv, _, outputs, err := convertBoth(t, doc2xtest.Script{StalePolls: 100})
+	if err == nil {
+		t.Fatal("ConvertAll succeeded although docx kept reporting the md file")
+	}
+	if len(outputs) != 2 {
+		t.Fatalf("got %d outputs, want 2", len(outputs))
+	}
+	if md := outputs[0]; md.Err != nil || md.URL == "" {
+		t.Errorf("md output = %+v, want a finished conversion", md)
+	}
+	docx := outputs[1]
+	if docx.Err == nil || !strings.Contains(docx.Err.Error(), "still reports previous URL") || docx.URL != "" {
+		t.Errorf("docx output = %+v, want the previous URL rejected", docx)
+	}
+
+	// md finishes on its first poll; docx gives up after staleConvertPolls (5) polls.
+	if n := countRequests(srv, client.EndpointConvertResult); n != 1+5 {
+		t.Errorf("server received %d result polls, want 6", n)
+	}
+}
@@ -0,0 +1,433 @@
+package doc2xtest
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+type envelope struct {
+	Code string `json:"code"`
+	Msg  string `json:"msg,omitempty"`
+	Data any    `json:"data,omitempty"`
+}
+
+type pageJSON struct {
+	URL        string `json:"url"`
+	PageIdx    int    `json:"page_idx"`
+	PageWidth  int    `json:"page_width"`
+	PageHeight int    `json:"page_height"`
+	Md         string `json:"md"`
+}
+
+type resultJSON struct {
+	Version string     `json:"version,omitempty"`
+	Pages   []pageJSON `json:"pages"`
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	s.route(mux, http.MethodPost, client.EndpointParsePDF, s.handleParsePDF)
+	s.route(mux, http.MethodPost, client.EndpointPreUpload, s.handlePreUpload)
+	s.route(mux, http.MethodGet, client.EndpointParseStatus, s.handleParseStatus)
+	s.route(mux, http.MethodPost, client.EndpointConvertParse, s.handleConvertParse)
+	s.route(mux, http.MethodGet, client.EndpointConvertResult, s.handleConvertResult)
+	s.route(mux, http.MethodPost, client.EndpointParseImageLayout, s.handleImageLayout)
+	s.route(mux, http.MethodPost, client.EndpointAsyncParseImageLayout, s.handleAsyncImageLayout)
+	s.route(mux, http.MethodGet, client.EndpointParseImageLayoutStatus, s.handleImageLayoutStatus)
+	s.route(mux, http.MethodPut, UploadPathPrefix, s.handleUpload)
+	s.route(mux, http.MethodGet, DownloadPathPrefix, s.handleDownload)
+
+	return mux
+}
+
+// route registers fn behind request logging, trace ids, auth and failure injection.
+func (s *Server) route(mux *http.ServeMux, method, path string, fn http.HandlerFunc) {
+	public := strings.HasSuffix(path, "/")
+
+	mux.HandleFunc(method+" "+path, func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.traceSeq++
+		traceID := fmt.Sprintf("trace-%06d", s.traceSeq)
+		s.requests = append(s.requests, Request{
+			Method:  r.Method,
+			Path:    r.URL.Path,
+			UID:     requestUID(r),
+			TraceID: traceID,
+			Time:    time.Now(),
+		})
+		failure, failed := s.popFailure(path)
+		s.mu.Unlock()
+
+		w.Header().Set(client.TraceIDHeader, traceID)
+
+		if !public && s.apiKey != "" && r.Header.Get("Authorization") != "Bearer "+s.apiKey {
+			writeJSON(w, http.StatusUnauthorized, envelope{Code: "unauthorized", Msg: "invalid api key"})
+			return
+		}
+
+		if failed {
+			writeFailure(w, failure)
+			return
+		}
+
+		fn(w, r)
+	})
+}
+
+func (s *Server) popFailure(path string) (Failure, bool) {
+	queue := s.failures[path]
+	if len(queue) == 0 {
+		return Failure{}, false
+	}
+	s.failures[path] = queue[1:]
+	return queue[0], true
+}
+
+func (s *Server) handleParsePDF(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil || len(data) == 0 {
+		writeJSON(w, http.StatusOK, envelope{Code: "parse_file_invalid", Msg: "empty request body"})
+		return
+	}
+
+	s.mu.Lock()
+	t := s.newParseTask()
+	t.uploaded = true
+	s.uploads[t.uid] = data
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, envelope{Code: client.CodeSuccess, Data: map[string]string{"uid": t.uid}})
+}
+
+func (s *Server) handlePreUpload(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	t := s.newParseTask()
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, envelope{Code: client.CodeSuccess, Data: map[string]string{
+		"uid": t.uid,
+		"url": s.URL + UploadPathPrefix + t.uid,
+	}})
+}
+
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	uid := strings.TrimPrefix(r.URL.Path, UploadPathPrefix)
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.parses[uid]
+	if !ok {
+		http.Error(w, "unknown upload target", http.StatusForbidden)
+		return
+	}
+	t.uploaded = true
+	s.uploads[uid] = data
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleParseStatus(w http.ResponseWriter, r *http.Request) {
+	uid := r.URL.Query().Get("uid")
+
+	s.mu.Lock()
+	t, ok := s.parses[uid]
+	if !ok {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, envelope{Code: "parse_status_not_found", Msg: "task not found or expired"})
+		return
+	}
+	status, progress, detail := t.advance()
+	s.mu.Unlock()
+
+	data := map[string]any{
+		"progress": progress,
+		"status":   status,
+		"detail":   detail,
+	}
+	if status == client.StatusSuccess {
+		data["result"] = s.result(uid)
+	}
+
+	writeJSON(w, http.StatusOK, envelope{Code: client.CodeSuccess, Data: data})
+}
+
+func (s *Server) handleConvertParse(w http.ResponseWriter, r *http.Request) {
+	var req client.ConvertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, envelope{Code: "invalid_request", Msg: err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	parsed, ok := s.parses[req.UID]
+	if !ok || parsed.polls <= len(parsed.script.Progress) || parsed.script.Fail {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, envelope{Code: "parse_status_not_found", Msg: "parse task not finished or expired"})
+		return
+	}
+	t := &convertTask{
+		task: task{uid: req.UID, script: s.convertScript, uploaded: true},
+		req:  req,
+	}
+	if prev, ok := s.converts[req.UID]; ok {
+		t.previous = prev.url
+	}
+	s.converts[req.UID] = t
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, envelope{Code: client.CodeSuccess, Data: map[string]string{
+		"status": string(client.ConvertStatusProcessing),
+		"url":    "",
+	}})
+}
+
+func (s *Server) handleConvertResult(w http.ResponseWriter, r *http.Request) {
+	uid := r.URL.Query().Get("uid")
+
+	s.mu.Lock()
+	t, ok := s.converts[uid]
+	if !ok {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, envelope{Code: "parse_status_not_found", Msg: "conversion not found or expired"})
+		return
+	}
+	var status, url string
+	if t.previous != "" && t.stale < t.script.StalePolls {
+		t.stale++
+		status, url = client.StatusSuccess, t.previous
+	} else if status, _, _ = t.advance(); status == client.StatusSuccess {
+		// Each format gets its own URL, as conversions of one UID to several formats do upstream.
+		url = s.publish(uid+"/"+string(t.req.To), downloadName(uid, t.req), s.content(uid, t.req.To))
+		t.url = url
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, envelope{Code: client.CodeSuccess, Data: map[string]string{
+		"status": status,
+		"url":    url,
+	}})
+}
+
+func (s *Server) handleImageLayout(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil || len(data) == 0 {
+		writeJSON(w, http.StatusOK, envelope{Code: "parse_file_invalid", Msg: "empty request body"})
+		return
+	}
+
+	s.mu.Lock()
+	s.seq++
+	uid := fmt.Sprintf("img-%06d", s.seq)
+	s.uploads[uid] = data
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, envelope{Code: client.CodeSuccess, Data: map[string]any{
+		"uid":         uid,
+		"result":      s.result(uid),
+		"convert_zip": s.layoutZIP(),
+	}})
+}
+
+func (s *Server) handleAsyncImageLayout(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil || len(data) == 0 {
+		writeJSON(w, http.StatusOK, envelope{Code: "parse_file_invalid", Msg: "empty request body"})
+		return
+	}
+
+	s.mu.Lock()
+	s.seq++
+	uid := fmt.Sprintf("img-%06d", s.seq)
+	s.images[uid] = &task{uid: uid, script: s.imageScript, uploaded: true}
+	s.uploads[uid] = data
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, envelope{Code: client.CodeSuccess, Data: map[string]string{"uid": uid}})
+}
+
+func (s *Server) handleImageLayoutStatus(w http.ResponseWriter, r *http.Request) {
+	uid := r.URL.Query().Get("uid")
+
+	s.mu.Lock()
+	t, ok := s.images[uid]
+	if !ok {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, envelope{Code: "parse_status_not_found", Msg: "task not found or expired"})
+		return
+	}
+	status, progress, detail := t.advance()
+	s.mu.Unlock()
+
+	data := map[string]any{
+		"status":   status,
+		"progress": progress,
+		"detail":   detail,
+	}
+	if status == client.StatusSuccess {
+		data["result"] = s.result(uid)
+		data["convert_zip"] = s.layoutZIP()
+	}
+
+	writeJSON(w, http.StatusOK, envelope{Code: client.CodeSuccess, Data: data})
+}
+
+func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	data, ok := s.files[r.URL.Path]
+	s.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	sum := md5.Sum(data)
+	w.Header().Set("ETag", `"`+strings.ToUpper(hex.EncodeToString(sum[:]))+`"`)
+	http.ServeContent(w, r, r.URL.Path, time.Time{}, bytes.NewReader(data))
+}
+
+// newParseTask registers a parse task using the default script. Callers hold s.mu.
+func (s *Server) newParseTask() *task {
+	s.seq++
+	t := &task{uid: fmt.Sprintf("uid-%06d", s.seq), script: s.parseScript}
+	s.parses[t.uid] = t
+	return t
+}
+
+// publish stores data for download and returns its URL. Callers hold s.mu.
+func (s *Server) publish(uid, name string, data []byte) string {
+	path := DownloadPathPrefix + uid + "/" + name
+	s.files[path] = data
+	return s.URL + path
+}
+
+func (s *Server) result(uid string) resultJSON {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pages := make([]pageJSON, len(s.pages))
+	for i, p := range s.pages {
+		pages[i] = pageJSON{
+			URL:        fmt.Sprintf("%s%s%s/page_%d.png", s.URL, DownloadPathPrefix, uid, i),
+			PageIdx:    i,
+			PageWidth:  p.Width,
+			PageHeight: p.Height,
+			Md:         p.Md,
+		}
+	}
+	return resultJSON{Version: "doc2xtest", Pages: pages}
+}
+
+// layoutZIP builds the base64 convert_zip payload returned by image layout endpoints.
+func (s *Server) layoutZIP() string {
+	s.mu.Lock()
+	var md strings.Builder
+	for i, p := range s.pages {
+		if i > 0 {
+			md.WriteString("\n\n")
+		}
+		md.WriteString(p.Md)
+	}
+	s.mu.Unlock()
+
+	md.WriteString("\n\n![](images/0.png)\n")
+	data := buildZIP(map[string][]byte{
+		"output.md":    []byte(md.String()),
+		"images/0.png": fakePNG,
+	})
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func defaultContent(uid string, to client.ConvertFormat) []byte {
+	switch to {
+	case client.FormatDocx:
+		return buildZIP(map[string][]byte{"word/document.xml": []byte("<w:document/>")})
+	case client.FormatTex:
+		return buildZIP(map[string][]byte{uid + ".tex": []byte("\\section{Sample}\n")})
+	default:
+		return buildZIP(map[string][]byte{uid + ".md": []byte("# Sample\n")})
+	}
+}
+
+func downloadName(uid string, req client.ConvertRequest) string {
+	name := req.Filename
+	if name == "" {
+		name = uid
+	}
+	if req.To == client.FormatDocx {
+		return name + ".docx"
+	}
+	return name + ".zip"
+}
+
+func buildZIP(files map[string][]byte) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		f, err := zw.Create(name)
+		if err != nil {
+			panic(err)
+		}
+		if _, err := f.Write(data); err != nil {
+			panic(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// fakePNG is the 8-byte PNG signature, enough for content sniffing.
+var fakePNG = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+func requestUID(r *http.Request) string {
+	if uid := r.URL.Query().Get("uid"); uid != "" {
+		return uid
+	}
+	if uid, ok := strings.CutPrefix(r.URL.Path, UploadPathPrefix); ok {
+		return uid
+	}
+	return ""
+}
+
+func writeFailure(w http.ResponseWriter, f Failure) {
+	for key, values := range f.Header {
+		for _, v := range values {
+			w.Header().Set(key, v)
+		}
+	}
+
+	code := f.Code
+	if code == "" {
+		code = client.CodeFailed
+	}
+	status := f.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, envelope{Code: code, Msg: f.Msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,319 @@
+// Package doc2xtest provides an in-process fake of the Doc2X v2 API.
+//
+// The fake is backed by httptest and implements every endpoint used by the
+// client package, so code built on client.Client can be exercised without
+// network access:
+//
+//	srv := doc2xtest.NewServer()
+//	defer srv.Close()
+//
+//	c := client.NewClient("sk-test", client.WithBaseURL(srv.URL))
+//
+// Task progress, failures and download payloads are scriptable through Script
+// and Failure values.
+package doc2xtest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"time"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+// Path prefixes served by the fake for presigned uploads and file downloads.
+const (
+	UploadPathPrefix   = "/upload/"
+	DownloadPathPrefix = "/files/"
+)
+
+// Script describes how a fake task advances across successive status polls.
+type Script struct {
+	// Progress lists the progress values reported by successive polls while the
+	// task is still processing. Once exhausted the task reaches its final state.
+	Progress []int
+	// Fail makes the task finish with a failed status instead of success.
+	Fail bool
+	// Detail is reported alongside a failed status.
+	Detail string
+	// StalePolls makes a conversion of a UID that was converted before report the previous
+	// conversion, success and download URL included, for that many polls before its own
+	// progress, as the upstream result endpoint does until it picks up a new request.
+	// It has no effect on parse and image tasks.
+	StalePolls int
+}
+
+// Failure describes an injected error response for a single request.
+type Failure struct {
+	// StatusCode is the HTTP status to answer with. Zero means 200 with a failing code.
+	StatusCode int
+	// Code is the API code placed in the response body. Defaults to "failed".
+	Code string
+	// Msg is the API message placed in the response body.
+	Msg string
+	// Header carries extra response headers, e.g. Retry-After or trace-id overrides.
+	Header http.Header
+}
+
+// Request records a call received by the fake.
+type Request struct {
+	Method  string
+	Path    string
+	UID     string
+	TraceID string
+	Time    time.Time
+}
+
+// Page is the parse result content returned for a single page.
+type Page struct {
+	Md     string
+	Width  int
+	Height int
+}
+
+// ContentFunc produces the downloadable payload for a finished conversion.
+type ContentFunc func(uid string, to client.ConvertFormat) []byte
+
+// Option customizes a Server.
+type Option func(*Server)
+
+// WithAPIKey requires requests to carry "Authorization: Bearer <apiKey>".
+func WithAPIKey(apiKey string) Option {
+	return func(s *Server) {
+		s.apiKey = apiKey
+	}
+}
+
+// WithParseScript sets the default script used for new parse tasks.
+func WithParseScript(script Script) Option {
+	return func(s *Server) {
+		s.parseScript = script
+	}
+}
+
+// WithConvertScript sets the default script used for new conversion tasks.
+func WithConvertScript(script Script) Option {
+	return func(s *Server) {
+		s.convertScript = script
+	}
+}
+
+// WithImageScript sets the default script used for new async image layout tasks.
+func WithImageScript(script Script) Option {
+	return func(s *Server) {
+		s.imageScript = script
+	}
+}
+
+// WithPages sets the pages returned by successful parse and image layout tasks.
+func WithPages(pages ...Page) Option {
+	return func(s *Server) {
+		s.pages = pages
+	}
+}
+
+// WithConvertContent overrides the payload served for finished conversions.
+func WithConvertContent(fn ContentFunc) Option {
+	return func(s *Server) {
+		if fn != nil {
+			s.content = fn
+		}
+	}
+}
+
+// Server is a scriptable fake of the Doc2X v2 API.
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	apiKey        string
+	seq           int
+	traceSeq      int
+	parseScript   Script
+	convertScript Script
+	imageScript   Script
+	pages         []Page
+	content       ContentFunc
+	parses        map[string]*task
+	converts      map[string]*convertTask
+	images        map[string]*task
+	uploads       map[string][]byte
+	files         map[string][]byte
+	failures      map[string][]Failure
+	requests      []Request
+}
+
+type task struct {
+	uid      string
+	script   Script
+	polls    int
+	uploaded bool
+}
+
+type convertTask struct {
+	task
+	req      client.ConvertRequest
+	url      string // Download URL, set once the conversion finished
+	previous string // Download URL of the UID's previous conversion
+	stale    int    // Polls answered with the previous conversion
+}
+
+// NewServer starts a fake Doc2X server. Callers must Close it when done.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		parseScript:   Script{Progress: []int{50}},
+		convertScript: Script{Progress: []int{50}},
+		imageScript:   Script{Progress: []int{50}},
+		pages:         []Page{{Md: "# Sample\n\nHello from doc2xtest.", Width: 1240, Height: 1754}},
+		content:       defaultContent,
+		parses:        make(map[string]*task),
+		converts:      make(map[string]*convertTask),
+		images:        make(map[string]*task),
+		uploads:       make(map[string][]byte),
+		files:         make(map[string][]byte),
+		failures:      make(map[string][]Failure),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.Server = httptest.NewServer(s.routes())
+	return s
+}
+
+// Client returns a client.Client configured to talk to the fake.
+func (s *Server) Client(opts ...client.Option) client.Client {
+	apiKey := s.apiKey
+	if apiKey == "" {
+		apiKey = "sk-doc2xtest"
+	}
+	opts = append([]client.Option{client.WithBaseURL(s.URL)}, opts...)
+	return client.NewClient(apiKey, opts...)
+}
+
+// FailNext queues failures for the next requests hitting path.
+// Path is one of the client.Endpoint* constants, UploadPathPrefix or DownloadPathPrefix.
+func (s *Server) FailNext(path string, failures ...Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[path] = append(s.failures[path], failures...)
+}
+
+// SetParseScript replaces the script of an existing parse task and restarts its polls.
+func (s *Server) SetParseScript(uid string, script Script) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.parses[uid]
+	if !ok {
+		return fmt.Errorf("doc2xtest: unknown parse uid %s", uid)
+	}
+	t.script = script
+	t.polls = 0
+	return nil
+}
+
+// SetConvertScript replaces the script of an existing conversion task and restarts its polls.
+func (s *Server) SetConvertScript(uid string, script Script) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.converts[uid]
+	if !ok {
+		return fmt.Errorf("doc2xtest: unknown convert uid %s", uid)
+	}
+	t.script = script
+	t.polls, t.stale = 0, 0
+	return nil
+}
+
+// SetImageScript replaces the script of an existing async image task and restarts its polls.
+func (s *Server) SetImageScript(uid string, script Script) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.images[uid]
+	if !ok {
+		return fmt.Errorf("doc2xtest: unknown image uid %s", uid)
+	}
+	t.script = script
+	t.polls = 0
+	return nil
+}
+
+// Expire forgets every task for uid, mimicking the 24 h server-side expiry.
+func (s *Server) Expire(uid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.parses, uid)
+	delete(s.converts, uid)
+	delete(s.images, uid)
+}
+
+// Uploaded returns the bytes received for uid through either upload flow.
+func (s *Server) Uploaded(uid string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.uploads[uid]
+	return data, ok
+}
+
+// UIDs returns the UIDs of every parse task created so far, sorted.
+func (s *Server) UIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uids := make([]string, 0, len(s.parses))
+	for uid := range s.parses {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+	return uids
+}
+
+// Requests returns a copy of every request received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Request, len(s.requests))
+	copy(out, s.requests)
+	return out
+}
+
+// advance returns the state reported by the next poll of t.
+func (t *task) advance() (status string, progress int, detail string) {
+	if !t.uploaded {
+		return client.StatusProcessing, 0, ""
+	}
+
+	idx := t.polls
+	t.polls++
+
+	if idx < len(t.script.Progress) {
+		return client.StatusProcessing, t.script.Progress[idx], ""
+	}
+
+	if t.script.Fail {
+		detail = t.script.Detail
+		if detail == "" {
+			detail = "scripted failure"
+		}
+		return client.StatusFailed, lastProgress(t.script.Progress), detail
+	}
+
+	return client.StatusSuccess, 100, ""
+}
+
+func lastProgress(progress []int) int {
+	if len(progress) == 0 {
+		return 0
+	}
+	return progress[len(progress)-1]
+}
@@ -0,0 +1,151 @@
+package doc2xtest_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	client "github.com/hsn0918/doc2x-client"
+	"github.com/hsn0918/doc2x-client/doc2xtest"
+)
+
+var samplePDF = []byte("%PDF-1.4\n% doc2xtest sample\n")
+
+func TestRoundTrip(t *testing.T) {
+	srv := doc2xtest.NewServer(
+		doc2xtest.WithParseScript(doc2xtest.Script{Progress: []int{10, 60}}),
+		doc2xtest.WithPages(doc2xtest.Page{Md: "# Title\n\nBody", Width: 600, Height: 800}),
+	)
+	defer srv.Close()
+
+	c := srv.Client()
+	ctx := context.Background()
+
+	uploaded, err := c.Upload(ctx, bytes.NewReader(samplePDF), int64(len(samplePDF)))
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if uploaded.Method != client.UploadMethodDirect {
+		t.Errorf("upload method = %s, want %s", uploaded.Method, client.UploadMethodDirect)
+	}
+	if data, ok := srv.Uploaded(uploaded.UID); !ok || !bytes.Equal(data, samplePDF) {
+		t.Errorf("server received %q, want %q", data, samplePDF)
+	}
+
+	status, err := c.WaitForParsing(ctx, uploaded.UID, time.Millisecond)
+	if err != nil {
+		t.Fatalf("WaitForParsing: %v", err)
+	}
+	if status.Data.Status != client.ParseStatusSuccess {
+		t.Fatalf("parse status = %s, want success", status.Data.Status)
+	}
+	pages := status.Data.Result.Pages
+	if len(pages) != 1 || pages[0].Md != "# Title\n\nBody" || pages[0].PageWidth != 600 {
+		t.Errorf("unexpected pages: %+v", pages)
+	}
+
+	if _, err := c.ConvertParse(ctx, client.ConvertRequest{UID: uploaded.UID, To: client.FormatMarkdown}); err != nil {
+		t.Fatalf("ConvertParse: %v", err)
+	}
+	converted, err := c.WaitForConversion(ctx, uploaded.UID, time.Millisecond)
+	if err != nil {
+		t.Fatalf("WaitForConversion: %v", err)
+	}
+	if converted.Data.URL == "" {
+		t.Fatal("conversion finished without a download URL")
+	}
+
+	data, err := c.DownloadFile(ctx, converted.Data.URL)
+	if err != nil {
+		t.Fatalf("DownloadFile: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("download is not a zip: %v", err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != uploaded.UID+".md" {
+		t.Errorf("unexpected zip entries: %v", zr.File)
+	}
+}
+
+func TestPresignedUpload(t *testing.T) {
+	srv := doc2xtest.NewServer()
+	defer srv.Close()
+
+	c := srv.Client()
+	ctx := context.Background()
+
+	pre, err := c.PreUpload(ctx)
+	if err != nil {
+		t.Fatalf("PreUpload: %v", err)
+	}
+	if err := c.UploadToPresignedURL(ctx, pre.Data.URL, samplePDF); err != nil {
+		t.Fatalf("UploadToPresignedURL: %v", err)
+	}
+	if data, ok := srv.Uploaded(pre.Data.UID); !ok || !bytes.Equal(data, samplePDF) {
+		t.Errorf("server received %q, want %q", data, samplePDF)
+	}
+	if _, err := c.WaitForParsing(ctx, pre.Data.UID, time.Millisecond); err != nil {
+		t.Fatalf("WaitForParsing: %v", err)
+	}
+}
+
+func TestScriptedParseFailure(t *testing.T) {
+	srv := doc2xtest.NewServer(doc2xtest.WithParseScript(doc2xtest.Script{Progress: []int{30}, Fail: true, Detail: "bad scan"}))
+	defer srv.Close()
+
+	c := srv.Client()
+	ctx := context.Background()
+
+	uploaded, err := c.UploadPDF(ctx, samplePDF)
+	if err != nil {
+		t.Fatalf("UploadPDF: %v", err)
+	}
+	_, err = c.WaitForParsing(ctx, uploaded.Data.UID, time.Millisecond)
+	if !errors.Is(err, client.ErrParseFailed) {
+		t.Fatalf("WaitForParsing error = %v, want ErrParseFailed", err)
+	}
+}
+
+func TestFailNextAndExpire(t *testing.T) {
+	srv := doc2xtest.NewServer(doc2xtest.WithAPIKey("sk-test"))
+	defer srv.Close()
+
+	c := srv.Client()
+	ctx := context.Background()
+
+	srv.FailNext(client.EndpointParsePDF, doc2xtest.Failure{Code: client.CodeQuotaLimit, Msg: "no quota"})
+	if _, err := c.UploadPDF(ctx, samplePDF); !errors.Is(err, client.ErrQuotaExceeded) {
+		t.Fatalf("first UploadPDF error = %v, want ErrQuotaExceeded", err)
+	}
+
+	uploaded, err := c.UploadPDF(ctx, samplePDF)
+	if err != nil {
+		t.Fatalf("second UploadPDF: %v", err)
+	}
+	uid := uploaded.Data.UID
+
+	srv.Expire(uid)
+	if _, err := c.GetStatus(ctx, uid); !errors.Is(err, client.ErrTaskExpired) {
+		t.Fatalf("GetStatus after Expire error = %v, want ErrTaskExpired", err)
+	}
+
+	unauthorized := client.NewClient("sk-wrong", client.WithBaseURL(srv.URL))
+	if _, err := unauthorized.UploadPDF(ctx, samplePDF); !errors.Is(err, client.ErrUnauthorized) {
+		t.Fatalf("UploadPDF with a wrong key error = %v, want ErrUnauthorized", err)
+	}
+
+	var traced int
+	for _, req := range srv.Requests() {
+		if req.Method == http.MethodPost && req.Path == client.EndpointParsePDF && req.TraceID != "" {
+			traced++
+		}
+	}
+	if traced != 3 {
+		t.Errorf("recorded %d traced upload requests, want 3", traced)
+	}
+}
@@ -0,0 +1,284 @@
+package client
+
+import (
+	"regexp"
+	"strings"
+)
+
+// BlockKind enumerates the structural elements recognised in page Markdown.
+type BlockKind string
+
+const (
+	BlockHeading   BlockKind = "heading"
+	BlockParagraph BlockKind = "paragraph"
+	BlockTable     BlockKind = "table"
+	BlockFormula   BlockKind = "formula" // Display formula on its own lines
+	BlockImage     BlockKind = "image"
+	BlockCaption   BlockKind = "caption"
+	BlockFootnote  BlockKind = "footnote"
+)
+
+// ImageRef is an image referenced from page Markdown.
+type ImageRef struct {
+	URL string `json:"url"`
+	Alt string `json:"alt,omitempty"`
+}
+
+// Block is one structural element of a page.
+type Block struct {
+	Kind     BlockKind `json:"kind"`
+	Page     int       `json:"page"`               // Page index the block belongs to
+	Order    int       `json:"order"`              // Position within the page, starting from 0
+	Text     string    `json:"text"`               // Markdown source; heading and footnote markers are stripped
+	Level    int       `json:"level,omitempty"`    // Heading level (1-6)
+	Label    string    `json:"label,omitempty"`    // Footnote label
+	Image    *ImageRef `json:"image,omitempty"`    // Set for image blocks
+	Formulas []Formula `json:"formulas,omitempty"` // Formulas within Text; offsets are relative to Text
+}
+
+// DocumentPage is a page of a Document.
+type DocumentPage struct {
+	Index  int     `json:"index"`
+	Width  int     `json:"width"`
+	Height int     `json:"height"`
+	URL    string  `json:"url,omitempty"` // Page preview image URL
+	Blocks []Block `json:"blocks"`
+}
+
+// Document is the structured form of a parse result.
+type Document struct {
+	Version string         `json:"version,omitempty"`
+	Pages   []DocumentPage `json:"pages"`
+}
+
+// Blocks returns every block of the document in reading order.
+func (d *Document) Blocks() []Block {
+	var blocks []Block
+	for _, page := range d.Pages {
+		blocks = append(blocks, page.Blocks...)
+	}
+	return blocks
+}
+
+// Document turns the Markdown of every page into typed blocks.
+func (r *ParseResult) Document() *Document {
+	doc := &Document{Version: r.Version, Pages: make([]DocumentPage, 0, len(r.Pages))}
+	for _, page := range r.Pages {
+		doc.Pages = append(doc.Pages, DocumentPage{
+			Index:  page.PageIdx,
+			Width:  page.PageWidth,
+			Height: page.PageHeight,
+			URL:    page.URL,
+			Blocks: ParseMarkdown(page.PageIdx, page.Md),
+		})
+	}
+	return doc
+}
+
+// Document turns the Markdown of every image layout page into typed blocks.
+func (r *ImageLayoutResult) Document() *Document {
+	doc := &Document{Pages: make([]DocumentPage, 0, len(r.Pages))}
+	for _, page := range r.Pages {
+		doc.Pages = append(doc.Pages, DocumentPage{
+			Index:  page.PageIdx,
+			Width:  page.PageWidth,
+			Height: page.PageHeight,
+			URL:    page.URL,
+			Blocks: ParseMarkdown(page.PageIdx, page.Md),
+		})
+	}
+	return doc
+}
+
+var (
+	headingPattern       = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	footnotePattern      = regexp.MustCompile(`^\[\^([^\]]+)\]:\s*(.*)$`)
+	markdownImagePattern = regexp.MustCompile(`^!\[([^\]]*)\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)$`)
+	htmlImagePattern     = regexp.MustCompile(`(?i)<img\b[^>]*\bsrc\s*=\s*["']([^"']+)["'][^>]*>`)
+	htmlAltPattern       = regexp.MustCompile(`(?i)\balt\s*=\s*["']([^"']*)["']`)
+	captionPattern       = regexp.MustCompile(`(?i)^(\*\*)?(figure|fig\.|table|tab\.|图|表)\s*\d`)
+	pipeSeparatorPattern = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+)
+
+// ParseMarkdown splits the Markdown of a single page into typed blocks.
+func ParseMarkdown(page int, md string) []Block {
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+
+	var blocks []Block
+	add := func(b Block) {
+		b.Page = page
+		b.Order = len(blocks)
+		blocks = append(blocks, b)
+	}
+
+	for i := 0; i < len(lines); {
+		line := strings.TrimSpace(lines[i])
+
+		switch {
+		case line == "":
+			i++
+		case strings.HasPrefix(line, "<!--"):
+			i = findClosingLine(lines, i, "<!--", "-->") + 1
+		case hasPrefixFold(line, "<table"):
+			end := findClosingLine(lines, i, "<table", "</table>")
+			add(Block{Kind: BlockTable, Text: joinLines(lines, i, end)})
+			i = end + 1
+		case isDisplayFormulaStart(line):
+			end := findClosingLine(lines, i, displayOpen(line), displayClose(line))
+			text := joinLines(lines, i, end)
+			add(Block{Kind: BlockFormula, Text: text, Formulas: findFormulas(text)})
+			i = end + 1
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			add(Block{Kind: BlockHeading, Level: len(m[1]), Text: m[2], Formulas: findFormulas(m[2])})
+			i++
+		case isPipeTableStart(lines, i):
+			end := i + 1
+			for end+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[end+1]), "|") {
+				end++
+			}
+			add(Block{Kind: BlockTable, Text: joinLines(lines, i, end)})
+			i = end + 1
+		case imageRef(line) != nil:
+			add(Block{Kind: BlockImage, Text: line, Image: imageRef(line)})
+			i++
+		case footnotePattern.MatchString(line):
+			m := footnotePattern.FindStringSubmatch(line)
+			end := i
+			for end+1 < len(lines) && isContinuation(lines[end+1]) {
+				end++
+			}
+			text := m[2]
+			if end > i {
+				text = strings.TrimSpace(text + "\n" + joinLines(lines, i+1, end))
+			}
+			add(Block{Kind: BlockFootnote, Label: m[1], Text: text, Formulas: findFormulas(text)})
+			i = end + 1
+		default:
+			end := i
+			for end+1 < len(lines) && strings.TrimSpace(lines[end+1]) != "" && !startsBlock(lines, end+1) {
+				end++
+			}
+			text := joinLines(lines, i, end)
+			add(Block{Kind: BlockParagraph, Text: text, Formulas: findFormulas(text)})
+			i = end + 1
+		}
+	}
+
+	markCaptions(blocks)
+	return blocks
+}
+
+// markCaptions turns short "Figure 1"/"Table 2" paragraphs next to images or tables into captions.
+func markCaptions(blocks []Block) {
+	for i := range blocks {
+		if blocks[i].Kind != BlockParagraph || !captionPattern.MatchString(blocks[i].Text) {
+			continue
+		}
+		if (i > 0 && isFigure(blocks[i-1].Kind)) || (i+1 < len(blocks) && isFigure(blocks[i+1].Kind)) {
+			blocks[i].Kind = BlockCaption
+		}
+	}
+}
+
+func isFigure(kind BlockKind) bool {
+	return kind == BlockImage || kind == BlockTable
+}
+
+// startsBlock reports whether lines[i] opens a block other than a paragraph continuation.
+func startsBlock(lines []string, i int) bool {
+	line := strings.TrimSpace(lines[i])
+	return strings.HasPrefix(line, "<!--") ||
+		hasPrefixFold(line, "<table") ||
+		isDisplayFormulaStart(line) ||
+		headingPattern.MatchString(line) ||
+		isPipeTableStart(lines, i) ||
+		imageRef(line) != nil ||
+		footnotePattern.MatchString(line)
+}
+
+func isPipeTableStart(lines []string, i int) bool {
+	if !strings.HasPrefix(strings.TrimSpace(lines[i]), "|") || i+1 >= len(lines) {
+		return false
+	}
+	sep := strings.TrimSpace(lines[i+1])
+	return strings.Contains(sep, "-") && pipeSeparatorPattern.MatchString(sep)
+}
+
+func isDisplayFormulaStart(line string) bool {
+	return strings.HasPrefix(line, "$$") || strings.HasPrefix(line, `\[`) || strings.HasPrefix(line, `\begin{equation`)
+}
+
+func displayOpen(line string) string {
+	switch {
+	case strings.HasPrefix(line, "$$"):
+		return "$$"
+	case strings.HasPrefix(line, `\[`):
+		return `\[`
+	default:
+		return `\begin{equation`
+	}
+}
+
+func displayClose(line string) string {
+	switch {
+	case strings.HasPrefix(line, "$$"):
+		return "$$"
+	case strings.HasPrefix(line, `\[`):
+		return `\]`
+	default:
+		return `\end{equation`
+	}
+}
+
+// findClosingLine returns the index of the line holding closing, which may share the opening line.
+// Unterminated blocks extend to the end of the page.
+func findClosingLine(lines []string, start int, opening, closing string) int {
+	first := strings.TrimSpace(lines[start])
+	if idx := strings.Index(first, opening); idx != -1 && strings.Contains(first[idx+len(opening):], closing) {
+		return start
+	}
+	for j := start + 1; j < len(lines); j++ {
+		if strings.Contains(lines[j], closing) {
+			return j
+		}
+	}
+	return len(lines) - 1
+}
+
+// imageRef extracts the image on a line consisting solely of a Markdown or HTML image.
+func imageRef(line string) *ImageRef {
+	if m := markdownImagePattern.FindStringSubmatch(line); m != nil {
+		return &ImageRef{URL: m[2], Alt: m[1]}
+	}
+	if !strings.HasPrefix(line, "<") {
+		return nil
+	}
+	m := htmlImagePattern.FindStringSubmatch(line)
+	if m == nil || strings.TrimSpace(stripTags(line)) != "" {
+		return nil
+	}
+	ref := &ImageRef{URL: m[1]}
+	if alt := htmlAltPattern.FindStringSubmatch(m[0]); alt != nil {
+		ref.Alt = alt[1]
+	}
+	return ref
+}
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+func stripTags(s string) string {
+	return tagPattern.ReplaceAllString(s, "")
+}
+
+func isContinuation(line string) bool {
+	return strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")
+}
+
+func joinLines(lines []string, start, end int) string {
+	return strings.TrimSpace(strings.Join(lines[start:end+1], "\n"))
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"testing"
+)
+
+func TestParseMarkdownBlocks(t *testing.T) {
+	md := "## Intro ##\n\n" +
+		"Energy $E=mc^2$ holds\nacross lines.\n\n" +
+		"$$\nx^2\n$$\n\n" +
+		"<!-- Media\nskipped -->\n\n" +
+		"![plot](https://cdn.example/p0.png)\n\n" +
+		"Figure 1: a plot\n\n" +
+		"| a | b |\n|---|---|\n| 1 | 2 |\n\n" +
+		"<table><tr><td>x</td></tr></table>\n\n" +
+		"[^1]: a note\n    that continues"
+
+	blocks := ParseMarkdown(3, md)
+
+	want := []struct {
+		kind BlockKind
+		text string
+	}{
+		{BlockHeading, "Intro"},
+		{BlockParagraph, "Energy $E=mc^2$ holds\nacross lines."},
+		{BlockFormula, "$$\nx^2\n$$"},
+		{BlockImage, "![plot](https://cdn.example/p0.png)"},
+		{BlockCaption, "Figure 1: a plot"},
+		{BlockTable, "| a | b |\n|---|---|\n| 1 | 2 |"},
+		{BlockTable, "<table><tr><td>x</td></tr></table>"},
+		{BlockFootnote, "a note\nthat continues"},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("got %d blocks, want %d: %+v", len(blocks), len(want), blocks)
+	}
+	for i, b := range blocks {
+		if b.Kind != want[i].kind || b.Text != want[i].text {
+			t.Errorf("block %d = %s %q, want %s %q", i, b.Kind, b.Text, want[i].kind, want[i].text)
+		}
+		if b.Page != 3 || b.Order != i {
+			t.Errorf("block %d: page %d order %d, want page 3 order %d", i, b.Page, b.Order, i)
+		}
+	}
+
+	if blocks[0].Level != 2 {
+		t.Errorf("heading level = %d, want 2", blocks[0].Level)
+	}
+	if f := blocks[1].Formulas; len(f) != 1 || f[0].Latex != "E=mc^2" || f[0].Offset != len("Energy ") {
+		t.Errorf("paragraph formulas = %+v", f)
+	}
+	if img := blocks[3].Image; img == nil || img.URL != "https://cdn.example/p0.png" || img.Alt != "plot" {
+		t.Errorf("image = %+v", img)
+	}
+	if blocks[7].Label != "1" {
+		t.Errorf("footnote label = %q, want 1", blocks[7].Label)
+	}
+}
+
+func TestParseMarkdownCaptionNeedsFigure(t *testing.T) {
+	blocks := ParseMarkdown(0, "Table 2 shows nothing here.\n\nJust text.")
+	for _, b := range blocks {
+		if b.Kind != BlockParagraph {
+			t.Errorf("block %q is %s, want paragraph without an adjacent table or image", b.Text, b.Kind)
+		}
+	}
+}
+
+func TestParseMarkdownHTMLImageAndUnterminatedFormula(t *testing.T) {
+	blocks := ParseMarkdown(0, "<img src=\"https://cdn.example/a.png\" alt=\"chart\"/>\n\n\\[\na+b")
+	if len(blocks) != 2 {
+		t.Fatalf("got %d blocks, want 2: %+v", len(blocks), blocks)
+	}
+	if img := blocks[0].Image; blocks[0].Kind != BlockImage || img == nil || img.URL != "https://cdn.example/a.png" || img.Alt != "chart" {
+		t.Errorf("html image block = %+v", blocks[0])
+	}
+	if blocks[1].Kind != BlockFormula || blocks[1].Text != "\\[\na+b" {
+		t.Errorf("unterminated formula block = %+v", blocks[1])
+	}
+}
+
+func TestDocumentKeepsPageMetadata(t *testing.T) {
+	result := &ParseResult{
+		Version: "v2",
+		Pages: []ParsePage{
+			{PageIdx: 0, PageWidth: 600, PageHeight: 800, URL: "https://cdn.example/0.png", Md: "# A\n\none"},
+			{PageIdx: 1, Md: "two"},
+		},
+	}
+
+	doc := result.Document()
+	if doc.Version != "v2" || len(doc.Pages) != 2 {
+		t.Fatalf("document = %+v", doc)
+	}
+	if p := doc.Pages[0]; p.Index != 0 || p.Width != 600 || p.Height != 800 || p.URL != "https://cdn.example/0.png" {
+		t.Errorf("page 0 = %+v", p)
+	}
+
+	blocks := doc.Blocks()
+	if len(blocks) != 3 || blocks[2].Text != "two" || blocks[2].Page != 1 {
+		t.Errorf("blocks = %+v", blocks)
+	}
+}
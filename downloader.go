@@ -3,11 +3,89 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"net/http"
+	"strconv"
 	"strings"
 )
 
+// DownloadOption customizes a single DownloadFileTo call.
+type DownloadOption func(*downloadConfig)
+
+type downloadConfig struct {
+	offset       int64
+	partial      io.Reader
+	ifRange      string
+	validator    func(string)
+	etagChecksum bool
+	progress     TransferProgressFunc
+}
+
+// WithResumeOffset resumes a partial download: the server is asked for the bytes from offset onwards
+// with an HTTP Range request and only those bytes are written to dst. Servers that ignore the range
+// are handled by skipping the first offset bytes of the full response. Only the received range can be
+// verified; use WithResumeFrom to verify the whole file.
+func WithResumeOffset(offset int64) DownloadOption {
+	return func(cfg *downloadConfig) {
+		if offset > 0 {
+			cfg.offset = offset
+		}
+	}
+}
+
+// WithResumeFrom resumes a partial download like WithResumeOffset, at the end of partial, which holds
+// the bytes already downloaded, such as the part file opened for reading and appending. Reading it
+// lets a checksum of the whole file (see WithETagChecksum) be verified; with WithResumeOffset alone a
+// resumed download is only checked against a Content-MD5 of the requested range. It overrides
+// WithResumeOffset.
+func WithResumeFrom(partial io.Reader) DownloadOption {
+	return func(cfg *downloadConfig) {
+		cfg.partial = partial
+	}
+}
+
+// WithETagChecksum also verifies the download against an ETag that is a plain MD5 hex digest. OSS sends
+// such ETags for objects uploaded in one piece, but an ETag is only guaranteed to identify a version of
+// the file, so this is off by default.
+func WithETagChecksum() DownloadOption {
+	return func(cfg *downloadConfig) {
+		cfg.etagChecksum = true
+	}
+}
+
+// WithIfRange makes a resumed download conditional on the file being unchanged: validator, the ETag or
+// Last-Modified date of the response the partial file came from (see WithValidatorFunc), is sent as
+// If-Range. When the server answers with a different file, DownloadFileTo fails with ErrResumeMismatch
+// before writing anything, and the partial file must be discarded.
+func WithIfRange(validator string) DownloadOption {
+	return func(cfg *downloadConfig) {
+		cfg.ifRange = validator
+	}
+}
+
+// WithValidatorFunc reports the strong ETag, or else the Last-Modified date, of the response before its
+// body is written, so an interrupted download can later be resumed with WithIfRange. It is not called
+// when the response carries neither.
+func WithValidatorFunc(fn func(validator string)) DownloadOption {
+	return func(cfg *downloadConfig) {
+		cfg.validator = fn
+	}
+}
+
+// WithDownloadProgress reports the progress of a single download, overriding any callback set with
+// WithTransferProgress or ContextWithTransferProgress.
+func WithDownloadProgress(fn TransferProgressFunc) DownloadOption {
+	return func(cfg *downloadConfig) {
+		cfg.progress = fn
+	}
+}
+
 // DownloadFile downloads a file from the given URL.
 func (c *client) DownloadFile(ctx context.Context, url string) ([]byte, error) {
 	if url == "" {
@@ -27,7 +105,10 @@ func (c *client) DownloadFile(ctx context.Context, url string) ([]byte, error) {
 }
 
 // DownloadFileTo streams the file into the provided writer, avoiding buffering large payloads.
-func (c *client) DownloadFileTo(ctx context.Context, url string, dst io.Writer) error {
+// A body shorter than its Content-Length fails with ErrIncompleteDownload. The body is checked against
+// a Content-MD5 header and, with WithETagChecksum, the whole file against an MD5 ETag; a difference
+// fails with ErrChecksumMismatch.
+func (c *client) DownloadFileTo(ctx context.Context, url string, dst io.Writer, opts ...DownloadOption) error {
 	if url == "" {
 		return ErrEmptyDownloadURL
 	}
@@ -36,37 +117,220 @@ func (c *client) DownloadFileTo(ctx context.Context, url string, dst io.Writer)
 		return ErrNilWriter
 	}
 
+	var cfg downloadConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	// MD5 of the bytes already downloaded, so the whole file can be verified after resuming.
+	var prefix hash.Hash
+	if cfg.partial != nil {
+		prefix = md5.New()
+		n, err := io.Copy(prefix, cfg.partial)
+		if err != nil {
+			return fmt.Errorf("reading partial download failed: %w", err)
+		}
+		cfg.offset = n
+	}
+
 	url = strings.ReplaceAll(url, "\\u0026", "&")
 
 	transfer := c.transferClient()
 
-	resp, err := transfer.R().
+	req := transfer.R().
 		SetContext(ctx).
-		SetDoNotParseResponse(true).
-		Get(url)
+		SetDoNotParseResponse(true)
+	if cfg.offset > 0 {
+		req.SetHeader("Range", fmt.Sprintf("bytes=%d-", cfg.offset))
+		if cfg.ifRange != "" {
+			req.SetHeader("If-Range", cfg.ifRange)
+		}
+	}
+
+	resp, err := req.Get(url)
 
 	if err != nil {
 		return fmt.Errorf("download file from %s failed: %w", url, err)
 	}
 
+	body := resp.RawBody()
+	if body != nil {
+		defer body.Close()
+	}
+
+	if cfg.offset > 0 && resp.StatusCode() == http.StatusRequestedRangeNotSatisfiable {
+		if total, ok := contentRangeTotal(resp.Header().Get("Content-Range")); ok {
+			if total == cfg.offset {
+				// Already complete; the ETag, when sent, still covers the whole file.
+				if want := etagMD5(resp.Header()); cfg.etagChecksum && prefix != nil && want != nil {
+					return checksum{want: want, digest: prefix}.verify()
+				}
+				return nil
+			}
+			return fmt.Errorf("%w: file is %d bytes, resuming at byte %d", ErrResumeMismatch, total, cfg.offset)
+		}
+	}
+
 	if !resp.IsSuccess() {
-		return fmt.Errorf("download file failed with status %d: %s", resp.StatusCode(), resp.Status())
+		return errStatus(OperationDownloadFile, resp.StatusCode(), resp.Status(), resp.Header())
 	}
 
-	body := resp.RawBody()
 	if body == nil {
 		return fmt.Errorf("downloaded file is empty")
 	}
-	defer body.Close()
 
-	written, copyErr := io.Copy(dst, body)
+	validator := responseValidator(resp.Header())
+	if cfg.offset > 0 {
+		switch resp.StatusCode() {
+		case http.StatusPartialContent:
+			if start, ok := contentRangeStart(resp.Header().Get("Content-Range")); ok && start != cfg.offset {
+				return fmt.Errorf("%w: download resumed at byte %d, expected %d", ErrResumeMismatch, start, cfg.offset)
+			}
+		default:
+			// A full response to If-Range means the file changed, unless the server ignores ranges
+			// altogether and still reports the same validator.
+			if cfg.ifRange != "" && validator != cfg.ifRange {
+				return fmt.Errorf("%w: file changed since the partial download", ErrResumeMismatch)
+			}
+			if _, err := io.CopyN(io.Discard, body, cfg.offset); err != nil {
+				return fmt.Errorf("skipping already downloaded bytes failed: %w", err)
+			}
+		}
+	}
+	if cfg.validator != nil && validator != "" {
+		cfg.validator(validator)
+	}
+
+	expected := resp.RawResponse.ContentLength
+	if expected >= 0 && cfg.offset > 0 && resp.StatusCode() != http.StatusPartialContent {
+		expected -= cfg.offset
+	}
+
+	var reader io.Reader = body
+	fn := cfg.progress
+	if fn == nil {
+		fn = transferProgressFrom(ctx, c.transferProgress)
+	}
+	if fn != nil {
+		progress := newProgressReader(body, fn, OperationDownloadFile, expected)
+		defer progress.finish()
+		reader = progress
+	}
+
+	// Content-MD5 covers the body of this response, which a server ignoring the range sends whole,
+	// skipped bytes included. An MD5 ETag covers the whole file, so it needs the partial file's digest
+	// when resuming.
+	var checks []checksum
+	partialContent := resp.StatusCode() == http.StatusPartialContent
+	if want := contentMD5(resp.Header()); want != nil && (cfg.offset == 0 || partialContent) {
+		checks = append(checks, checksum{want: want, digest: md5.New()})
+	}
+	if want := etagMD5(resp.Header()); cfg.etagChecksum && want != nil {
+		switch {
+		case cfg.offset == 0:
+			checks = append(checks, checksum{want: want, digest: md5.New()})
+		case prefix != nil:
+			checks = append(checks, checksum{want: want, digest: prefix})
+		}
+	}
+	if len(checks) > 0 {
+		writers := []io.Writer{dst}
+		for _, check := range checks {
+			writers = append(writers, check.digest)
+		}
+		dst = io.MultiWriter(writers...)
+	}
+
+	written, copyErr := io.Copy(dst, reader)
+	if errors.Is(copyErr, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: body ended after %d of %d bytes", ErrIncompleteDownload, written, expected)
+	}
 	if copyErr != nil {
 		return fmt.Errorf("writing downloaded file failed: %w", copyErr)
 	}
 
-	if written == 0 {
+	if expected >= 0 && written < expected {
+		return fmt.Errorf("%w: got %d of %d bytes", ErrIncompleteDownload, written, expected)
+	}
+
+	if written == 0 && cfg.offset == 0 {
 		return fmt.Errorf("downloaded file is empty")
 	}
 
+	for _, check := range checks {
+		if err := check.verify(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// checksum is an MD5 digest announced by the server and the digest of the bytes it covers.
+type checksum struct {
+	want   []byte
+	digest hash.Hash
+}
+
+func (c checksum) verify() error {
+	if got := c.digest.Sum(nil); !bytes.Equal(got, c.want) {
+		return fmt.Errorf("%w: md5 %x, expected %x", ErrChecksumMismatch, got, c.want)
+	}
+	return nil
+}
+
+// contentMD5 returns the MD5 digest announced by a Content-MD5 header.
+func contentMD5(header http.Header) []byte {
+	if value := header.Get("Content-MD5"); value != "" {
+		if sum, err := base64.StdEncoding.DecodeString(value); err == nil && len(sum) == md5.Size {
+			return sum
+		}
+	}
+	return nil
+}
+
+// etagMD5 returns the digest of an ETag that is a plain MD5 hex digest. Weak and multipart ETags are
+// not digests of the body and are ignored.
+func etagMD5(header http.Header) []byte {
+	etag := header.Get("ETag")
+	if strings.HasPrefix(etag, "W/") {
+		return nil
+	}
+	if sum, err := hex.DecodeString(strings.Trim(etag, `"`)); err == nil && len(sum) == md5.Size {
+		return sum
+	}
+	return nil
+}
+
+// responseValidator returns the strong ETag of a response, or else its Last-Modified date, for use
+// in If-Range. Weak ETags are not allowed there.
+func responseValidator(header http.Header) string {
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return header.Get("Last-Modified")
+}
+
+// contentRangeStart parses the first byte position of a "bytes start-end/total" header.
+func contentRangeStart(value string) (int64, bool) {
+	spec, ok := strings.CutPrefix(value, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(start), 10, 64)
+	return n, err == nil
+}
+
+// contentRangeTotal parses the complete length of a "bytes start-end/total" or "bytes */total" header.
+func contentRangeTotal(value string) (int64, bool) {
+	_, total, ok := strings.Cut(value, "/")
+	if !ok || total == "*" {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(total), 10, 64)
+	return n, err == nil
+}
@@ -0,0 +1,265 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+var downloadContent = []byte(strings.Repeat("0123456789abcdef", 64))
+
+func md5ETag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// downloadServer serves a file through handler and records the headers of the last request.
+type downloadServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	header http.Header
+}
+
+func newDownloadServer(t *testing.T, handler http.HandlerFunc) *downloadServer {
+	t.Helper()
+	s := &downloadServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.header = r.Header.Clone()
+		s.mu.Unlock()
+		handler(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *downloadServer) lastHeader() http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.header
+}
+
+// serveFile answers like a static file server, honouring Range and If-Range against etag.
+func serveFile(data []byte, etag string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "file.zip", time.Time{}, bytes.NewReader(data))
+	}
+}
+
+// serveWhole answers every request with the whole file, ignoring Range.
+func serveWhole(data []byte, header http.Header) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Write(data)
+	}
+}
+
+func TestDownloadFileTo(t *testing.T) {
+	etag := md5ETag(downloadContent)
+	sum := md5.Sum(downloadContent)
+	srv := newDownloadServer(t, serveWhole(downloadContent, http.Header{
+		"ETag":        {etag},
+		"Content-MD5": {base64.StdEncoding.EncodeToString(sum[:])},
+	}))
+	c := client.NewClient("sk-test")
+
+	var (
+		buf       bytes.Buffer
+		validator string
+		events    []client.TransferProgress
+	)
+	err := c.DownloadFileTo(context.Background(), srv.URL, &buf,
+		client.WithETagChecksum(),
+		client.WithValidatorFunc(func(v string) { validator = v }),
+		client.WithDownloadProgress(func(p client.TransferProgress) { events = append(events, p) }),
+	)
+	if err != nil {
+		t.Fatalf("DownloadFileTo: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), downloadContent) {
+		t.Errorf("downloaded %d bytes, want the %d byte file", buf.Len(), len(downloadContent))
+	}
+	if validator != etag {
+		t.Errorf("validator = %q, want %q", validator, etag)
+	}
+	if len(events) == 0 {
+		t.Fatal("no progress reported")
+	}
+	if last := events[len(events)-1]; !last.Done || last.Bytes != int64(len(downloadContent)) || last.Total != int64(len(downloadContent)) {
+		t.Errorf("final progress = %+v", last)
+	}
+	if h := srv.lastHeader(); h.Get("Range") != "" {
+		t.Errorf("fresh download sent Range %q", h.Get("Range"))
+	}
+}
+
+func TestDownloadFileToResume(t *testing.T) {
+	const offset = 100
+	etag := md5ETag(downloadContent)
+	c := client.NewClient("sk-test")
+	ctx := context.Background()
+
+	t.Run("partial content", func(t *testing.T) {
+		srv := newDownloadServer(t, serveFile(downloadContent, etag))
+
+		var buf bytes.Buffer
+		err := c.DownloadFileTo(ctx, srv.URL, &buf,
+			client.WithResumeFrom(bytes.NewReader(downloadContent[:offset])),
+			client.WithIfRange(etag),
+			client.WithETagChecksum(),
+		)
+		if err != nil {
+			t.Fatalf("DownloadFileTo: %v", err)
+		}
+		if !bytes.Equal(buf.Bytes(), downloadContent[offset:]) {
+			t.Errorf("resumed download wrote %d bytes, want the %d bytes after the offset", buf.Len(), len(downloadContent)-offset)
+		}
+		h := srv.lastHeader()
+		if h.Get("Range") != "bytes=100-" || h.Get("If-Range") != etag {
+			t.Errorf("request sent Range %q and If-Range %q", h.Get("Range"), h.Get("If-Range"))
+		}
+	})
+
+	t.Run("range ignored", func(t *testing.T) {
+		srv := newDownloadServer(t, serveWhole(downloadContent, http.Header{"ETag": {etag}}))
+
+		var buf bytes.Buffer
+		err := c.DownloadFileTo(ctx, srv.URL, &buf, client.WithResumeOffset(offset), client.WithIfRange(etag))
+		if err != nil {
+			t.Fatalf("DownloadFileTo: %v", err)
+		}
+		if !bytes.Equal(buf.Bytes(), downloadContent[offset:]) {
+			t.Errorf("wrote %d bytes, want the already downloaded bytes skipped", buf.Len())
+		}
+	})
+
+	t.Run("validator changed", func(t *testing.T) {
+		srv := newDownloadServer(t, serveFile(downloadContent, `"v2"`))
+
+		var buf bytes.Buffer
+		err := c.DownloadFileTo(ctx, srv.URL, &buf, client.WithResumeOffset(offset), client.WithIfRange(`"v1"`))
+		if !errors.Is(err, client.ErrResumeMismatch) {
+			t.Fatalf("DownloadFileTo error = %v, want ErrResumeMismatch", err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("wrote %d bytes of a changed file", buf.Len())
+		}
+	})
+
+	t.Run("already complete", func(t *testing.T) {
+		srv := newDownloadServer(t, serveFile(downloadContent, etag))
+
+		var buf bytes.Buffer
+		err := c.DownloadFileTo(ctx, srv.URL, &buf, client.WithResumeFrom(bytes.NewReader(downloadContent)), client.WithETagChecksum())
+		if err != nil {
+			t.Fatalf("DownloadFileTo of a complete file: %v", err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("wrote %d bytes past the end of the file", buf.Len())
+		}
+	})
+
+	t.Run("offset past the end", func(t *testing.T) {
+		srv := newDownloadServer(t, serveFile(downloadContent, etag))
+
+		err := c.DownloadFileTo(ctx, srv.URL, io.Discard, client.WithResumeOffset(int64(len(downloadContent))+10))
+		if !errors.Is(err, client.ErrResumeMismatch) {
+			t.Fatalf("DownloadFileTo error = %v, want ErrResumeMismatch", err)
+		}
+	})
+
+	t.Run("wrong range start", func(t *testing.T) {
+		srv := newDownloadServer(t, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Range", "bytes 0-1023/1024")
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(downloadContent)
+		})
+
+		err := c.DownloadFileTo(ctx, srv.URL, io.Discard, client.WithResumeOffset(offset))
+		if !errors.Is(err, client.ErrResumeMismatch) {
+			t.Fatalf("DownloadFileTo error = %v, want ErrResumeMismatch", err)
+		}
+	})
+}
+
+func TestDownloadFileToIncomplete(t *testing.T) {
+	srv := newDownloadServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(downloadContent)))
+		w.Write(downloadContent[:100])
+	})
+
+	err := client.NewClient("sk-test").DownloadFileTo(context.Background(), srv.URL, io.Discard)
+	if !errors.Is(err, client.ErrIncompleteDownload) {
+		t.Fatalf("DownloadFileTo error = %v, want ErrIncompleteDownload", err)
+	}
+}
+
+func TestDownloadFileToChecksums(t *testing.T) {
+	other := md5.Sum([]byte("another file"))
+	c := client.NewClient("sk-test")
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		header http.Header
+		opts   []client.DownloadOption
+		want   error
+	}{
+		{
+			name:   "content-md5 mismatch",
+			header: http.Header{"Content-MD5": {base64.StdEncoding.EncodeToString(other[:])}},
+			want:   client.ErrChecksumMismatch,
+		},
+		{
+			name:   "etag mismatch",
+			header: http.Header{"ETag": {`"` + hex.EncodeToString(other[:]) + `"`}},
+			opts:   []client.DownloadOption{client.WithETagChecksum()},
+			want:   client.ErrChecksumMismatch,
+		},
+		{
+			name:   "etag not checked by default",
+			header: http.Header{"ETag": {`"` + hex.EncodeToString(other[:]) + `"`}},
+		},
+		{
+			name:   "weak etag ignored",
+			header: http.Header{"ETag": {`W/"` + hex.EncodeToString(other[:]) + `"`}},
+			opts:   []client.DownloadOption{client.WithETagChecksum()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newDownloadServer(t, serveWhole(downloadContent, tt.header))
+			err := c.DownloadFileTo(ctx, srv.URL, io.Discard, tt.opts...)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("DownloadFileTo error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// A corrupt partial file is caught by the ETag of the whole file even though the range is intact.
+	etag := md5ETag(downloadContent)
+	srv := newDownloadServer(t, serveFile(downloadContent, etag))
+	corrupt := bytes.Repeat([]byte("x"), 100)
+	err := c.DownloadFileTo(ctx, srv.URL, io.Discard, client.WithResumeFrom(bytes.NewReader(corrupt)), client.WithETagChecksum())
+	if !errors.Is(err, client.ErrChecksumMismatch) {
+		t.Fatalf("resume onto a corrupt part error = %v, want ErrChecksumMismatch", err)
+	}
+}
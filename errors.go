@@ -3,34 +3,137 @@ package client
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 )
 
 var (
-	ErrEmptyPDFData      = errors.New("pdf data cannot be empty")
-	ErrEmptyImageData    = errors.New("image data cannot be empty")
-	ErrEmptyUID          = errors.New("uid cannot be empty")
-	ErrEmptyFileData     = errors.New("file data cannot be empty")
-	ErrEmptyPresignedURL = errors.New("presigned url cannot be empty")
-	ErrEmptyTargetFormat = errors.New("target format cannot be empty")
-	ErrEmptyDownloadURL  = errors.New("download url cannot be empty")
-	ErrEmptyConvertZIP   = errors.New("convert_zip cannot be empty")
-	ErrNilReader         = errors.New("reader cannot be nil")
-	ErrNilWriter         = errors.New("writer cannot be nil")
+	ErrEmptyPDFData       = errors.New("pdf data cannot be empty")
+	ErrEmptyImageData     = errors.New("image data cannot be empty")
+	ErrEmptyUID           = errors.New("uid cannot be empty")
+	ErrEmptyFileData      = errors.New("file data cannot be empty")
+	ErrEmptyPresignedURL  = errors.New("presigned url cannot be empty")
+	ErrEmptyTargetFormat  = errors.New("target format cannot be empty")
+	ErrEmptyDownloadURL   = errors.New("download url cannot be empty")
+	ErrEmptyConvertZIP    = errors.New("convert_zip cannot be empty")
+	ErrNilReader          = errors.New("reader cannot be nil")
+	ErrNilWriter          = errors.New("writer cannot be nil")
+	ErrUnsafeZIPPath      = errors.New("zip entry escapes extract dir")
+	ErrNotPDF             = errors.New("data is not a PDF")
+	ErrFileTooLarge       = errors.New("file exceeds the upload size limit")
+	ErrIncompleteDownload = errors.New("download ended before the announced length")
+	ErrChecksumMismatch   = errors.New("downloaded file does not match its checksum")
+	ErrResumeMismatch     = errors.New("partial download does not match the remote file")
 )
 
-// errCode formats a failure message when the API reports a non-success code.
-func errCode(operation Operation, code, msg, traceID string) error {
-	traceID = normalizeTraceID(traceID)
-	if msg == "" {
-		return fmt.Errorf("%s failed with code %s (trace-id: %s)", operation, code, traceID)
+// FileTooLargeError reports a PDF rejected by Upload before any bytes were sent.
+// It matches ErrFileTooLarge with errors.Is.
+type FileTooLargeError struct {
+	Size  int64 // Declared file size in bytes
+	Limit int64 // Largest size the API accepts
+}
+
+func (e *FileTooLargeError) Error() string {
+	return fmt.Sprintf("file is %d bytes, exceeding the %d byte upload limit", e.Size, e.Limit)
+}
+
+func (e *FileTooLargeError) Unwrap() error {
+	return ErrFileTooLarge
+}
+
+// Classifications for API failures. Match them with errors.Is against errors returned by the client.
+var (
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrRateLimited   = errors.New("rate limited")
+	ErrTaskExpired   = errors.New("task expired or not found")
+	ErrParseFailed   = errors.New("parse failed")
+)
+
+// Doc2X error codes with a dedicated classification.
+const (
+	CodeQuotaLimit        = "parse_quota_limit"
+	CodeConcurrencyLimit  = "parse_concurrency_limit"
+	CodeTaskLimitExceeded = "parse_task_limit_exceeded"
+	CodeStatusNotFound    = "parse_status_not_found"
+	CodeUnauthorized      = "unauthorized"
+)
+
+// APIError describes a failed Doc2X API call, either an HTTP error status or a non-success response code.
+type APIError struct {
+	Operation  Operation
+	StatusCode int    // HTTP status code of the response
+	Status     string // HTTP status text, set when StatusCode is not 2xx
+	Code       string // Doc2X response code, e.g. "parse_quota_limit"
+	Msg        string // Doc2X response message
+	TraceID    string
+	RetryAfter time.Duration // Server-provided Retry-After hint for 429/503 responses
+}
+
+// Error formats the failure including the trace id for support requests.
+func (e *APIError) Error() string {
+	traceID := normalizeTraceID(e.TraceID)
+	if e.Code == "" {
+		return fmt.Sprintf("%s failed with status %d: %s (trace-id: %s)", e.Operation, e.StatusCode, e.Status, traceID)
 	}
-	return fmt.Errorf("%s failed with code %s: %s (trace-id: %s)", operation, code, msg, traceID)
+	if e.Msg == "" {
+		return fmt.Sprintf("%s failed with code %s (trace-id: %s)", e.Operation, e.Code, traceID)
+	}
+	return fmt.Sprintf("%s failed with code %s: %s (trace-id: %s)", e.Operation, e.Code, e.Msg, traceID)
 }
 
-// errStatus formats an error with HTTP status and trace id.
-func errStatus(operation Operation, statusCode int, status, traceID string) error {
-	traceID = normalizeTraceID(traceID)
-	return fmt.Errorf("%s failed with status %d: %s (trace-id: %s)", operation, statusCode, status, traceID)
+// Unwrap exposes the classification sentinel so errors.Is works, or nil when unclassified.
+func (e *APIError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	}
+
+	switch e.Code {
+	case "":
+		return nil
+	case CodeQuotaLimit:
+		return ErrQuotaExceeded
+	case CodeConcurrencyLimit, CodeTaskLimitExceeded:
+		return ErrRateLimited
+	case CodeStatusNotFound:
+		return ErrTaskExpired
+	case CodeUnauthorized:
+		return ErrUnauthorized
+	}
+
+	if strings.HasPrefix(e.Code, "parse_") {
+		return ErrParseFailed
+	}
+	return nil
+}
+
+// errCode builds an APIError when the API reports a non-success code.
+func errCode(operation Operation, statusCode int, code, msg, traceID string) error {
+	return &APIError{
+		Operation:  operation,
+		StatusCode: statusCode,
+		Code:       code,
+		Msg:        msg,
+		TraceID:    traceID,
+	}
+}
+
+// errStatus builds an APIError for a non-2xx HTTP status, keeping any Retry-After hint.
+func errStatus(operation Operation, statusCode int, status string, header http.Header) error {
+	apiErr := &APIError{
+		Operation:  operation,
+		StatusCode: statusCode,
+		Status:     status,
+		TraceID:    header.Get(TraceIDHeader),
+	}
+	if statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable {
+		apiErr.RetryAfter = parseRetryAfter(header)
+	}
+	return apiErr
 }
 
 func normalizeTraceID(traceID string) string {
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAPIErrorUnwrap(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *APIError
+		want error
+	}{
+		{"401", &APIError{StatusCode: http.StatusUnauthorized}, ErrUnauthorized},
+		{"403", &APIError{StatusCode: http.StatusForbidden}, ErrUnauthorized},
+		{"429", &APIError{StatusCode: http.StatusTooManyRequests}, ErrRateLimited},
+		{"status wins over code", &APIError{StatusCode: http.StatusTooManyRequests, Code: CodeQuotaLimit}, ErrRateLimited},
+		{"quota", &APIError{StatusCode: http.StatusOK, Code: CodeQuotaLimit}, ErrQuotaExceeded},
+		{"concurrency limit", &APIError{StatusCode: http.StatusOK, Code: CodeConcurrencyLimit}, ErrRateLimited},
+		{"task limit", &APIError{StatusCode: http.StatusOK, Code: CodeTaskLimitExceeded}, ErrRateLimited},
+		{"status not found", &APIError{StatusCode: http.StatusOK, Code: CodeStatusNotFound}, ErrTaskExpired},
+		{"unauthorized code", &APIError{StatusCode: http.StatusOK, Code: CodeUnauthorized}, ErrUnauthorized},
+		{"other parse code", &APIError{StatusCode: http.StatusOK, Code: "parse_error"}, ErrParseFailed},
+		{"parse code of a 500", &APIError{StatusCode: http.StatusInternalServerError, Code: "parse_file_invalid"}, ErrParseFailed},
+		{"unclassified code", &APIError{StatusCode: http.StatusOK, Code: "failed"}, nil},
+		{"unclassified status", &APIError{StatusCode: http.StatusInternalServerError}, nil},
+		{"503", &APIError{StatusCode: http.StatusServiceUnavailable}, nil},
+	}
+	sentinels := []error{ErrUnauthorized, ErrRateLimited, ErrQuotaExceeded, ErrTaskExpired, ErrParseFailed}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Unwrap(); got != tt.want {
+				t.Errorf("Unwrap = %v, want %v", got, tt.want)
+			}
+			wrapped := fmt.Errorf("stage: %w", tt.err)
+			for _, sentinel := range sentinels {
+				if is := errors.Is(wrapped, sentinel); is != (sentinel == tt.want) {
+					t.Errorf("errors.Is(%v) = %v", sentinel, is)
+				}
+			}
+			var apiErr *APIError
+			if !errors.As(wrapped, &apiErr) || apiErr != tt.err {
+				t.Error("errors.As did not find the APIError")
+			}
+		})
+	}
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  *APIError
+		want string
+	}{
+		{&APIError{Operation: OperationGetStatus, StatusCode: 502, Status: "502 Bad Gateway"}, "get status failed with status 502: 502 Bad Gateway (trace-id: unknown)"},
+		{&APIError{Operation: OperationUploadPDF, Code: CodeQuotaLimit, TraceID: "t-1"}, "upload PDF failed with code parse_quota_limit (trace-id: t-1)"},
+		{&APIError{Operation: OperationUploadPDF, Code: CodeQuotaLimit, Msg: "no pages left", TraceID: "t-1"}, "upload PDF failed with code parse_quota_limit: no pages left (trace-id: t-1)"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestErrStatusRetryAfter(t *testing.T) {
+	header := http.Header{"Retry-After": {"3"}}
+	header.Set(TraceIDHeader, "t-9")
+	tests := []struct {
+		status int
+		want   time.Duration
+		is     error
+	}{
+		{http.StatusTooManyRequests, 3 * time.Second, ErrRateLimited},
+		{http.StatusServiceUnavailable, 3 * time.Second, nil},
+		{http.StatusInternalServerError, 0, nil},
+		{http.StatusUnauthorized, 0, ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		err := errStatus(OperationGetStatus, tt.status, http.StatusText(tt.status), header)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("%d: %T is not an APIError", tt.status, err)
+		}
+		if apiErr.RetryAfter != tt.want || apiErr.TraceID != "t-9" || apiErr.StatusCode != tt.status {
+			t.Errorf("%d: APIError = %+v, want Retry-After %s", tt.status, apiErr, tt.want)
+		}
+		if got := apiErr.Unwrap(); got != tt.is {
+			t.Errorf("%d: Unwrap = %v, want %v", tt.status, got, tt.is)
+		}
+	}
+}
+
+func TestFileTooLargeError(t *testing.T) {
+	err := fmt.Errorf("upload: %w", &FileTooLargeError{Size: MaxPresignedUploadSize + 1, Limit: MaxPresignedUploadSize})
+	if !errors.Is(err, ErrFileTooLarge) {
+		t.Error("FileTooLargeError does not match ErrFileTooLarge")
+	}
+	var tooLarge *FileTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Size != MaxPresignedUploadSize+1 || tooLarge.Limit != MaxPresignedUploadSize {
+		t.Errorf("errors.As = %+v", tooLarge)
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		t.Error("FileTooLargeError matched APIError")
+	}
+}
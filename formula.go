@@ -0,0 +1,269 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formula is a LaTeX formula found in page Markdown.
+type Formula struct {
+	Latex   string      `json:"latex"`   // Formula body without delimiters
+	Raw     string      `json:"raw"`     // Source text including delimiters
+	Display bool        `json:"display"` // Display (block) formula rather than inline
+	Style   FormulaMode `json:"style"`   // Delimiter style of Raw
+	Offset  int         `json:"offset"`  // Byte offset of Raw within the scanned text
+}
+
+// PageFormula is a formula located within a parse result.
+type PageFormula struct {
+	Formula
+	Page int `json:"page"` // Page index
+	Line int `json:"line"` // 1-based line of the page Markdown where the formula starts
+}
+
+// Delimiters used by each formula mode. Normal and dollar follow the Doc2X output styles;
+// latex keeps inline formulas in \( \) and wraps display formulas in an unnumbered equation environment.
+var formulaDelimiters = map[FormulaMode]struct{ inlineOpen, inlineClose, displayOpen, displayClose string }{
+	FormulaModeNormal: {`\(`, `\)`, `\[`, `\]`},
+	FormulaModeDollar: {"$", "$", "$$", "$$"},
+	FormulaModeLatex:  {`\(`, `\)`, `\begin{equation*}`, `\end{equation*}`},
+}
+
+// ExtractFormulas returns every inline and display formula of a parse result in reading order.
+// Offsets are relative to the Markdown of the formula's page.
+func ExtractFormulas(result *ParseResult) []PageFormula {
+	if result == nil {
+		return nil
+	}
+
+	var formulas []PageFormula
+	for _, page := range result.Pages {
+		for _, f := range findFormulas(page.Md) {
+			formulas = append(formulas, PageFormula{
+				Formula: f,
+				Page:    page.PageIdx,
+				Line:    strings.Count(page.Md[:f.Offset], "\n") + 1,
+			})
+		}
+	}
+	return formulas
+}
+
+// NormalizeFormulas rewrites the delimiters of every formula in md to the given mode,
+// leaving formula bodies and the surrounding Markdown untouched. Formulas already in
+// that style, such as numbered equation environments under FormulaModeLatex, are kept verbatim.
+func NormalizeFormulas(md string, mode FormulaMode) (string, error) {
+	if _, ok := formulaDelimiters[mode]; !ok {
+		return "", fmt.Errorf("unsupported formula mode: %s", mode)
+	}
+
+	var (
+		sb   strings.Builder
+		last int
+	)
+	for _, f := range findFormulas(md) {
+		sb.WriteString(md[last:f.Offset])
+		if f.Style == mode {
+			sb.WriteString(f.Raw)
+		} else {
+			sb.WriteString(f.Render(mode))
+		}
+		last = f.Offset + len(f.Raw)
+	}
+	sb.WriteString(md[last:])
+	return sb.String(), nil
+}
+
+// NormalizeFormulas returns a copy of the result whose page Markdown uses the given formula mode.
+func (r *ParseResult) NormalizeFormulas(mode FormulaMode) (*ParseResult, error) {
+	out := &ParseResult{Version: r.Version, Pages: make([]ParsePage, len(r.Pages))}
+	for i, page := range r.Pages {
+		md, err := NormalizeFormulas(page.Md, mode)
+		if err != nil {
+			return nil, err
+		}
+		page.Md = md
+		out.Pages[i] = page
+	}
+	return out, nil
+}
+
+// Render formats the formula with the delimiters of mode, keeping the line layout of display formulas.
+// Unknown modes fall back to FormulaModeNormal.
+func (f Formula) Render(mode FormulaMode) string {
+	delims, ok := formulaDelimiters[mode]
+	if !ok {
+		delims = formulaDelimiters[FormulaModeNormal]
+	}
+
+	if !f.Display {
+		return delims.inlineOpen + f.Latex + delims.inlineClose
+	}
+	if strings.Contains(f.Raw, "\n") {
+		return delims.displayOpen + "\n" + f.Latex + "\n" + delims.displayClose
+	}
+	return delims.displayOpen + f.Latex + delims.displayClose
+}
+
+// WriteFormulasJSON writes formulas as an indented JSON array.
+func WriteFormulasJSON(w io.Writer, formulas []PageFormula) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if formulas == nil {
+		formulas = []PageFormula{}
+	}
+	if err := enc.Encode(formulas); err != nil {
+		return fmt.Errorf("write formulas json: %w", err)
+	}
+	return nil
+}
+
+// WriteFormulasLatex writes formulas as a compilable LaTeX document, one formula per paragraph
+// annotated with its page and line.
+func WriteFormulasLatex(w io.Writer, formulas []PageFormula) error {
+	var sb strings.Builder
+	sb.WriteString("\\documentclass{article}\n\\usepackage{amsmath}\n\\begin{document}\n")
+	for _, f := range formulas {
+		fmt.Fprintf(&sb, "\n%% page %d, line %d\n%s\n", f.Page+1, f.Line, f.Render(FormulaModeLatex))
+	}
+	sb.WriteString("\n\\end{document}\n")
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("write formulas latex: %w", err)
+	}
+	return nil
+}
+
+// findFormulas scans Markdown for formulas written with \( \), $ $, \[ \], $$ $$ or an
+// equation environment. Code spans and escaped dollars are skipped.
+func findFormulas(text string) []Formula {
+	var formulas []Formula
+
+	for i := 0; i < len(text); {
+		switch {
+		case text[i] == '`':
+			end := strings.IndexByte(text[i+1:], '`')
+			if end == -1 {
+				return formulas
+			}
+			i += end + 2
+		case text[i] == '\\' && i+1 < len(text):
+			if f, n, ok := scanBackslashFormula(text, i); ok {
+				formulas = append(formulas, f)
+				i += n
+				continue
+			}
+			i += 2
+		case text[i] == '$':
+			if f, n, ok := scanDollarFormula(text, i); ok {
+				formulas = append(formulas, f)
+				i += n
+				continue
+			}
+			i++
+		default:
+			i++
+		}
+	}
+
+	return formulas
+}
+
+// scanBackslashFormula matches \( \), \[ \] and \begin{equation} forms starting at text[i].
+func scanBackslashFormula(text string, i int) (Formula, int, bool) {
+	rest := text[i:]
+
+	var open, closing string
+	display := true
+	switch {
+	case strings.HasPrefix(rest, `\(`):
+		open, closing, display = `\(`, `\)`, false
+	case strings.HasPrefix(rest, `\[`):
+		open, closing = `\[`, `\]`
+	case strings.HasPrefix(rest, `\begin{equation`):
+		end := strings.IndexByte(rest, '}')
+		if end == -1 {
+			return Formula{}, 0, false
+		}
+		env := rest[len(`\begin{`):end]
+		open, closing = rest[:end+1], `\end{`+env+`}`
+	default:
+		return Formula{}, 0, false
+	}
+
+	end := strings.Index(rest[len(open):], closing)
+	if end == -1 {
+		return Formula{}, 0, false
+	}
+
+	style := FormulaModeNormal
+	if strings.HasPrefix(open, `\begin`) {
+		style = FormulaModeLatex
+	}
+
+	n := len(open) + end + len(closing)
+	return Formula{
+		Latex:   strings.TrimSpace(rest[len(open) : len(open)+end]),
+		Raw:     rest[:n],
+		Display: display,
+		Style:   style,
+		Offset:  i,
+	}, n, true
+}
+
+// scanDollarFormula matches $$ $$ and $ $ forms starting at text[i].
+func scanDollarFormula(text string, i int) (Formula, int, bool) {
+	rest := text[i:]
+
+	if strings.HasPrefix(rest, "$$") {
+		end := strings.Index(rest[2:], "$$")
+		if end == -1 {
+			return Formula{}, 0, false
+		}
+		n := end + 4
+		return Formula{
+			Latex:   strings.TrimSpace(rest[2 : 2+end]),
+			Raw:     rest[:n],
+			Display: true,
+			Style:   FormulaModeDollar,
+			Offset:  i,
+		}, n, true
+	}
+
+	// Inline dollars must hug their content and stay within one paragraph, so prices like
+	// "$5 and $6" are not mistaken for formulas.
+	if len(rest) < 3 || isSpace(rest[1]) {
+		return Formula{}, 0, false
+	}
+	for j := 1; j < len(rest); j++ {
+		switch rest[j] {
+		case '\\':
+			j++
+		case '\n':
+			if j+1 < len(rest) && rest[j+1] == '\n' {
+				return Formula{}, 0, false
+			}
+		case '$':
+			if isSpace(rest[j-1]) || (j+1 < len(rest) && isDigit(rest[j+1])) {
+				return Formula{}, 0, false
+			}
+			return Formula{
+				Latex:  rest[1:j],
+				Raw:    rest[:j+1],
+				Style:  FormulaModeDollar,
+				Offset: i,
+			}, j + 1, true
+		}
+	}
+	return Formula{}, 0, false
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFindFormulas(t *testing.T) {
+	text := "Inline \\(a+b\\), $c$ and `$not$` code.\n\n" +
+		"Prices $5 and $6 are not math, nor is \\$x\\$.\n\n" +
+		"\\[\nE=mc^2\n\\]\n\n" +
+		"$$ y $$\n\n" +
+		"\\begin{equation}\nz=1\n\\end{equation}"
+
+	formulas := findFormulas(text)
+
+	want := []struct {
+		latex   string
+		display bool
+		style   FormulaMode
+	}{
+		{"a+b", false, FormulaModeNormal},
+		{"c", false, FormulaModeDollar},
+		{"E=mc^2", true, FormulaModeNormal},
+		{"y", true, FormulaModeDollar},
+		{"z=1", true, FormulaModeLatex},
+	}
+	if len(formulas) != len(want) {
+		t.Fatalf("found %d formulas, want %d: %+v", len(formulas), len(want), formulas)
+	}
+	for i, f := range formulas {
+		if f.Latex != want[i].latex || f.Display != want[i].display || f.Style != want[i].style {
+			t.Errorf("formula %d = %q display=%v style=%s, want %q display=%v style=%s",
+				i, f.Latex, f.Display, f.Style, want[i].latex, want[i].display, want[i].style)
+		}
+		if text[f.Offset:f.Offset+len(f.Raw)] != f.Raw {
+			t.Errorf("formula %d: offset %d does not point at %q", i, f.Offset, f.Raw)
+		}
+	}
+}
+
+func TestNormalizeFormulas(t *testing.T) {
+	md := "See \\(x\\) and $y$.\n\n$$\nz\n$$"
+
+	tests := []struct {
+		mode FormulaMode
+		want string
+	}{
+		{FormulaModeDollar, "See $x$ and $y$.\n\n$$\nz\n$$"},
+		{FormulaModeNormal, "See \\(x\\) and \\(y\\).\n\n\\[\nz\n\\]"},
+		{FormulaModeLatex, "See \\(x\\) and \\(y\\).\n\n\\begin{equation*}\nz\n\\end{equation*}"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeFormulas(md, tt.mode)
+		if err != nil {
+			t.Fatalf("NormalizeFormulas(%s): %v", tt.mode, err)
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeFormulas(%s) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+
+	if _, err := NormalizeFormulas(md, "mathml"); err == nil {
+		t.Error("unsupported mode normalized without error")
+	}
+}
+
+func TestNormalizeFormulasKeepsNumberedEquations(t *testing.T) {
+	md := "\\begin{equation}\na=b\n\\end{equation}"
+	got, err := NormalizeFormulas(md, FormulaModeLatex)
+	if err != nil {
+		t.Fatalf("NormalizeFormulas: %v", err)
+	}
+	if got != md {
+		t.Errorf("numbered equation rewritten to %q", got)
+	}
+}
+
+func TestExtractFormulas(t *testing.T) {
+	result := &ParseResult{Pages: []ParsePage{
+		{PageIdx: 0, Md: "No math here."},
+		{PageIdx: 1, Md: "Line one.\nThen $a$ and\n\n$$b$$"},
+	}}
+
+	formulas := ExtractFormulas(result)
+	if len(formulas) != 2 {
+		t.Fatalf("extracted %d formulas, want 2: %+v", len(formulas), formulas)
+	}
+	if f := formulas[0]; f.Page != 1 || f.Line != 2 || f.Latex != "a" {
+		t.Errorf("first formula = %+v", f)
+	}
+	if f := formulas[1]; f.Page != 1 || f.Line != 4 || !f.Display {
+		t.Errorf("second formula = %+v", f)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteFormulasLatex(&buf, formulas); err != nil {
+		t.Fatalf("WriteFormulasLatex: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "% page 2, line 2\n\\(a\\)") || !strings.Contains(out, "\\begin{equation*}b\\end{equation*}") {
+		t.Errorf("latex output missing formulas:\n%s", out)
+	}
+}
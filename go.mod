@@ -6,10 +6,8 @@ require (
 	github.com/go-resty/resty/v2 v2.16.5
 	github.com/spf13/cobra v1.8.1
 	github.com/spf13/pflag v1.0.5
+	golang.org/x/net v0.44.0
+	golang.org/x/sync v0.19.0
 )
 
-require (
-	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	golang.org/x/net v0.44.0 // indirect
-	golang.org/x/sync v0.19.0 // indirect
-)
+require github.com/inconshreveable/mousetrap v1.1.0 // indirect
@@ -28,11 +28,11 @@ func (c *client) ParseImageLayout(ctx context.Context, imageData []byte) (*Image
 	result.TraceID = traceID
 
 	if !resp.IsSuccess() {
-		return nil, errStatus(OperationParseImageLayout, resp.StatusCode(), resp.Status(), traceID)
+		return nil, errStatus(OperationParseImageLayout, resp.StatusCode(), resp.Status(), resp.Header())
 	}
 
 	if err := ensureAPISuccess(result.Code, result.Msg); err != nil {
-		return nil, errCode(OperationParseImageLayout, result.Code, result.Msg, traceID)
+		return nil, errCode(OperationParseImageLayout, resp.StatusCode(), result.Code, result.Msg, traceID)
 	}
 
 	if result.Data == nil {
@@ -64,11 +64,11 @@ func (c *client) AsyncParseImageLayout(ctx context.Context, imageData []byte) (*
 	result.TraceID = traceID
 
 	if !resp.IsSuccess() {
-		return nil, errStatus(OperationAsyncParseImageLayout, resp.StatusCode(), resp.Status(), traceID)
+		return nil, errStatus(OperationAsyncParseImageLayout, resp.StatusCode(), resp.Status(), resp.Header())
 	}
 
 	if err := ensureAPISuccess(result.Code, result.Msg); err != nil {
-		return nil, errCode(OperationAsyncParseImageLayout, result.Code, result.Msg, traceID)
+		return nil, errCode(OperationAsyncParseImageLayout, resp.StatusCode(), result.Code, result.Msg, traceID)
 	}
 
 	if result.Data == nil {
@@ -99,11 +99,11 @@ func (c *client) GetImageLayoutStatus(ctx context.Context, uid string) (*ImageLa
 	result.TraceID = traceID
 
 	if !resp.IsSuccess() {
-		return nil, errStatus(OperationGetImageLayoutStatus, resp.StatusCode(), resp.Status(), traceID)
+		return nil, errStatus(OperationGetImageLayoutStatus, resp.StatusCode(), resp.Status(), resp.Header())
 	}
 
 	if err := ensureAPISuccess(result.Code, result.Msg); err != nil {
-		return nil, errCode(OperationGetImageLayoutStatus, result.Code, result.Msg, traceID)
+		return nil, errCode(OperationGetImageLayoutStatus, resp.StatusCode(), result.Code, result.Msg, traceID)
 	}
 
 	return &result, nil
@@ -115,7 +115,7 @@ func (c *client) WaitForImageLayout(ctx context.Context, uid string, pollInterva
 		return nil, ErrEmptyUID
 	}
 
-	return waitWithPolling(ctx, uid, pollInterval, OperationImageLayout, c.processingTimeout, c.GetImageLayoutStatus, func(status *ImageLayoutStatusResponse) (bool, error) {
+	return waitWithPolling(ctx, uid, c.pollConfig(OperationImageLayout, pollInterval), c.GetImageLayoutStatus, imageLayoutTaskState, func(status *ImageLayoutStatusResponse) (bool, error) {
 		if status.Data == nil {
 			return false, nil
 		}
@@ -128,7 +128,7 @@ func (c *client) WaitForImageLayout(ctx context.Context, uid string, pollInterva
 			if detail == "" {
 				detail = "unknown error"
 			}
-			return false, fmt.Errorf("image layout failed: %s (trace-id: %s)", detail, status.TraceID)
+			return false, fmt.Errorf("image layout %w: %s (trace-id: %s)", ErrParseFailed, detail, normalizeTraceID(status.TraceID))
 		default:
 			return false, nil
 		}
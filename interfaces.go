@@ -19,6 +19,7 @@ type Parser interface {
 	PreUpload(ctx context.Context) (*PreUploadResponse, error)
 	UploadToPresignedURL(ctx context.Context, url string, fileData []byte) error
 	UploadToPresignedURLFrom(ctx context.Context, url string, r io.Reader) error
+	Upload(ctx context.Context, r io.Reader, size int64) (*UploadResult, error)
 	GetStatus(ctx context.Context, uid string) (*StatusResponse, error)
 	WaitForParsing(ctx context.Context, uid string, pollInterval time.Duration) (*StatusResponse, error)
 }
@@ -28,12 +29,13 @@ type Converter interface {
 	ConvertParse(ctx context.Context, req ConvertRequest) (*ConvertResponse, error)
 	GetConvertResult(ctx context.Context, uid string) (*ConvertResultResponse, error)
 	WaitForConversion(ctx context.Context, uid string, pollInterval time.Duration) (*ConvertResultResponse, error)
+	ConvertAll(ctx context.Context, uid string, reqs []ConvertRequest) ([]ConvertOutput, error)
 }
 
 // Downloader handles file download operations
 type Downloader interface {
 	DownloadFile(ctx context.Context, url string) ([]byte, error)
-	DownloadFileTo(ctx context.Context, url string, dst io.Writer) error
+	DownloadFileTo(ctx context.Context, url string, dst io.Writer, opts ...DownloadOption) error
 }
 
 // ImageParser handles image parsing operations
@@ -43,7 +45,8 @@ type ImageParser interface {
 	GetImageLayoutStatus(ctx context.Context, uid string) (*ImageLayoutStatusResponse, error)
 	WaitForImageLayout(ctx context.Context, uid string, pollInterval time.Duration) (*ImageLayoutStatusResponse, error)
 	FetchConvertZIP(ctx context.Context, convertZIP string) ([]byte, error)
-	FetchConvertZIPTo(ctx context.Context, convertZIP string, dst io.Writer) error
+	FetchConvertZIPTo(ctx context.Context, convertZIP string, dst io.Writer) (int64, error)
+	FetchConvertBundle(ctx context.Context, convertZIP string) (*ConvertBundle, error)
 }
 
 // Client combines all doc2x operations
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// limits configures request throttling for one class of traffic.
+type limits struct {
+	rps         float64
+	burst       int
+	maxInFlight int
+}
+
+func (l limits) enabled() bool {
+	return l.rps > 0 || l.maxInFlight > 0
+}
+
+// WithRateLimit caps API requests (every call against the Doc2X base URL, including resty retries)
+// to rps per second with the given burst. It is shared by all goroutines using the client.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *client) {
+		c.apiLimits.rps = rps
+		c.apiLimits.burst = burst
+	}
+}
+
+// WithMaxInFlight caps the number of concurrent API requests.
+func WithMaxInFlight(n int) Option {
+	return func(c *client) {
+		c.apiLimits.maxInFlight = n
+	}
+}
+
+// WithTransferRateLimit caps presigned uploads and file downloads to rps per second with the given burst.
+func WithTransferRateLimit(rps float64, burst int) Option {
+	return func(c *client) {
+		c.transferLimits.rps = rps
+		c.transferLimits.burst = burst
+	}
+}
+
+// WithTransferMaxInFlight caps the number of concurrent presigned uploads and file downloads.
+// A download slot is held until its response body has been read and closed.
+func WithTransferMaxInFlight(n int) Option {
+	return func(c *client) {
+		c.transferLimits.maxInFlight = n
+	}
+}
+
+// tokenBucket is a minimal token-bucket rate limiter.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rps float64, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait before using it.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a token reserved by a caller that gave up waiting.
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+// wait blocks until a token is available or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	delay := b.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// limitedTransport enforces a rate limit and an in-flight cap in front of another RoundTripper.
+type limitedTransport struct {
+	base   http.RoundTripper
+	bucket *tokenBucket
+	slots  chan struct{}
+}
+
+// newLimitedTransport wraps base according to l. It returns base unchanged when no limit is set.
+func newLimitedTransport(base http.RoundTripper, l limits) http.RoundTripper {
+	if !l.enabled() {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	t := &limitedTransport{base: base}
+	if l.rps > 0 {
+		t.bucket = newTokenBucket(l.rps, l.burst)
+	}
+	if l.maxInFlight > 0 {
+		t.slots = make(chan struct{}, l.maxInFlight)
+	}
+	return t
+}
+
+// unwrapTransport returns the transport underneath any limiter installed by the client.
+func unwrapTransport(rt http.RoundTripper) http.RoundTripper {
+	if t, ok := rt.(*limitedTransport); ok {
+		return t.base
+	}
+	return rt
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	if t.slots != nil {
+		select {
+		case t.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if t.bucket != nil {
+		if err := t.bucket.wait(ctx); err != nil {
+			t.release()
+			return nil, err
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || t.slots == nil {
+		t.release()
+		return resp, err
+	}
+
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: t.release}
+	return resp, nil
+}
+
+func (t *limitedTransport) release() {
+	if t.slots != nil {
+		<-t.slots
+	}
+}
+
+// releaseOnClose frees an in-flight slot once the response body is closed.
+type releaseOnClose struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(10, 2)
+
+	for i := range 2 {
+		if delay := b.reserve(); delay != 0 {
+			t.Fatalf("reserve %d within burst waited %s", i+1, delay)
+		}
+	}
+	delay := b.reserve()
+	if delay < 90*time.Millisecond || delay > 100*time.Millisecond {
+		t.Fatalf("reserve past burst waited %s, want about 100ms", delay)
+	}
+
+	b.cancel()
+	if delay := b.reserve(); delay > 100*time.Millisecond {
+		t.Errorf("reserve after cancel waited %s, want at most 100ms", delay)
+	}
+}
+
+func TestTokenBucketWaitCanceled(t *testing.T) {
+	b := newTokenBucket(0.1, 1)
+	b.reserve()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wait error = %v, want DeadlineExceeded", err)
+	}
+}
+
+// concurrencyServer answers after a short delay and records the most requests it served at once.
+func concurrencyServer(t *testing.T, body string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var active, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &peak
+}
+
+func TestLimitedTransportMaxInFlight(t *testing.T) {
+	srv, peak := concurrencyServer(t, "ok")
+	httpClient := &http.Client{Transport: newLimitedTransport(http.DefaultTransport, limits{maxInFlight: 2})}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := httpClient.Get(srv.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got > 2 {
+		t.Errorf("server saw %d concurrent requests, want at most 2", got)
+	}
+}
+
+func TestLimitedTransportRate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+	httpClient := &http.Client{Transport: newLimitedTransport(http.DefaultTransport, limits{rps: 20, burst: 1})}
+
+	started := time.Now()
+	for range 4 {
+		resp, err := httpClient.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	// One request from the burst, then three at 50ms intervals.
+	if elapsed := time.Since(started); elapsed < 140*time.Millisecond {
+		t.Errorf("4 requests at 20 rps took %s, want about 150ms", elapsed)
+	}
+}
+
+func TestNewLimitedTransportDisabled(t *testing.T) {
+	base := http.DefaultTransport
+	if got := newLimitedTransport(base, limits{}); got != base {
+		t.Errorf("transport without limits = %T, want the base transport", got)
+	}
+}
+
+func TestClientMaxInFlightSharedAcrossCalls(t *testing.T) {
+	srv, peak := concurrencyServer(t, `{"code":"success","data":{"status":"processing","progress":10}}`)
+	c := NewClient("sk-test", WithBaseURL(srv.URL), WithMaxInFlight(1))
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetStatus(context.Background(), "uid-1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got != 1 {
+		t.Errorf("server saw %d concurrent requests, want 1", got)
+	}
+}
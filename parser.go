@@ -3,9 +3,13 @@ package client
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"time"
+
+	"github.com/go-resty/resty/v2"
 )
 
 // UploadPDF uploads PDF data for parsing.
@@ -23,6 +27,12 @@ func (c *client) UploadPDFReader(ctx context.Context, pdfReader io.Reader) (*Upl
 		return nil, ErrNilReader
 	}
 
+	if fn := transferProgressFrom(ctx, c.transferProgress); fn != nil {
+		progress := newProgressReader(pdfReader, fn, OperationUploadPDF, uploadSize(ctx, pdfReader))
+		defer progress.finish()
+		pdfReader = progress
+	}
+
 	var result UploadResponse
 	resp, err := c.restyClient.R().
 		SetContext(ctx).
@@ -39,11 +49,11 @@ func (c *client) UploadPDFReader(ctx context.Context, pdfReader io.Reader) (*Upl
 	result.TraceID = traceID
 
 	if !resp.IsSuccess() {
-		return nil, errStatus(OperationUploadPDF, resp.StatusCode(), resp.Status(), traceID)
+		return nil, errStatus(OperationUploadPDF, resp.StatusCode(), resp.Status(), resp.Header())
 	}
 
 	if err := ensureAPISuccess(result.Code, result.Msg); err != nil {
-		return nil, errCode(OperationUploadPDF, result.Code, result.Msg, traceID)
+		return nil, errCode(OperationUploadPDF, resp.StatusCode(), result.Code, result.Msg, traceID)
 	}
 
 	return &result, nil
@@ -65,11 +75,11 @@ func (c *client) PreUpload(ctx context.Context) (*PreUploadResponse, error) {
 	result.TraceID = traceID
 
 	if !resp.IsSuccess() {
-		return nil, errStatus(OperationPreUpload, resp.StatusCode(), resp.Status(), traceID)
+		return nil, errStatus(OperationPreUpload, resp.StatusCode(), resp.Status(), resp.Header())
 	}
 
 	if err := ensureAPISuccess(result.Code, result.Msg); err != nil {
-		return nil, errCode(OperationPreUpload, result.Code, result.Msg, traceID)
+		return nil, errCode(OperationPreUpload, resp.StatusCode(), result.Code, result.Msg, traceID)
 	}
 
 	return &result, nil
@@ -99,6 +109,22 @@ func (c *client) UploadToPresignedURLFrom(ctx context.Context, url string, file
 	}
 
 	transfer := c.transferClient()
+	total := uploadSize(ctx, file)
+
+	if fn := transferProgressFrom(ctx, c.transferProgress); fn != nil {
+		progress := newProgressReader(file, fn, OperationUploadPresigned, total)
+		defer progress.finish()
+		file = progress
+	}
+
+	// Wrapped readers hide the length from net/http; object stores reject the chunked encoding it
+	// would fall back to.
+	if total > 0 {
+		transfer.SetPreRequestHook(func(_ *resty.Client, req *http.Request) error {
+			req.ContentLength = total
+			return nil
+		})
+	}
 
 	resp, err := transfer.R().
 		SetContext(ctx).
@@ -111,12 +137,61 @@ func (c *client) UploadToPresignedURLFrom(ctx context.Context, url string, file
 	}
 
 	if !resp.IsSuccess() {
-		return fmt.Errorf("upload to presigned URL failed with status %d: %s", resp.StatusCode(), resp.Status())
+		return errStatus(OperationUploadPresigned, resp.StatusCode(), resp.Status(), resp.Header())
 	}
 
 	return nil
 }
 
+// pdfMagic starts every PDF file.
+var pdfMagic = []byte("%PDF-")
+
+// Upload sends a PDF of the given size, using UploadPDF up to MaxDirectUploadSize and a presigned
+// upload up to MaxPresignedUploadSize. Larger files fail with a *FileTooLargeError and data without
+// the %PDF- header fails with ErrNotPDF, both before a UID is requested.
+func (c *client) Upload(ctx context.Context, r io.Reader, size int64) (*UploadResult, error) {
+	if r == nil {
+		return nil, ErrNilReader
+	}
+	if size <= 0 {
+		return nil, ErrEmptyPDFData
+	}
+	if size > MaxPresignedUploadSize {
+		return nil, &FileTooLargeError{Size: size, Limit: MaxPresignedUploadSize}
+	}
+
+	header := make([]byte, len(pdfMagic))
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrNotPDF
+		}
+		return nil, fmt.Errorf("read PDF header: %w", err)
+	}
+	if !bytes.Equal(header, pdfMagic) {
+		return nil, ErrNotPDF
+	}
+	body := io.MultiReader(bytes.NewReader(header), r)
+	ctx = contextWithUploadSize(ctx, size)
+
+	if size <= MaxDirectUploadSize {
+		resp, err := c.UploadPDFReader(ctx, body)
+		if err != nil {
+			return nil, err
+		}
+		return &UploadResult{UID: resp.Data.UID, TraceID: resp.TraceID, Method: UploadMethodDirect}, nil
+	}
+
+	pre, err := c.PreUpload(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &UploadResult{UID: pre.Data.UID, TraceID: pre.TraceID, Method: UploadMethodPresigned}
+	if err := c.UploadToPresignedURLFrom(ctx, pre.Data.URL, body); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
 // GetStatus checks the parsing status for a given UID.
 func (c *client) GetStatus(ctx context.Context, uid string) (*StatusResponse, error) {
 	if uid == "" {
@@ -138,11 +213,11 @@ func (c *client) GetStatus(ctx context.Context, uid string) (*StatusResponse, er
 	result.TraceID = traceID
 
 	if !resp.IsSuccess() {
-		return nil, errStatus(OperationGetStatus, resp.StatusCode(), resp.Status(), traceID)
+		return nil, errStatus(OperationGetStatus, resp.StatusCode(), resp.Status(), resp.Header())
 	}
 
 	if err := ensureAPISuccess(result.Code, result.Msg); err != nil {
-		return nil, errCode(OperationGetStatus, result.Code, result.Msg, traceID)
+		return nil, errCode(OperationGetStatus, resp.StatusCode(), result.Code, result.Msg, traceID)
 	}
 
 	return &result, nil
@@ -154,7 +229,7 @@ func (c *client) WaitForParsing(ctx context.Context, uid string, pollInterval ti
 		return nil, ErrEmptyUID
 	}
 
-	return waitWithPolling(ctx, uid, pollInterval, OperationParsing, c.processingTimeout, c.GetStatus, func(status *StatusResponse) (bool, error) {
+	return waitWithPolling(ctx, uid, c.pollConfig(OperationParsing, pollInterval), c.GetStatus, parseTaskState, func(status *StatusResponse) (bool, error) {
 		if status.Data == nil {
 			return false, nil
 		}
@@ -167,7 +242,7 @@ func (c *client) WaitForParsing(ctx context.Context, uid string, pollInterval ti
 			if detail == "" {
 				detail = "unknown error"
 			}
-			return false, fmt.Errorf("parse failed: %s (trace-id: %s)", detail, status.TraceID)
+			return false, fmt.Errorf("%w: %s (trace-id: %s)", ErrParseFailed, detail, normalizeTraceID(status.TraceID))
 		default:
 			return false, nil
 		}
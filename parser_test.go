@@ -0,0 +1,184 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+// uploadServer answers direct and presigned uploads and records what it received.
+type uploadServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	paths    []string
+	put      *http.Request // Last presigned PUT, without its body
+	putBytes int64
+}
+
+func newUploadServer(t *testing.T) *uploadServer {
+	t.Helper()
+	s := &uploadServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+client.EndpointParsePDF, func(w http.ResponseWriter, r *http.Request) {
+		s.record(r)
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"code":"success","data":{"uid":"uid-direct"}}`)
+	})
+	mux.HandleFunc("POST "+client.EndpointPreUpload, func(w http.ResponseWriter, r *http.Request) {
+		s.record(r)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"code":"success","data":{"uid":"uid-presigned","url":"`+s.URL+`/oss/uid-presigned"}}`)
+	})
+	mux.HandleFunc("PUT /oss/", func(w http.ResponseWriter, r *http.Request) {
+		s.record(r)
+		n, _ := io.Copy(io.Discard, r.Body)
+		s.mu.Lock()
+		s.put, s.putBytes = r.Clone(context.Background()), n
+		s.mu.Unlock()
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *uploadServer) record(r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paths = append(s.paths, r.URL.Path)
+}
+
+func (s *uploadServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.paths...)
+}
+
+func (s *uploadServer) lastPut() (*http.Request, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.put, s.putBytes
+}
+
+// zeros is an endless reader of zero bytes.
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// largePDF returns a reader of size bytes starting with a PDF header.
+func largePDF(size int64) io.Reader {
+	header := []byte("%PDF-1.7\n")
+	return io.MultiReader(bytes.NewReader(header), io.LimitReader(zeros{}, size-int64(len(header))))
+}
+
+func TestUploadChoosesMethodBySize(t *testing.T) {
+	srv := newUploadServer(t)
+	c := client.NewClient("sk-test", client.WithBaseURL(srv.URL))
+	ctx := context.Background()
+
+	direct, err := c.Upload(ctx, largePDF(1<<10), 1<<10)
+	if err != nil {
+		t.Fatalf("Upload of a small file: %v", err)
+	}
+	if direct.Method != client.UploadMethodDirect || direct.UID != "uid-direct" {
+		t.Errorf("small file upload = %+v, want a direct upload", direct)
+	}
+
+	const size = client.MaxDirectUploadSize + 1
+	presigned, err := c.Upload(ctx, largePDF(size), size)
+	if err != nil {
+		t.Fatalf("Upload past the direct limit: %v", err)
+	}
+	if presigned.Method != client.UploadMethodPresigned || presigned.UID != "uid-presigned" {
+		t.Errorf("large file upload = %+v, want a presigned upload", presigned)
+	}
+
+	// Without a progress callback the PUT must still announce its length instead of going chunked.
+	put, n := srv.lastPut()
+	if put.ContentLength != size || len(put.TransferEncoding) != 0 || n != size {
+		t.Errorf("presigned PUT sent Content-Length %d, Transfer-Encoding %q and %d bytes, want %d bytes with a length",
+			put.ContentLength, put.TransferEncoding, n, int64(size))
+	}
+	want := []string{client.EndpointParsePDF, client.EndpointPreUpload, "/oss/uid-presigned"}
+	if got := srv.requests(); !slices.Equal(got, want) {
+		t.Errorf("server saw %q, want %q", got, want)
+	}
+}
+
+func TestUploadRejectsBeforeSending(t *testing.T) {
+	srv := newUploadServer(t)
+	c := client.NewClient("sk-test", client.WithBaseURL(srv.URL))
+
+	tests := []struct {
+		name string
+		data string
+		size int64
+		want error
+	}{
+		{"not a pdf", "hello world", 11, client.ErrNotPDF},
+		{"shorter than the header", "%PD", 3, client.ErrNotPDF},
+		{"empty", "", 0, client.ErrEmptyPDFData},
+		{"too large", "%PDF-", client.MaxPresignedUploadSize + 1, client.ErrFileTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.Upload(context.Background(), bytes.NewReader([]byte(tt.data)), tt.size)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Upload error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	var tooLarge *client.FileTooLargeError
+	_, err := c.Upload(context.Background(), bytes.NewReader(nil), client.MaxPresignedUploadSize+1)
+	if !errors.As(err, &tooLarge) || tooLarge.Size != client.MaxPresignedUploadSize+1 || tooLarge.Limit != client.MaxPresignedUploadSize {
+		t.Errorf("Upload error = %#v, want a *FileTooLargeError with size and limit", err)
+	}
+	if got := srv.requests(); len(got) != 0 {
+		t.Errorf("rejected uploads reached the server: %q", got)
+	}
+}
+
+func TestUploadToPresignedURL(t *testing.T) {
+	srv := newUploadServer(t)
+	c := client.NewClient("sk-test", client.WithBaseURL(srv.URL))
+	ctx := context.Background()
+
+	pre, err := c.PreUpload(ctx)
+	if err != nil {
+		t.Fatalf("PreUpload: %v", err)
+	}
+	if pre.Data.UID != "uid-presigned" || pre.Data.URL != srv.URL+"/oss/uid-presigned" {
+		t.Errorf("PreUpload data = %+v", pre.Data)
+	}
+
+	data := []byte("%PDF-1.4 presigned")
+	if err := c.UploadToPresignedURL(ctx, pre.Data.URL, data); err != nil {
+		t.Fatalf("UploadToPresignedURL: %v", err)
+	}
+	if put, _ := srv.lastPut(); put.ContentLength != int64(len(data)) || put.Header.Get("Content-Type") != "application/octet-stream" {
+		t.Errorf("presigned PUT = length %d, type %q", put.ContentLength, put.Header.Get("Content-Type"))
+	}
+
+	if err := c.UploadToPresignedURL(ctx, "", data); !errors.Is(err, client.ErrEmptyPresignedURL) {
+		t.Errorf("empty URL error = %v, want ErrEmptyPresignedURL", err)
+	}
+	if err := c.UploadToPresignedURL(ctx, pre.Data.URL, nil); !errors.Is(err, client.ErrEmptyFileData) {
+		t.Errorf("empty data error = %v, want ErrEmptyFileData", err)
+	}
+	if err := c.UploadToPresignedURLFrom(ctx, srv.URL+"/missing", bytes.NewReader(data)); err == nil {
+		t.Error("PUT answered with 404 reported success")
+	}
+}
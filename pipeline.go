@@ -0,0 +1,510 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Stage identifies a step of a Pipeline run.
+type Stage string
+
+const (
+	StageUpload   Stage = "upload"   // Client.Upload, direct or presigned by size
+	StageParse    Stage = "parse"    // WaitForParsing and Sink.WriteResult
+	StageConvert  Stage = "convert"  // ConvertAll for every requested format
+	StageDownload Stage = "download" // Download of one converted file into the sink
+)
+
+// StageEvent describes a pipeline stage to hooks.
+type StageEvent struct {
+	Stage   Stage
+	Name    string          // Input name passed to the pipeline
+	UID     string          // Task UID; empty before the upload stage finishes
+	TraceID string          // Trace ID of the stage's last API response
+	Status  *StatusResponse // Parse status, set after StageParse
+	Outputs []ConvertOutput // Conversion outcomes, set after StageConvert
+	Output  *ConvertOutput  // Conversion being downloaded, set for StageDownload
+	Elapsed time.Duration   // Stage duration, set for AfterStage
+	Err     error           // Stage failure, set for AfterStage
+	Cached  bool            // The stage was served from PipelineOptions.Cache
+}
+
+// PipelineHooks observe pipeline stages. Both hooks are optional; an error returned by either
+// stops the run and is returned by the pipeline.
+type PipelineHooks struct {
+	BeforeStage func(ctx context.Context, ev StageEvent) error
+	AfterStage  func(ctx context.Context, ev StageEvent) error
+}
+
+// Sink receives pipeline outputs.
+type Sink interface {
+	// WriteResult stores a successful parse result. It runs inside StageParse.
+	WriteResult(ctx context.Context, name string, status *StatusResponse) error
+	// WriteFile stores one converted file; download streams the file into the writer it is given.
+	WriteFile(ctx context.Context, name string, out ConvertOutput, download func(io.Writer) error) error
+}
+
+// PipelineOptions configures a Pipeline.
+type PipelineOptions struct {
+	Formats             []ConvertFormat // Conversion targets; empty stops after parsing
+	FormulaMode         FormulaMode     // Formula mode for conversions (default FormulaModeNormal)
+	Filename            string          // Output filename for md/tex conversions (optional)
+	MergeCrossPageForms bool            // Merge tables across pages during conversion
+	PollInterval        time.Duration   // Base poll interval (default DefaultPollInterval)
+	Sink                Sink            // Receives results and downloads; nil skips downloads
+	Cache               *FileCache      // Reuses results of identical PDFs; nil disables caching
+	Hooks               PipelineHooks
+}
+
+// PipelineResult is the outcome of a pipeline run.
+type PipelineResult struct {
+	Name    string
+	UID     string
+	Status  *StatusResponse
+	Outputs []ConvertOutput
+}
+
+// StageError reports the stage a pipeline run failed in.
+type StageError struct {
+	Stage Stage
+	Name  string
+	UID   string
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	if e.UID == "" {
+		return fmt.Sprintf("%s %s: %v", e.Name, e.Stage, e.Err)
+	}
+	return fmt.Sprintf("%s %s (uid %s): %v", e.Name, e.Stage, e.UID, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// Pipeline runs upload, parse, conversion and download for PDFs:
+//
+//	p := client.NewPipeline(c, client.PipelineOptions{
+//		Formats: []client.ConvertFormat{client.FormatMarkdown, client.FormatDocx},
+//		Sink:    client.DirSink{Dir: "out"},
+//	})
+//	result, err := p.ProcessFile(ctx, "paper.pdf")
+//
+// A Pipeline holds no per-run state and may be used concurrently.
+type Pipeline struct {
+	client Client
+	opts   PipelineOptions
+}
+
+// NewPipeline creates a pipeline on top of c.
+func NewPipeline(c Client, opts PipelineOptions) *Pipeline {
+	if opts.FormulaMode == "" {
+		opts.FormulaMode = FormulaModeNormal
+	}
+	return &Pipeline{client: c, opts: opts}
+}
+
+// ProcessPDF runs the full pipeline for the PDF at path.
+func ProcessPDF(ctx context.Context, c Client, path string, opts PipelineOptions) (*PipelineResult, error) {
+	return NewPipeline(c, opts).ProcessFile(ctx, path)
+}
+
+// ProcessFile runs the full pipeline for the PDF at path, named after its base name.
+func (p *Pipeline) ProcessFile(ctx context.Context, path string) (*PipelineResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat file %s: %w", path, err)
+	}
+	return p.Process(ctx, filepath.Base(path), f, info.Size())
+}
+
+// Process uploads size bytes of r under name and continues with parsing, conversion and download.
+// With a cache, seekable readers are hashed first so identical content is served from the cache
+// without an upload; other readers are hashed while uploading.
+func (p *Pipeline) Process(ctx context.Context, name string, r io.Reader, size int64) (*PipelineResult, error) {
+	var digest hash.Hash
+	if p.opts.Cache != nil && contentHashFrom(ctx) == "" {
+		if rs, ok := r.(io.ReadSeeker); ok {
+			sum, err := hashSeeker(rs)
+			if err != nil {
+				return &PipelineResult{Name: name}, err
+			}
+			ctx = ContextWithContentHash(ctx, sum)
+		} else {
+			digest = sha256.New()
+			r = io.TeeReader(r, digest)
+		}
+	}
+
+	if result, ok, err := p.Cached(ctx, name); ok || err != nil {
+		return result, err
+	}
+
+	uid, _, err := p.Upload(ctx, name, r, size)
+	if err != nil {
+		return &PipelineResult{Name: name}, err
+	}
+	if digest != nil {
+		ctx = ContextWithContentHash(ctx, hex.EncodeToString(digest.Sum(nil)))
+	}
+	return p.Continue(ctx, name, uid)
+}
+
+// hashSeeker hashes the rest of rs and rewinds it.
+func hashSeeker(rs io.ReadSeeker) (string, error) {
+	start, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", fmt.Errorf("seek input: %w", err)
+	}
+	sum, err := ContentHash(rs)
+	if err != nil {
+		return "", err
+	}
+	if _, err := rs.Seek(start, io.SeekStart); err != nil {
+		return "", fmt.Errorf("rewind input: %w", err)
+	}
+	return sum, nil
+}
+
+// Cached serves a run from the cache when the parse result for the content hash in ctx (see
+// ContextWithContentHash) is cached: the result goes to the sink, cached files are copied and
+// formats not cached yet are converted from the cached task UID. On a miss it does nothing and
+// reports false.
+func (p *Pipeline) Cached(ctx context.Context, name string) (*PipelineResult, bool, error) {
+	sum := contentHashFrom(ctx)
+	if p.opts.Cache == nil || sum == "" {
+		return nil, false, nil
+	}
+
+	cached, ok, err := p.opts.Cache.LookupParse(sum)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	result := &PipelineResult{Name: name, UID: cached.UID, Status: cached.Status}
+	ev := StageEvent{Stage: StageParse, Name: name, UID: cached.UID, TraceID: cached.TraceID, Status: cached.Status, Cached: true}
+	if err := p.stage(ctx, &ev, func() error {
+		return p.writeResult(ctx, name, cached.Status)
+	}); err != nil {
+		return result, true, err
+	}
+
+	if len(p.opts.Formats) == 0 {
+		return result, true, nil
+	}
+
+	outputs, err := p.Convert(ctx, name, cached.UID)
+	result.Outputs = outputs
+	return result, true, err
+}
+
+// Upload runs StageUpload only and returns the new task UID and its trace ID.
+func (p *Pipeline) Upload(ctx context.Context, name string, r io.Reader, size int64) (uid, traceID string, err error) {
+	ev := StageEvent{Stage: StageUpload, Name: name}
+	err = p.stage(ctx, &ev, func() error {
+		uploaded, err := p.client.Upload(ctx, r, size)
+		if uploaded != nil {
+			ev.UID, ev.TraceID = uploaded.UID, uploaded.TraceID
+		}
+		return err
+	})
+	return ev.UID, ev.TraceID, err
+}
+
+// Continue runs parsing, conversion and download for an uploaded task, such as one whose
+// earlier run was interrupted. Results are cached when ctx carries the content hash.
+func (p *Pipeline) Continue(ctx context.Context, name, uid string) (*PipelineResult, error) {
+	result := &PipelineResult{Name: name, UID: uid}
+
+	ev := StageEvent{Stage: StageParse, Name: name, UID: uid}
+	if err := p.stage(ctx, &ev, func() error {
+		status, err := p.client.WaitForParsing(ctx, uid, p.opts.PollInterval)
+		if status != nil {
+			ev.TraceID, ev.Status = status.TraceID, status
+		}
+		if err != nil {
+			return err
+		}
+		if status.Data == nil || status.Data.Result == nil {
+			return fmt.Errorf("parse finished without a result (trace-id: %s)", status.TraceID)
+		}
+		result.Status = status
+		if sum := contentHashFrom(ctx); p.opts.Cache != nil && sum != "" {
+			if err := p.opts.Cache.StoreParse(sum, name, uid, status); err != nil {
+				return err
+			}
+		}
+		return p.writeResult(ctx, name, status)
+	}); err != nil {
+		return result, err
+	}
+
+	if len(p.opts.Formats) == 0 {
+		return result, nil
+	}
+
+	outputs, err := p.Convert(ctx, name, uid)
+	result.Outputs = outputs
+	return result, err
+}
+
+func (p *Pipeline) writeResult(ctx context.Context, name string, status *StatusResponse) error {
+	if p.opts.Sink == nil {
+		return nil
+	}
+	return p.opts.Sink.WriteResult(ctx, name, status)
+}
+
+// Convert runs conversion and download for a parsed task. Formats that converted are still
+// downloaded when others failed; the returned error joins every failure. Files cached for the
+// content hash in ctx are not converted again.
+func (p *Pipeline) Convert(ctx context.Context, name, uid string) ([]ConvertOutput, error) {
+	reqs := p.convertRequests(uid)
+	outputs := make([]ConvertOutput, len(reqs))
+
+	var (
+		pending []ConvertRequest
+		slots   []int // Index in outputs of each pending request
+	)
+	sum := contentHashFrom(ctx)
+	for i, req := range reqs {
+		if p.opts.Cache != nil && sum != "" {
+			file, ok, err := p.opts.Cache.LookupFile(sum, req)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				outputs[i] = ConvertOutput{Request: req, URL: file.URL, TraceID: file.TraceID, Cached: true}
+				continue
+			}
+		}
+		pending = append(pending, req)
+		slots = append(slots, i)
+	}
+
+	ev := StageEvent{Stage: StageConvert, Name: name, UID: uid, Cached: len(pending) == 0}
+	err := p.stage(ctx, &ev, func() error {
+		var err error
+		if len(pending) > 0 {
+			var converted []ConvertOutput
+			converted, err = p.client.ConvertAll(ContextWithPollInterval(ctx, p.opts.PollInterval), uid, pending)
+			for i, slot := range slots {
+				if converted == nil {
+					// Nothing converted, e.g. the UID lock was not acquired; cached outputs are kept.
+					outputs[slot] = ConvertOutput{Request: pending[i], Err: err}
+					continue
+				}
+				outputs[slot] = converted[i]
+			}
+		}
+		ev.Outputs = outputs
+		if len(outputs) > 0 {
+			ev.TraceID = outputs[len(outputs)-1].TraceID
+		}
+		return err
+	})
+
+	// Hook errors stop the run; cached and converted formats are downloaded despite failed ones.
+	var stageErr *StageError
+	if err != nil && !errors.As(err, &stageErr) {
+		return outputs, err
+	}
+	return outputs, errors.Join(err, p.downloadAll(ctx, name, outputs))
+}
+
+func (p *Pipeline) convertRequests(uid string) []ConvertRequest {
+	reqs := make([]ConvertRequest, 0, len(p.opts.Formats))
+	for _, format := range p.opts.Formats {
+		reqs = append(reqs, ConvertRequest{
+			UID:                 uid,
+			To:                  format,
+			FormulaMode:         p.opts.FormulaMode,
+			Filename:            p.opts.Filename,
+			MergeCrossPageForms: p.opts.MergeCrossPageForms,
+		})
+	}
+	return reqs
+}
+
+// downloadAll stores every successful conversion in the sink, continuing past failed downloads.
+func (p *Pipeline) downloadAll(ctx context.Context, name string, outputs []ConvertOutput) error {
+	if p.opts.Sink == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, out := range outputs {
+		if out.Err != nil {
+			continue
+		}
+		if err := p.Download(ctx, name, out); err != nil {
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Download runs StageDownload only, storing one finished conversion in the sink, such as one whose
+// earlier download failed.
+func (p *Pipeline) Download(ctx context.Context, name string, out ConvertOutput) error {
+	if p.opts.Sink == nil {
+		return errors.New("pipeline has no sink to download into")
+	}
+	ev := StageEvent{Stage: StageDownload, Name: name, UID: out.Request.UID, TraceID: out.TraceID, Output: &out, Cached: out.Cached}
+	return p.stage(ctx, &ev, func() error {
+		return p.opts.Sink.WriteFile(ctx, name, out, p.download(ctx, out))
+	})
+}
+
+// download returns the function that streams out into a sink: from the cache for cached outputs,
+// otherwise from the server while also storing the file in the cache when there is one.
+func (p *Pipeline) download(ctx context.Context, out ConvertOutput) func(io.Writer) error {
+	sum := contentHashFrom(ctx)
+	if p.opts.Cache == nil || sum == "" {
+		return func(w io.Writer) error {
+			return p.client.DownloadFileTo(ctx, out.URL, w)
+		}
+	}
+
+	if out.Cached {
+		return func(w io.Writer) error {
+			file, ok, err := p.opts.Cache.LookupFile(sum, out.Request)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("cached %s file disappeared", out.Request.To)
+			}
+			f, err := os.Open(file.Path)
+			if err != nil {
+				return fmt.Errorf("open cached file: %w", err)
+			}
+			defer f.Close()
+			_, err = io.Copy(w, f)
+			return err
+		}
+	}
+
+	return func(w io.Writer) error {
+		return p.opts.Cache.StoreFile(sum, out, func(cw io.Writer) error {
+			return p.client.DownloadFileTo(ctx, out.URL, io.MultiWriter(w, cw))
+		})
+	}
+}
+
+// stage runs fn between the hooks. Failures of fn are wrapped in a StageError; hook errors are
+// returned as they are.
+func (p *Pipeline) stage(ctx context.Context, ev *StageEvent, fn func() error) error {
+	if hook := p.opts.Hooks.BeforeStage; hook != nil {
+		if err := hook(ctx, *ev); err != nil {
+			return err
+		}
+	}
+
+	started := time.Now()
+	err := fn()
+	if err != nil {
+		err = &StageError{Stage: ev.Stage, Name: ev.Name, UID: ev.UID, Err: err}
+	}
+
+	if hook := p.opts.Hooks.AfterStage; hook != nil {
+		ev.Elapsed, ev.Err = time.Since(started), err
+		if hookErr := hook(ctx, *ev); hookErr != nil {
+			return hookErr
+		}
+	}
+	return err
+}
+
+// DirSink writes parse results and converted files into Dir: the result as <name>.json and each
+// converted file as <name>_<format><ext>, with the extension taken from the download URL.
+type DirSink struct {
+	Dir string
+}
+
+// WriteResult writes the parse result JSON.
+func (s DirSink) WriteResult(ctx context.Context, name string, status *StatusResponse) error {
+	return s.create(stripExt(name)+".json", func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(status.Data.Result)
+	})
+}
+
+// WriteFile downloads a converted file.
+func (s DirSink) WriteFile(ctx context.Context, name string, out ConvertOutput, download func(io.Writer) error) error {
+	return s.create(stripExt(name)+"_"+string(out.Request.To)+urlExt(out.URL), download)
+}
+
+// create writes to a temporary file and renames it into place once write succeeds.
+func (s DirSink) create(name string, write func(io.Writer) error) error {
+	if err := os.MkdirAll(s.dirOrDot(), 0o755); err != nil {
+		return fmt.Errorf("create output dir: %w", err)
+	}
+
+	target := filepath.Join(s.dirOrDot(), name)
+	tmp, err := os.CreateTemp(s.dirOrDot(), "."+name+".*.part")
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("chmod file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("rename file: %w", err)
+	}
+	return nil
+}
+
+func (s DirSink) dirOrDot() string {
+	if s.Dir == "" {
+		return "."
+	}
+	return s.Dir
+}
+
+func stripExt(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// urlExt returns the extension of the URL path, defaulting to ".zip" as conversions are zipped.
+func urlExt(rawURL string) string {
+	if parsed, err := url.Parse(rawURL); err == nil {
+		if ext := path.Ext(parsed.Path); ext != "" {
+			return ext
+		}
+	}
+	return ".zip"
+}
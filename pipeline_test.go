@@ -0,0 +1,141 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	client "github.com/hsn0918/doc2x-client"
+	"github.com/hsn0918/doc2x-client/doc2xtest"
+)
+
+func writeSample(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "paper.pdf")
+	if err := os.WriteFile(path, samplePDF, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPipelineProcessFile(t *testing.T) {
+	srv := doc2xtest.NewServer(
+		doc2xtest.WithPages(doc2xtest.Page{Md: "# Title\n\nBody"}),
+		doc2xtest.WithConvertContent(convertedContent),
+	)
+	defer srv.Close()
+
+	out := t.TempDir()
+	var stages []client.Stage
+	p := client.NewPipeline(srv.Client(), client.PipelineOptions{
+		Formats:      []client.ConvertFormat{client.FormatMarkdown, client.FormatDocx},
+		PollInterval: time.Millisecond,
+		Sink:         client.DirSink{Dir: out},
+		Hooks: client.PipelineHooks{
+			AfterStage: func(_ context.Context, ev client.StageEvent) error {
+				if ev.Err != nil {
+					t.Errorf("stage %s failed: %v", ev.Stage, ev.Err)
+				}
+				stages = append(stages, ev.Stage)
+				return nil
+			},
+		},
+	})
+
+	result, err := p.ProcessFile(context.Background(), writeSample(t))
+	if err != nil {
+		t.Fatalf("ProcessFile: %v", err)
+	}
+	if result.Name != "paper.pdf" || result.UID == "" || len(result.Outputs) != 2 {
+		t.Fatalf("result = %+v", result)
+	}
+
+	want := []client.Stage{client.StageUpload, client.StageParse, client.StageConvert, client.StageDownload, client.StageDownload}
+	if !reflect.DeepEqual(stages, want) {
+		t.Errorf("stages = %v, want %v", stages, want)
+	}
+
+	data, err := os.ReadFile(filepath.Join(out, "paper.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed client.ParseResult
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("decode paper.json: %v", err)
+	}
+	if len(parsed.Pages) != 1 || parsed.Pages[0].Md != "# Title\n\nBody" {
+		t.Errorf("paper.json pages = %+v", parsed.Pages)
+	}
+
+	files := map[client.ConvertFormat]string{client.FormatMarkdown: "paper_md.zip", client.FormatDocx: "paper_docx.docx"}
+	for to, name := range files {
+		data, err := os.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := convertedContent(result.UID, to); string(data) != string(want) {
+			t.Errorf("%s file = %q, want %q", to, data, want)
+		}
+	}
+}
+
+func TestPipelineStageError(t *testing.T) {
+	srv := doc2xtest.NewServer(doc2xtest.WithParseScript(doc2xtest.Script{Fail: true, Detail: "broken pdf"}))
+	defer srv.Close()
+
+	var stages []client.Stage
+	p := client.NewPipeline(srv.Client(), client.PipelineOptions{
+		Formats:      []client.ConvertFormat{client.FormatMarkdown},
+		PollInterval: time.Millisecond,
+		Sink:         client.DirSink{Dir: t.TempDir()},
+		Hooks: client.PipelineHooks{
+			AfterStage: func(_ context.Context, ev client.StageEvent) error {
+				stages = append(stages, ev.Stage)
+				return nil
+			},
+		},
+	})
+
+	result, err := p.ProcessFile(context.Background(), writeSample(t))
+	var stageErr *client.StageError
+	if !errors.As(err, &stageErr) {
+		t.Fatalf("ProcessFile error = %v, want a *StageError", err)
+	}
+	if stageErr.Stage != client.StageParse || stageErr.Name != "paper.pdf" || stageErr.UID != result.UID {
+		t.Errorf("stage error = %+v, want parse failure of paper.pdf (uid %s)", stageErr, result.UID)
+	}
+	if result.Outputs != nil {
+		t.Errorf("outputs = %+v, want none after a failed parse", result.Outputs)
+	}
+	if want := []client.Stage{client.StageUpload, client.StageParse}; !reflect.DeepEqual(stages, want) {
+		t.Errorf("stages = %v, want %v", stages, want)
+	}
+}
+
+func TestPipelineHookErrorStopsRun(t *testing.T) {
+	srv := doc2xtest.NewServer()
+	defer srv.Close()
+
+	stop := errors.New("stop")
+	p := client.NewPipeline(srv.Client(), client.PipelineOptions{
+		Formats:      []client.ConvertFormat{client.FormatMarkdown},
+		PollInterval: time.Millisecond,
+		Hooks: client.PipelineHooks{
+			BeforeStage: func(_ context.Context, ev client.StageEvent) error {
+				if ev.Stage == client.StageConvert {
+					return stop
+				}
+				return nil
+			},
+		},
+	})
+
+	if _, err := p.ProcessFile(context.Background(), writeSample(t)); err != stop {
+		t.Errorf("ProcessFile error = %v, want the hook error as is", err)
+	}
+}
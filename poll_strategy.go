@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// DefaultPollInterval is used when neither a strategy nor a positive poll interval is provided.
+const DefaultPollInterval = 2 * time.Second
+
+// PollState describes the polling loop when a PollStrategy picks the next delay.
+type PollState struct {
+	Attempt  int           // Status requests issued so far, starting at 1
+	Progress int           // Last reported progress percentage
+	Elapsed  time.Duration // Time since polling started
+	Interval time.Duration // Poll interval passed to the Wait* call, zero when unset
+}
+
+// PollStrategy decides how long to wait before the next status poll.
+type PollStrategy interface {
+	NextDelay(state PollState) time.Duration
+}
+
+// PollStrategyFunc adapts a function to PollStrategy.
+type PollStrategyFunc func(state PollState) time.Duration
+
+// NextDelay calls f.
+func (f PollStrategyFunc) NextDelay(state PollState) time.Duration {
+	return f(state)
+}
+
+// FixedPoll waits the same interval between polls. A non-positive interval falls back to the
+// interval passed to the Wait* call, then to DefaultPollInterval.
+func FixedPoll(interval time.Duration) PollStrategy {
+	return PollStrategyFunc(func(state PollState) time.Duration {
+		return firstPositive(interval, state.Interval, DefaultPollInterval)
+	})
+}
+
+// ExponentialPoll grows the delay geometrically from Initial up to Max, with optional jitter.
+type ExponentialPoll struct {
+	Initial    time.Duration // First delay; falls back to the call interval, then 500ms
+	Max        time.Duration // Upper bound; defaults to 30s
+	Multiplier float64       // Growth factor; defaults to 2
+	Jitter     float64       // Random spread as a fraction of the delay, e.g. 0.2 for ±20%
+}
+
+// NextDelay implements PollStrategy.
+func (p ExponentialPoll) NextDelay(state PollState) time.Duration {
+	initial := firstPositive(p.Initial, state.Interval, 500*time.Millisecond)
+	maxDelay := firstPositive(p.Max, 30*time.Second)
+	multiplier := p.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+
+	attempt := max(state.Attempt-1, 0)
+	delay := float64(initial) * math.Pow(multiplier, float64(attempt))
+	delay = math.Min(delay, float64(maxDelay))
+
+	return applyJitter(time.Duration(delay), p.Jitter)
+}
+
+// ProgressAwarePoll polls slowly while a task has far to go and speeds up as progress approaches 100%.
+type ProgressAwarePoll struct {
+	Min time.Duration // Delay close to completion; defaults to 500ms
+	Max time.Duration // Delay at 0% progress; defaults to 10s
+}
+
+// NextDelay implements PollStrategy.
+func (p ProgressAwarePoll) NextDelay(state PollState) time.Duration {
+	minDelay := firstPositive(p.Min, 500*time.Millisecond)
+	maxDelay := max(firstPositive(p.Max, 10*time.Second), minDelay)
+
+	progress := min(max(state.Progress, 0), 100)
+	remaining := float64(100-progress) / 100
+
+	return minDelay + time.Duration(remaining*float64(maxDelay-minDelay))
+}
+
+// WithPollStrategy sets the default strategy used by the Wait* helpers.
+func WithPollStrategy(strategy PollStrategy) Option {
+	return func(c *client) {
+		c.pollStrategy = strategy
+	}
+}
+
+type (
+	pollStrategyKey struct{}
+	pollIntervalKey struct{}
+)
+
+// ContextWithPollStrategy overrides the poll strategy for Wait* calls made with the returned context.
+func ContextWithPollStrategy(ctx context.Context, strategy PollStrategy) context.Context {
+	return context.WithValue(ctx, pollStrategyKey{}, strategy)
+}
+
+// ContextWithPollInterval sets the base poll interval for calls that do not take one, such as ConvertAll.
+func ContextWithPollInterval(ctx context.Context, interval time.Duration) context.Context {
+	return context.WithValue(ctx, pollIntervalKey{}, interval)
+}
+
+// pollIntervalFrom returns interval, or the context interval when interval is not positive.
+func pollIntervalFrom(ctx context.Context, interval time.Duration) time.Duration {
+	if interval > 0 {
+		return interval
+	}
+	if d, ok := ctx.Value(pollIntervalKey{}).(time.Duration); ok {
+		return d
+	}
+	return interval
+}
+
+// pollStrategyFrom resolves the strategy for a call: context first, then the client default, then a fixed interval.
+func pollStrategyFrom(ctx context.Context, fallback PollStrategy) PollStrategy {
+	if strategy, ok := ctx.Value(pollStrategyKey{}).(PollStrategy); ok && strategy != nil {
+		return strategy
+	}
+	if fallback != nil {
+		return fallback
+	}
+	return FixedPoll(0)
+}
+
+func applyJitter(delay time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 || delay <= 0 {
+		return delay
+	}
+	jitter = math.Min(jitter, 1)
+	spread := (rand.Float64()*2 - 1) * jitter * float64(delay)
+	return delay + time.Duration(spread)
+}
+
+func firstPositive(values ...time.Duration) time.Duration {
+	for _, v := range values {
+		if v > 0 {
+			return v
+		}
+	}
+	return 0
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFixedPoll(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		state    PollState
+		want     time.Duration
+	}{
+		{interval: time.Second, state: PollState{Interval: 5 * time.Second}, want: time.Second},
+		{state: PollState{Interval: 5 * time.Second}, want: 5 * time.Second},
+		{want: DefaultPollInterval},
+	}
+	for _, tt := range tests {
+		if got := FixedPoll(tt.interval).NextDelay(tt.state); got != tt.want {
+			t.Errorf("FixedPoll(%s).NextDelay(%+v) = %s, want %s", tt.interval, tt.state, got, tt.want)
+		}
+	}
+}
+
+func TestExponentialPoll(t *testing.T) {
+	p := ExponentialPoll{Initial: 100 * time.Millisecond, Max: time.Second}
+	want := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, w := range want {
+		if got := p.NextDelay(PollState{Attempt: i + 1}); got != w*time.Millisecond {
+			t.Errorf("attempt %d: delay = %s, want %s", i+1, got, w*time.Millisecond)
+		}
+	}
+
+	// The call interval is the initial delay when Initial is not set.
+	if got := (ExponentialPoll{Multiplier: 3}).NextDelay(PollState{Attempt: 2, Interval: time.Second}); got != 3*time.Second {
+		t.Errorf("delay from call interval = %s, want 3s", got)
+	}
+
+	jittered := ExponentialPoll{Initial: time.Second, Jitter: 0.2}
+	for range 100 {
+		if got := jittered.NextDelay(PollState{Attempt: 1}); got < 800*time.Millisecond || got > 1200*time.Millisecond {
+			t.Fatalf("jittered delay %s outside ±20%% of 1s", got)
+		}
+	}
+}
+
+func TestProgressAwarePoll(t *testing.T) {
+	p := ProgressAwarePoll{Min: time.Second, Max: 11 * time.Second}
+	tests := []struct {
+		progress int
+		want     time.Duration
+	}{
+		{0, 11 * time.Second},
+		{50, 6 * time.Second},
+		{100, time.Second},
+		{150, time.Second},
+		{-10, 11 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := p.NextDelay(PollState{Progress: tt.progress}); got != tt.want {
+			t.Errorf("progress %d: delay = %s, want %s", tt.progress, got, tt.want)
+		}
+	}
+}
+
+func TestPollStrategyFrom(t *testing.T) {
+	fixed := FixedPoll(time.Second)
+	override := FixedPoll(time.Minute)
+
+	if got := pollStrategyFrom(context.Background(), fixed).NextDelay(PollState{}); got != time.Second {
+		t.Errorf("client strategy delay = %s, want 1s", got)
+	}
+	ctx := ContextWithPollStrategy(context.Background(), override)
+	if got := pollStrategyFrom(ctx, fixed).NextDelay(PollState{}); got != time.Minute {
+		t.Errorf("context strategy delay = %s, want 1m", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	header := func(value string) http.Header {
+		return http.Header{"Retry-After": []string{value}}
+	}
+
+	if got := parseRetryAfter(header("7")); got != 7*time.Second {
+		t.Errorf("seconds: got %s, want 7s", got)
+	}
+	for _, value := range []string{"", "-3", "soon", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)} {
+		if got := parseRetryAfter(header(value)); got != 0 {
+			t.Errorf("Retry-After %q: got %s, want 0", value, got)
+		}
+	}
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(header(date)); got <= 50*time.Second || got > time.Minute {
+		t.Errorf("HTTP date a minute ahead: got %s", got)
+	}
+}
+
+func TestRetryAfterOnlyFromThrottlingStatus(t *testing.T) {
+	header := http.Header{"Retry-After": []string{"2"}}
+
+	err := errStatus(OperationGetStatus, http.StatusTooManyRequests, "429 Too Many Requests", header)
+	if got := retryAfterOf(fmt.Errorf("wrapped: %w", err)); got != 2*time.Second {
+		t.Errorf("429 Retry-After = %s, want 2s", got)
+	}
+	err = errStatus(OperationGetStatus, http.StatusInternalServerError, "500 Internal Server Error", header)
+	if got := retryAfterOf(err); got != 0 {
+		t.Errorf("500 Retry-After = %s, want 0", got)
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"429", &APIError{StatusCode: http.StatusTooManyRequests}, true},
+		{"503", &APIError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"400", &APIError{StatusCode: http.StatusBadRequest}, false},
+		{"concurrency code on 200", errCode(OperationGetStatus, http.StatusOK, CodeConcurrencyLimit, "busy", ""), true},
+		{"task limit code on 200", errCode(OperationGetStatus, http.StatusOK, CodeTaskLimitExceeded, "busy", ""), true},
+		{"quota code", errCode(OperationGetStatus, http.StatusOK, CodeQuotaLimit, "empty", ""), false},
+		{"canceled", context.Canceled, false},
+		{"deadline", fmt.Errorf("poll: %w", context.DeadlineExceeded), false},
+		{"plain", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := isTransientError(tt.err); got != tt.want {
+			t.Errorf("%s: isTransientError = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -37,30 +40,86 @@ func withProcessingTimeout(ctx context.Context, timeout time.Duration) (context.
 	return ctxWithTimeout, cancel
 }
 
+// pollConfig carries the per-call settings of a polling loop.
+type pollConfig struct {
+	operation Operation
+	interval  time.Duration
+	timeout   time.Duration
+	strategy  PollStrategy
+	progress  ProgressFunc
+}
+
+// pollConfig builds the polling settings for operation using the client defaults.
+func (c *client) pollConfig(operation Operation, interval time.Duration) pollConfig {
+	return pollConfig{
+		operation: operation,
+		interval:  interval,
+		timeout:   c.processingTimeout,
+		strategy:  c.pollStrategy,
+		progress:  c.progress,
+	}
+}
+
+// emit forwards event to the configured progress callback, if any.
+func (cfg pollConfig) emit(event ProgressEvent) {
+	if cfg.progress == nil {
+		return
+	}
+	event.Operation = cfg.operation
+	event.Time = time.Now()
+	cfg.progress(event)
+}
+
 // waitWithPolling repeatedly fetches task status until completion, failure, or timeout.
-func waitWithPolling[T any](ctx context.Context, uid string, pollInterval time.Duration, operation Operation,
-	timeout time.Duration,
+// Delays between polls come from the resolved PollStrategy, stretched to honour Retry-After hints.
+func waitWithPolling[T any](ctx context.Context, uid string, cfg pollConfig,
 	fetch func(context.Context, string) (*T, error),
+	describe func(*T) taskState,
 	evaluate func(*T) (bool, error),
 ) (*T, error) {
-	if pollInterval <= 0 {
-		pollInterval = 2 * time.Second
-	}
+	operation := cfg.operation
+	strategy := pollStrategyFrom(ctx, cfg.strategy)
+	cfg.interval = pollIntervalFrom(ctx, cfg.interval)
 
-	ctx, cancel := withProcessingTimeout(ctx, timeout)
+	ctx, cancel := withProcessingTimeout(ctx, cfg.timeout)
 	defer cancel()
 
-	ticker := time.NewTicker(pollInterval)
-	defer ticker.Stop()
-
+	started := time.Now()
 	retriesLeft := transientFetchRetryBudget
+	polls := 0
+	var last taskState
+
+	nextDelay := func() time.Duration {
+		delay := strategy.NextDelay(PollState{
+			Attempt:  polls,
+			Progress: last.progress,
+			Elapsed:  time.Since(started),
+			Interval: cfg.interval,
+		})
+		if delay <= 0 {
+			delay = firstPositive(cfg.interval, DefaultPollInterval)
+		}
+		return delay
+	}
 
 	for {
 		result, err := fetch(ctx, uid)
+		polls++
 		if err != nil {
 			if retriesLeft > 0 && isTransientError(err) {
 				retriesLeft--
-				if err := waitForNextPoll(ctx, ticker, operation); err != nil {
+				cfg.emit(ProgressEvent{
+					Kind:     ProgressEventRetry,
+					UID:      uid,
+					Status:   last.status,
+					Progress: last.progress,
+					Poll:     polls,
+					Retries:  transientFetchRetryBudget - retriesLeft,
+					TraceID:  last.traceID,
+					Err:      err,
+				})
+				delay := max(nextDelay(), retryAfterOf(err))
+				if err := waitForNextPoll(ctx, delay, operation); err != nil {
 					return nil, err
 				}
 				continue
@@ -70,6 +129,21 @@ func waitWithPolling[T any](ctx context.Context, uid string, pollInterval time.D
 
 		retriesLeft = transientFetchRetryBudget
 
+		state := describe(result)
+		kind := ProgressEventProgress
+		if polls == 1 || state.status != last.status {
+			kind = ProgressEventStatus
+		}
+		last = state
+		cfg.emit(ProgressEvent{
+			Kind:     kind,
+			UID:      uid,
+			Status:   state.status,
+			Progress: state.progress,
+			Poll:     polls,
+			TraceID:  state.traceID,
+		})
+
 		done, evalErr := evaluate(result)
 		if evalErr != nil {
 			return nil, evalErr
@@ -78,18 +152,21 @@ func waitWithPolling[T any](ctx context.Context, uid string, pollInterval time.D
 			return result, nil
 		}
 
-		if err := waitForNextPoll(ctx, ticker, operation); err != nil {
+		if err := waitForNextPoll(ctx, nextDelay(), operation); err != nil {
 			return nil, err
 		}
 	}
 }
 
-// waitForNextPoll blocks until the next ticker pulse or context cancellation.
-func waitForNextPoll(ctx context.Context, ticker *time.Ticker, operation Operation) error {
+// waitForNextPoll blocks for delay or until context cancellation.
+func waitForNextPoll(ctx context.Context, delay time.Duration, operation Operation) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
 	select {
 	case <-ctx.Done():
 		return fmt.Errorf("waiting for %s cancelled: %w", operation, ctx.Err())
-	case <-ticker.C:
+	case <-timer.C:
 		return nil
 	}
 }
@@ -104,6 +181,20 @@ func isTransientError(err error) bool {
 		return false
 	}
 
+	// Concurrency limits arrive as body codes on HTTP 200 as well as 429.
+	if errors.Is(err, ErrRateLimited) {
+		return true
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
 	var netErr net.Error
 	if errors.As(err, &netErr) {
 		return netErr.Timeout() || netErr.Temporary()
@@ -116,3 +207,35 @@ func isTransientError(err error) bool {
 	var tempErr temporary
 	return errors.As(err, &tempErr) && tempErr.Temporary()
 }
+
+// retryAfterOf returns the Retry-After hint carried by an APIError, or zero.
+func retryAfterOf(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
+
+// parseRetryAfter reads a Retry-After header given either as seconds or as an HTTP date.
+func parseRetryAfter(header http.Header) time.Duration {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,80 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	client "github.com/hsn0918/doc2x-client"
+	"github.com/hsn0918/doc2x-client/doc2xtest"
+)
+
+func uploadSample(t *testing.T, c client.Client) string {
+	t.Helper()
+	uploaded, err := c.UploadPDF(context.Background(), samplePDF)
+	if err != nil {
+		t.Fatalf("UploadPDF: %v", err)
+	}
+	return uploaded.Data.UID
+}
+
+func TestWaitForParsingHonoursRetryAfter(t *testing.T) {
+	srv := doc2xtest.NewServer()
+	defer srv.Close()
+
+	c := srv.Client()
+	uid := uploadSample(t, c)
+
+	srv.FailNext(client.EndpointParseStatus, doc2xtest.Failure{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"1"}},
+	})
+	started := time.Now()
+	if _, err := c.WaitForParsing(context.Background(), uid, time.Millisecond); err != nil {
+		t.Fatalf("WaitForParsing: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < time.Second {
+		t.Errorf("WaitForParsing returned after %s, before the 1s Retry-After", elapsed)
+	}
+}
+
+func TestWaitForParsingRetriesRateLimitCode(t *testing.T) {
+	srv := doc2xtest.NewServer()
+	defer srv.Close()
+
+	c := srv.Client()
+	uid := uploadSample(t, c)
+
+	// Concurrency limits may come as a body code on HTTP 200.
+	srv.FailNext(client.EndpointParseStatus, doc2xtest.Failure{Code: client.CodeConcurrencyLimit, Msg: "too many tasks"})
+	if _, err := c.WaitForParsing(context.Background(), uid, time.Millisecond); err != nil {
+		t.Fatalf("WaitForParsing: %v", err)
+	}
+}
+
+func TestWaitForParsingUsesContextStrategy(t *testing.T) {
+	srv := doc2xtest.NewServer(doc2xtest.WithParseScript(doc2xtest.Script{Progress: []int{20, 70}}))
+	defer srv.Close()
+
+	c := srv.Client(client.WithPollStrategy(client.FixedPoll(time.Hour)))
+	uid := uploadSample(t, c)
+
+	var states []client.PollState
+	ctx := client.ContextWithPollStrategy(context.Background(), client.PollStrategyFunc(func(state client.PollState) time.Duration {
+		states = append(states, state)
+		return time.Millisecond
+	}))
+	if _, err := c.WaitForParsing(ctx, uid, 5*time.Second); err != nil {
+		t.Fatalf("WaitForParsing: %v", err)
+	}
+
+	if len(states) != 2 {
+		t.Fatalf("strategy consulted %d times, want 2: %+v", len(states), states)
+	}
+	for i, want := range []int{20, 70} {
+		if states[i].Attempt != i+1 || states[i].Progress != want || states[i].Interval != 5*time.Second {
+			t.Errorf("state %d = %+v, want attempt %d, progress %d, interval 5s", i, states[i], i+1, want)
+		}
+	}
+}
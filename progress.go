@@ -0,0 +1,78 @@
+package client
+
+import "time"
+
+// ProgressEventKind classifies events emitted while waiting for long-running tasks.
+type ProgressEventKind string
+
+const (
+	// ProgressEventStatus is emitted when a poll reports a status different from the previous poll.
+	ProgressEventStatus ProgressEventKind = "status"
+	// ProgressEventProgress is emitted for every other successful poll.
+	ProgressEventProgress ProgressEventKind = "progress"
+	// ProgressEventRetry is emitted when a poll failed transiently and will be retried.
+	ProgressEventRetry ProgressEventKind = "retry"
+)
+
+// ProgressEvent reports the intermediate state of WaitForParsing, WaitForConversion and WaitForImageLayout.
+type ProgressEvent struct {
+	Kind      ProgressEventKind
+	Operation Operation
+	UID       string
+	Status    string // Task status reported by the last successful poll
+	Progress  int    // Progress percentage (0-100) when the endpoint reports one
+	Poll      int    // Number of status requests issued so far, including failed ones
+	Retries   int    // Consecutive transient failures, only set on retry events
+	TraceID   string
+	Err       error // Transient error, only set on retry events
+	Time      time.Time
+}
+
+// ProgressFunc receives progress events. It is called synchronously from the polling loop and should return quickly.
+type ProgressFunc func(ProgressEvent)
+
+// WithProgress registers a callback that receives every poll result and transient retry of the Wait* helpers.
+func WithProgress(fn ProgressFunc) Option {
+	return func(c *client) {
+		c.progress = fn
+	}
+}
+
+// taskState is the progress-relevant view of a status response.
+type taskState struct {
+	status   string
+	progress int
+	traceID  string
+}
+
+func parseTaskState(status *StatusResponse) taskState {
+	state := taskState{traceID: status.TraceID}
+	if status.Data != nil {
+		state.status = string(status.Data.Status)
+		state.progress = status.Data.Progress
+	}
+	return state
+}
+
+func convertTaskState(result *ConvertResultResponse) taskState {
+	state := taskState{
+		status:  string(result.Data.Status),
+		traceID: result.TraceID,
+	}
+	if result.Data.Status == ConvertStatusSuccess {
+		state.progress = 100
+	}
+	return state
+}
+
+func imageLayoutTaskState(status *ImageLayoutStatusResponse) taskState {
+	state := taskState{traceID: status.TraceID}
+	if status.Data != nil {
+		state.status = status.Data.Status
+		state.progress = status.Data.Progress
+		if state.status == StatusSuccess {
+			state.progress = 100
+		}
+	}
+	return state
+}
@@ -0,0 +1,124 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	client "github.com/hsn0918/doc2x-client"
+	"github.com/hsn0918/doc2x-client/doc2xtest"
+)
+
+var samplePDF = []byte("%PDF-1.4\n% client test\n")
+
+// eventRecorder collects progress events; the callback may run on the polling goroutine.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []client.ProgressEvent
+}
+
+func (r *eventRecorder) record(ev client.ProgressEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, ev)
+}
+
+func (r *eventRecorder) all() []client.ProgressEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]client.ProgressEvent(nil), r.events...)
+}
+
+func TestWaitForParsingEmitsProgress(t *testing.T) {
+	srv := doc2xtest.NewServer(doc2xtest.WithParseScript(doc2xtest.Script{Progress: []int{10, 60}}))
+	defer srv.Close()
+
+	var rec eventRecorder
+	c := srv.Client(client.WithProgress(rec.record))
+	ctx := context.Background()
+
+	uploaded, err := c.UploadPDF(ctx, samplePDF)
+	if err != nil {
+		t.Fatalf("UploadPDF: %v", err)
+	}
+	uid := uploaded.Data.UID
+
+	srv.FailNext(client.EndpointParseStatus, doc2xtest.Failure{StatusCode: http.StatusServiceUnavailable})
+	if _, err := c.WaitForParsing(ctx, uid, time.Millisecond); err != nil {
+		t.Fatalf("WaitForParsing: %v", err)
+	}
+
+	want := []struct {
+		kind     client.ProgressEventKind
+		status   string
+		progress int
+	}{
+		{client.ProgressEventRetry, "", 0},
+		{client.ProgressEventStatus, client.StatusProcessing, 10},
+		{client.ProgressEventProgress, client.StatusProcessing, 60},
+		{client.ProgressEventStatus, client.StatusSuccess, 100},
+	}
+	events := rec.all()
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, ev := range events {
+		if ev.Kind != want[i].kind || ev.Status != want[i].status || ev.Progress != want[i].progress {
+			t.Errorf("event %d = %s %q %d, want %s %q %d", i, ev.Kind, ev.Status, ev.Progress, want[i].kind, want[i].status, want[i].progress)
+		}
+		if ev.UID != uid || ev.Operation != client.OperationParsing || ev.Poll != i+1 {
+			t.Errorf("event %d = uid %s, operation %s, poll %d", i, ev.UID, ev.Operation, ev.Poll)
+		}
+	}
+	if retry := events[0]; retry.Retries != 1 || retry.Err == nil {
+		t.Errorf("retry event = retries %d, err %v", retry.Retries, retry.Err)
+	}
+	if events[3].TraceID == "" {
+		t.Error("final event has no trace ID")
+	}
+}
+
+func TestWaitForConversionEmitsStatusChanges(t *testing.T) {
+	srv := doc2xtest.NewServer(
+		doc2xtest.WithParseScript(doc2xtest.Script{}),
+		doc2xtest.WithConvertScript(doc2xtest.Script{Progress: []int{0, 0}}),
+	)
+	defer srv.Close()
+
+	var rec eventRecorder
+	c := srv.Client(client.WithProgress(rec.record))
+	ctx := context.Background()
+
+	uploaded, err := c.UploadPDF(ctx, samplePDF)
+	if err != nil {
+		t.Fatalf("UploadPDF: %v", err)
+	}
+	uid := uploaded.Data.UID
+	if _, err := c.WaitForParsing(ctx, uid, time.Millisecond); err != nil {
+		t.Fatalf("WaitForParsing: %v", err)
+	}
+	if _, err := c.ConvertParse(ctx, client.ConvertRequest{UID: uid, To: client.FormatMarkdown}); err != nil {
+		t.Fatalf("ConvertParse: %v", err)
+	}
+	if _, err := c.WaitForConversion(ctx, uid, time.Millisecond); err != nil {
+		t.Fatalf("WaitForConversion: %v", err)
+	}
+
+	var kinds []client.ProgressEventKind
+	for _, ev := range rec.all() {
+		if ev.Operation == client.OperationConversion {
+			kinds = append(kinds, ev.Kind)
+		}
+	}
+	want := []client.ProgressEventKind{client.ProgressEventStatus, client.ProgressEventProgress, client.ProgressEventStatus}
+	if len(kinds) != len(want) {
+		t.Fatalf("conversion events = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("conversion events = %v, want %v", kinds, want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Package render turns Doc2X parse results into local documents without a
+// server round-trip through ConvertParse.
+//
+// HTML output is a single self-contained file: styles are inlined, every page
+// gets an anchor and a link to its preview image, and formulas are kept as
+// \( \) and \[ \] delimited text so KaTeX auto-render (or MathJax) can
+// typeset them when its assets are supplied through Options.Head:
+//
+//	f, _ := os.Create("paper.html")
+//	defer f.Close()
+//	err := render.HTML(f, result.Document(), render.Options{Title: "paper"})
+package render
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+// Options configures HTML rendering.
+type Options struct {
+	// Title is used for the <title> element and the page header. Defaults to "Document".
+	Title string
+	// Head is raw HTML appended to <head>, typically inlined KaTeX CSS and scripts.
+	// It is trusted and written without escaping.
+	Head template.HTML
+}
+
+type htmlPage struct {
+	Number   int
+	Anchor   string
+	Preview  string
+	Width    int
+	Height   int
+	Headings []htmlHeading
+	Body     template.HTML
+}
+
+type htmlHeading struct {
+	Anchor string
+	Level  int
+	Text   string
+}
+
+// HTML writes doc as a standalone HTML document.
+func HTML(w io.Writer, doc *client.Document, opts Options) error {
+	if doc == nil {
+		return fmt.Errorf("render html: document is nil")
+	}
+	if opts.Title == "" {
+		opts.Title = "Document"
+	}
+
+	pages := make([]htmlPage, 0, len(doc.Pages))
+	for _, page := range doc.Pages {
+		pages = append(pages, renderPage(page))
+	}
+
+	if err := pageTemplate.Execute(w, struct {
+		Title string
+		Head  template.HTML
+		Pages []htmlPage
+	}{opts.Title, opts.Head, pages}); err != nil {
+		return fmt.Errorf("render html: %w", err)
+	}
+	return nil
+}
+
+func renderPage(page client.DocumentPage) htmlPage {
+	out := htmlPage{
+		Number:  page.Index + 1,
+		Anchor:  pageAnchor(page.Index),
+		Preview: safeURL(page.URL),
+		Width:   page.Width,
+		Height:  page.Height,
+	}
+
+	var sb strings.Builder
+	for _, block := range page.Blocks {
+		if block.Kind == client.BlockHeading {
+			h := htmlHeading{
+				Anchor: fmt.Sprintf("%s-h%d", out.Anchor, block.Order),
+				Level:  min(max(block.Level, 1), 6),
+				Text:   block.Text,
+			}
+			out.Headings = append(out.Headings, h)
+			fmt.Fprintf(&sb, "<h%d id=\"%s\">%s</h%d>\n", h.Level, h.Anchor, inlineHTML(block.Text, block.Formulas), h.Level)
+			continue
+		}
+		sb.WriteString(blockHTML(block))
+	}
+	out.Body = template.HTML(sb.String())
+	return out
+}
+
+func pageAnchor(index int) string {
+	return fmt.Sprintf("page-%d", index+1)
+}
+
+var pageTemplate = template.Must(template.New("document").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { margin: 0; font: 16px/1.6 -apple-system, "Segoe UI", "Helvetica Neue", "PingFang SC", "Microsoft YaHei", sans-serif; color: #1f2328; background: #f6f8fa; }
+nav { position: fixed; top: 0; bottom: 0; left: 0; width: 240px; overflow-y: auto; padding: 16px; background: #fff; border-right: 1px solid #d0d7de; font-size: 14px; }
+nav ul { list-style: none; margin: 0; padding-left: 12px; }
+nav > ul { padding-left: 0; }
+nav a { color: #0969da; text-decoration: none; }
+main { margin-left: 273px; padding: 24px; max-width: 900px; }
+section.page { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; padding: 16px 32px; margin-bottom: 24px; }
+.page-header { display: flex; justify-content: space-between; color: #57606a; font-size: 13px; border-bottom: 1px solid #d0d7de; margin-bottom: 8px; }
+.page-header a { color: #57606a; }
+table { border-collapse: collapse; margin: 12px 0; }
+th, td { border: 1px solid #d0d7de; padding: 4px 8px; }
+th { background: #f6f8fa; }
+figure { margin: 12px 0; text-align: center; }
+figure img { max-width: 100%; }
+.caption { text-align: center; color: #57606a; font-size: 14px; }
+.math.display { overflow-x: auto; margin: 12px 0; text-align: center; }
+.footnote { font-size: 13px; color: #57606a; }
+pre { background: #f6f8fa; padding: 8px; overflow-x: auto; }
+@media print { nav { display: none; } main { margin: 0; } section.page { break-after: page; border: none; } }
+</style>
+{{.Head}}
+</head>
+<body>
+<nav>
+<strong>{{.Title}}</strong>
+<ul>
+{{- range .Pages}}
+<li><a href="#{{.Anchor}}">Page {{.Number}}</a>
+{{- if .Headings}}
+<ul>
+{{- range .Headings}}
+<li><a href="#{{.Anchor}}">{{.Text}}</a></li>
+{{- end}}
+</ul>
+{{- end}}
+</li>
+{{- end}}
+</ul>
+</nav>
+<main>
+{{- range .Pages}}
+<section class="page" id="{{.Anchor}}">
+<div class="page-header"><a href="#{{.Anchor}}">Page {{.Number}}</a>{{if .Preview}}<a href="{{.Preview}}" target="_blank" rel="noopener">Page preview ({{.Width}}×{{.Height}})</a>{{end}}</div>
+{{.Body}}
+</section>
+{{- end}}
+</main>
+</body>
+</html>
+`))
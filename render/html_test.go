@@ -0,0 +1,67 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+func TestHTML(t *testing.T) {
+	result := &client.ParseResult{Pages: []client.ParsePage{
+		{PageIdx: 0, PageWidth: 1000, PageHeight: 1400, URL: "https://cdn.example/p0.png", Md: "# Intro <b>\n\ntext one\n\n### Details"},
+		{PageIdx: 1, PageWidth: 1000, PageHeight: 1400, URL: "javascript:alert(1)", Md: "####### not a heading"},
+	}}
+
+	var sb strings.Builder
+	err := HTML(&sb, result.Document(), Options{
+		Title: "Paper </title><script>",
+		Head:  `<script src="katex.js"></script>`,
+	})
+	if err != nil {
+		t.Fatalf("HTML: %v", err)
+	}
+	out := sb.String()
+
+	for _, want := range []string{
+		"<title>Paper &lt;/title&gt;&lt;script&gt;</title>",
+		`<script src="katex.js"></script>` + "\n</head>",
+		// Navigation lists every page with its headings nested below.
+		`<li><a href="#page-1">Page 1</a>` + "\n<ul>\n" +
+			`<li><a href="#page-1-h0">Intro &lt;b&gt;</a></li>` + "\n" +
+			`<li><a href="#page-1-h2">Details</a></li>` + "\n</ul>\n</li>",
+		`<li><a href="#page-2">Page 2</a>` + "\n</li>",
+		// Each page is a section with a header linking to itself and its preview.
+		`<section class="page" id="page-1">`,
+		`<a href="#page-1">Page 1</a><a href="https://cdn.example/p0.png" target="_blank" rel="noopener">Page preview (1000×1400)</a></div>`,
+		`<h1 id="page-1-h0">Intro &lt;b&gt;</h1>`,
+		`<h3 id="page-1-h2">Details</h3>`,
+		"<p>text one</p>",
+		`<section class="page" id="page-2">` + "\n" + `<div class="page-header"><a href="#page-2">Page 2</a></div>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q", want)
+		}
+	}
+	if strings.Contains(out, "javascript:") {
+		t.Error("unsafe preview URL reached the output")
+	}
+	if n := len(regexp.MustCompile(`<section class="page"`).FindAllString(out, -1)); n != 2 {
+		t.Errorf("rendered %d page sections, want 2", n)
+	}
+}
+
+func TestHTMLDefaults(t *testing.T) {
+	var sb strings.Builder
+	if err := HTML(&sb, &client.Document{}, Options{}); err != nil {
+		t.Fatalf("HTML: %v", err)
+	}
+	if !strings.Contains(sb.String(), "<title>Document</title>") {
+		t.Error("empty title did not default to Document")
+	}
+
+	if err := HTML(&sb, nil, Options{}); err == nil {
+		t.Error("nil document rendered without error")
+	}
+}
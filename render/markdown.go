@@ -0,0 +1,176 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+// blockHTML renders a non-heading block. Source HTML from the page Markdown is never passed
+// through: tables are rebuilt from their parsed cells and everything else is escaped.
+func blockHTML(block client.Block) string {
+	switch block.Kind {
+	case client.BlockTable:
+		return tableHTML(block.Text)
+	case client.BlockFormula:
+		return formulaHTML(block)
+	case client.BlockImage:
+		return imageHTML(block.Image)
+	case client.BlockCaption:
+		return fmt.Sprintf("<p class=\"caption\">%s</p>\n", inlineHTML(block.Text, block.Formulas))
+	case client.BlockFootnote:
+		return fmt.Sprintf("<p class=\"footnote\" id=\"fn-%d-%s\"><sup>%s</sup> %s</p>\n",
+			block.Page+1, html.EscapeString(block.Label), html.EscapeString(block.Label), inlineHTML(block.Text, block.Formulas))
+	default:
+		return fmt.Sprintf("<p>%s</p>\n", inlineHTML(block.Text, block.Formulas))
+	}
+}
+
+func tableHTML(text string) string {
+	table, err := client.ParseTable(text)
+	if err != nil {
+		return fmt.Sprintf("<pre>%s</pre>\n", html.EscapeString(text))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<table>\n")
+	if len(table.Header) > 0 {
+		sb.WriteString("<thead><tr>")
+		for _, cell := range table.Header {
+			fmt.Fprintf(&sb, "<th>%s</th>", cellHTML(cell))
+		}
+		sb.WriteString("</tr></thead>\n")
+	}
+	sb.WriteString("<tbody>\n")
+	for _, row := range table.Rows {
+		sb.WriteString("<tr>")
+		for _, cell := range row {
+			fmt.Fprintf(&sb, "<td>%s</td>", cellHTML(cell))
+		}
+		sb.WriteString("</tr>\n")
+	}
+	sb.WriteString("</tbody>\n</table>\n")
+	return sb.String()
+}
+
+// cellHTML renders a table cell, which may itself contain inline formulas.
+func cellHTML(cell string) string {
+	blocks := client.ParseMarkdown(0, cell)
+	if len(blocks) != 1 {
+		return html.EscapeString(cell)
+	}
+	return inlineHTML(blocks[0].Text, blocks[0].Formulas)
+}
+
+func formulaHTML(block client.Block) string {
+	if len(block.Formulas) == 0 {
+		return fmt.Sprintf("<div class=\"math display\">%s</div>\n", html.EscapeString(block.Text))
+	}
+
+	var sb strings.Builder
+	for _, f := range block.Formulas {
+		fmt.Fprintf(&sb, "<div class=\"math display\">%s</div>\n", html.EscapeString(f.Render(client.FormulaModeNormal)))
+	}
+	return sb.String()
+}
+
+func imageHTML(ref *client.ImageRef) string {
+	if ref == nil || safeURL(ref.URL) == "" {
+		return ""
+	}
+	return fmt.Sprintf("<figure><img src=\"%s\" alt=\"%s\" loading=\"lazy\"></figure>\n",
+		html.EscapeString(safeURL(ref.URL)), html.EscapeString(ref.Alt))
+}
+
+// inlineHTML escapes text and applies inline Markdown, emitting formulas as \( \) or \[ \]
+// delimited math spans. Formula offsets are relative to text.
+func inlineHTML(text string, formulas []client.Formula) string {
+	var (
+		sb   strings.Builder
+		last int
+	)
+	for _, f := range formulas {
+		if f.Offset < last || f.Offset+len(f.Raw) > len(text) {
+			continue
+		}
+		sb.WriteString(inlineMarkdown(text[last:f.Offset]))
+		class := "math inline"
+		if f.Display {
+			class = "math display"
+		}
+		fmt.Fprintf(&sb, "<span class=\"%s\">%s</span>", class, html.EscapeString(f.Render(client.FormulaModeNormal)))
+		last = f.Offset + len(f.Raw)
+	}
+	sb.WriteString(inlineMarkdown(text[last:]))
+	return sb.String()
+}
+
+var (
+	codeSpanPattern    = regexp.MustCompile("`([^`]+)`")
+	inlineImagePattern = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+	linkPattern        = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	footnoteRefPattern = regexp.MustCompile(`\[\^([^\]]+)\]`)
+	strongPattern      = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	emphasisPattern    = regexp.MustCompile(`\*([^*\s][^*]*)\*`)
+)
+
+// inlineMarkdown renders code spans, images, links, footnote references and emphasis.
+// Input is escaped first, so the patterns operate on HTML-safe text.
+func inlineMarkdown(text string) string {
+	parts := codeSpanPattern.Split(text, -1)
+	codes := codeSpanPattern.FindAllStringSubmatch(text, -1)
+
+	var sb strings.Builder
+	for i, part := range parts {
+		sb.WriteString(inlineSpans(part))
+		if i < len(codes) {
+			fmt.Fprintf(&sb, "<code>%s</code>", html.EscapeString(codes[i][1]))
+		}
+	}
+	return strings.ReplaceAll(sb.String(), "\n", "<br>\n")
+}
+
+func inlineSpans(text string) string {
+	text = html.EscapeString(text)
+	text = inlineImagePattern.ReplaceAllStringFunc(text, func(m string) string {
+		sub := inlineImagePattern.FindStringSubmatch(m)
+		src := safeURL(html.UnescapeString(sub[2]))
+		if src == "" {
+			return sub[1]
+		}
+		return fmt.Sprintf("<img src=\"%s\" alt=\"%s\">", html.EscapeString(src), sub[1])
+	})
+	text = footnoteRefPattern.ReplaceAllString(text, `<sup class="footnote-ref">$1</sup>`)
+	text = linkPattern.ReplaceAllStringFunc(text, func(m string) string {
+		sub := linkPattern.FindStringSubmatch(m)
+		href := safeURL(html.UnescapeString(sub[2]))
+		if href == "" {
+			return sub[1]
+		}
+		return fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(href), sub[1])
+	})
+	text = strongPattern.ReplaceAllString(text, "<strong>$1</strong>")
+	return emphasisPattern.ReplaceAllString(text, "<em>$1</em>")
+}
+
+// safeURL returns u when it is an http(s), relative or fragment URL, and "" otherwise.
+func safeURL(u string) string {
+	u = strings.TrimSpace(u)
+	if u == "" {
+		return ""
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "", "http", "https":
+		return u
+	default:
+		return ""
+	}
+}
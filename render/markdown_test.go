@@ -0,0 +1,104 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	client "github.com/hsn0918/doc2x-client"
+)
+
+func TestSafeURL(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"https://cdn.example/a.png", "https://cdn.example/a.png"},
+		{"HTTP://cdn.example/a.png", "HTTP://cdn.example/a.png"},
+		{" images/0.png ", "images/0.png"},
+		{"#page-2", "#page-2"},
+		{"javascript:alert(1)", ""},
+		{"JavaScript:alert(1)", ""},
+		{"data:text/html,<script>alert(1)</script>", ""},
+		{"vbscript:msgbox", ""},
+		{"file:///etc/passwd", ""},
+		{"%zz", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := safeURL(tt.in); got != tt.want {
+			t.Errorf("safeURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBlockHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{
+			name: "paragraph is escaped",
+			md:   `a <script>alert("x")</script> & b`,
+			want: "<p>a &lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; b</p>\n",
+		},
+		{
+			name: "inline markdown",
+			md:   "**bold** *em* `<code>` [link](https://example.com/?a=1&b=2) note[^1]",
+			want: "<p><strong>bold</strong> <em>em</em> <code>&lt;code&gt;</code> " +
+				"<a href=\"https://example.com/?a=1&amp;b=2\">link</a> note<sup class=\"footnote-ref\">1</sup></p>\n",
+		},
+		{
+			name: "javascript link keeps only its text",
+			md:   "[click](javascript:location='//evil.example') and [ok](#page-1)",
+			want: "<p>click and <a href=\"#page-1\">ok</a></p>\n",
+		},
+		{
+			name: "inline image with unsafe source keeps its alt text",
+			md:   "see ![chart](data:image/svg+xml,x) and ![logo](logo.png)",
+			want: "<p>see chart and <img src=\"logo.png\" alt=\"logo\"></p>\n",
+		},
+		{
+			name: "inline formula",
+			md:   `mass \(E=mc^2 < x\) here`,
+			want: "<p>mass <span class=\"math inline\">\\(E=mc^2 &lt; x\\)</span> here</p>\n",
+		},
+		{
+			name: "display formula",
+			md:   `$$a<b$$`,
+			want: "<div class=\"math display\">\\[a&lt;b\\]</div>\n",
+		},
+		{
+			name: "image block",
+			md:   `![fig "1"](https://cdn.example/1.png)`,
+			want: "<figure><img src=\"https://cdn.example/1.png\" alt=\"fig &#34;1&#34;\" loading=\"lazy\"></figure>\n",
+		},
+		{
+			name: "unsafe image block is dropped",
+			md:   `<img src="javascript:alert(1)" alt="x">`,
+			want: "",
+		},
+		{
+			name: "table is rebuilt from cells",
+			md:   "| a | <b>b</b> |\n|---|---|\n| \\(x\\) | <i onclick=\"x\">2</i> |",
+			want: "<table>\n<thead><tr><th>a</th><th>&lt;b&gt;b&lt;/b&gt;</th></tr></thead>\n<tbody>\n" +
+				"<tr><td><span class=\"math inline\">\\(x\\)</span></td><td>&lt;i onclick=&#34;x&#34;&gt;2&lt;/i&gt;</td></tr>\n</tbody>\n</table>\n",
+		},
+		{
+			name: "footnote",
+			md:   "[^a<]: the <note>",
+			want: "<p class=\"footnote\" id=\"fn-1-a&lt;\"><sup>a&lt;</sup> the &lt;note&gt;</p>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := client.ParseMarkdown(0, tt.md)
+			var sb strings.Builder
+			for _, block := range blocks {
+				sb.WriteString(blockHTML(block))
+			}
+			if got := sb.String(); got != tt.want {
+				t.Errorf("blocks %+v rendered\n%q\nwant\n%q", blocks, got, tt.want)
+			}
+		})
+	}
+}
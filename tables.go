@@ -0,0 +1,391 @@
+package client
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Table is a table extracted from a parse result.
+type Table struct {
+	Page    int        `json:"page"`              // Page index the table starts on
+	EndPage int        `json:"end_page"`          // Page index the table ends on; differs from Page for stitched tables
+	Order   int        `json:"order"`             // Block order of the table on its first page
+	Caption string     `json:"caption,omitempty"` // Adjacent caption, if any
+	Header  []string   `json:"header,omitempty"`
+	Rows    [][]string `json:"rows"`
+
+	// StartsPage and EndsPage report whether the table is the first or last content block on its page.
+	// StitchTables uses them to detect tables split by a page break.
+	StartsPage bool `json:"-"`
+	EndsPage   bool `json:"-"`
+}
+
+// Columns returns the number of columns of the widest row or header.
+func (t Table) Columns() int {
+	n := len(t.Header)
+	for _, row := range t.Rows {
+		n = max(n, len(row))
+	}
+	return n
+}
+
+// WriteCSV writes the header (when present) and rows as CSV.
+func (t Table) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if len(t.Header) > 0 {
+		if err := cw.Write(t.Header); err != nil {
+			return fmt.Errorf("write csv header: %w", err)
+		}
+	}
+	if err := cw.WriteAll(t.Rows); err != nil {
+		return fmt.Errorf("write csv rows: %w", err)
+	}
+	return nil
+}
+
+// WriteTablesJSON writes tables as an indented JSON array.
+func WriteTablesJSON(w io.Writer, tables []Table) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if tables == nil {
+		tables = []Table{}
+	}
+	if err := enc.Encode(tables); err != nil {
+		return fmt.Errorf("write tables json: %w", err)
+	}
+	return nil
+}
+
+// ExtractTables returns every pipe or HTML table of a parse result in reading order.
+// Tables that cannot be parsed are skipped.
+func ExtractTables(result *ParseResult) []Table {
+	if result == nil {
+		return nil
+	}
+
+	var tables []Table
+	for _, page := range result.Document().Pages {
+		content := contentBlocks(page.Blocks)
+		for i, block := range content {
+			if block.Kind != BlockTable {
+				continue
+			}
+
+			table, err := ParseTable(block.Text)
+			if err != nil {
+				continue
+			}
+			table.Page = page.Index
+			table.EndPage = page.Index
+			table.Order = block.Order
+			table.StartsPage = i == 0 || (i == 1 && content[0].Kind == BlockCaption)
+			table.EndsPage = i == len(content)-1
+			table.Caption = adjacentCaption(content, i)
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// contentBlocks drops footnotes, which sit below page content and would hide a trailing table.
+func contentBlocks(blocks []Block) []Block {
+	out := make([]Block, 0, len(blocks))
+	for _, b := range blocks {
+		if b.Kind != BlockFootnote {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+func adjacentCaption(blocks []Block, i int) string {
+	if i > 0 && blocks[i-1].Kind == BlockCaption {
+		return blocks[i-1].Text
+	}
+	if i+1 < len(blocks) && blocks[i+1].Kind == BlockCaption {
+		return blocks[i+1].Text
+	}
+	return ""
+}
+
+// StitchTables merges tables continued across a page break, similar to the server-side
+// MergeCrossPageForms option: a table ending its page is joined with a table starting the
+// next page when both have the same column count. A repeated header on the continuation is dropped.
+func StitchTables(tables []Table) []Table {
+	var out []Table
+	for _, t := range tables {
+		if n := len(out); n > 0 && continues(out[n-1], t) {
+			prev := &out[n-1]
+			if len(t.Header) > 0 && !slices.Equal(t.Header, prev.Header) {
+				prev.Rows = append(prev.Rows, t.Header)
+			}
+			prev.Rows = append(prev.Rows, t.Rows...)
+			prev.EndPage = t.EndPage
+			prev.EndsPage = t.EndsPage
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func continues(prev, next Table) bool {
+	return prev.EndsPage && next.StartsPage && next.Page == prev.EndPage+1 && next.Caption == "" &&
+		prev.Columns() == next.Columns()
+}
+
+// ParseTable parses a Markdown pipe table or an HTML <table>, expanding rowspan and colspan.
+func ParseTable(text string) (Table, error) {
+	text = strings.TrimSpace(text)
+	if hasPrefixFold(text, "<table") {
+		return parseHTMLTable(text)
+	}
+	if strings.HasPrefix(text, "|") {
+		return parsePipeTable(text)
+	}
+	return Table{}, fmt.Errorf("unrecognised table markup")
+}
+
+func parsePipeTable(text string) (Table, error) {
+	lines := strings.Split(text, "\n")
+	if len(lines) < 2 || !pipeSeparatorPattern.MatchString(strings.TrimSpace(lines[1])) {
+		return Table{}, fmt.Errorf("pipe table is missing its separator row")
+	}
+
+	t := Table{Header: splitPipeRow(lines[0])}
+	for _, line := range lines[2:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		t.Rows = append(t.Rows, splitPipeRow(line))
+	}
+	padTable(&t)
+	return t, nil
+}
+
+func splitPipeRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var (
+		cells []string
+		cell  strings.Builder
+	)
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// htmlCell is a cell awaiting placement in the table grid.
+type htmlCell struct {
+	text    string
+	rowspan int
+	colspan int
+}
+
+type htmlRow struct {
+	cells  []htmlCell
+	header bool
+}
+
+func parseHTMLTable(text string) (Table, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(text), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return Table{}, fmt.Errorf("parse html table: %w", err)
+	}
+
+	var table *html.Node
+	for _, n := range nodes {
+		if table = findElement(n, atom.Table); table != nil {
+			break
+		}
+	}
+	if table == nil {
+		return Table{}, fmt.Errorf("html table not found")
+	}
+
+	var rows []htmlRow
+	collectRows(table, false, &rows)
+	if len(rows) == 0 {
+		return Table{}, fmt.Errorf("html table has no rows")
+	}
+
+	grid := layoutGrid(rows)
+
+	var t Table
+	headerRows := 0
+	for headerRows < len(rows) && rows[headerRows].header {
+		headerRows++
+	}
+	if headerRows > 0 && headerRows < len(rows) {
+		t.Header = mergeHeaderRows(grid[:headerRows])
+		t.Rows = grid[headerRows:]
+	} else {
+		t.Rows = grid
+	}
+	padTable(&t)
+	return t, nil
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// collectRows gathers <tr> rows of table, skipping nested tables. Rows inside <thead> or made
+// only of <th> cells are marked as header rows.
+func collectRows(n *html.Node, inHead bool, rows *[]htmlRow) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		switch c.DataAtom {
+		case atom.Thead:
+			collectRows(c, true, rows)
+		case atom.Tbody, atom.Tfoot:
+			collectRows(c, false, rows)
+		case atom.Tr:
+			row := htmlRow{header: inHead}
+			allTH := true
+			for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type != html.ElementNode || (cell.DataAtom != atom.Td && cell.DataAtom != atom.Th) {
+					continue
+				}
+				allTH = allTH && cell.DataAtom == atom.Th
+				row.cells = append(row.cells, htmlCell{
+					text:    cellText(cell),
+					rowspan: spanAttr(cell, "rowspan"),
+					colspan: spanAttr(cell, "colspan"),
+				})
+			}
+			row.header = row.header || (allTH && len(row.cells) > 0)
+			*rows = append(*rows, row)
+		}
+	}
+}
+
+func spanAttr(n *html.Node, name string) int {
+	for _, attr := range n.Attr {
+		if strings.EqualFold(attr.Key, name) {
+			if v, err := strconv.Atoi(strings.TrimSpace(attr.Val)); err == nil && v > 0 {
+				return min(v, 1000)
+			}
+		}
+	}
+	return 1
+}
+
+// cellText returns the visible text of a cell with whitespace collapsed.
+func cellText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+		case n.Type == html.ElementNode && n.DataAtom == atom.Br:
+			sb.WriteByte(' ')
+		case n.Type == html.ElementNode && n.DataAtom == atom.Table:
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// layoutGrid places cells into a rectangular grid, repeating spanned cell text in every covered slot.
+func layoutGrid(rows []htmlRow) [][]string {
+	grid := make([][]string, len(rows))
+	filled := make([][]bool, len(rows))
+
+	place := func(r, c int, text string) {
+		for len(grid[r]) <= c {
+			grid[r] = append(grid[r], "")
+			filled[r] = append(filled[r], false)
+		}
+		grid[r][c] = text
+		filled[r][c] = true
+	}
+
+	for r, row := range rows {
+		col := 0
+		for _, cell := range row.cells {
+			for col < len(filled[r]) && filled[r][col] {
+				col++
+			}
+			for dr := 0; dr < cell.rowspan && r+dr < len(rows); dr++ {
+				for dc := 0; dc < cell.colspan; dc++ {
+					place(r+dr, col+dc, cell.text)
+				}
+			}
+			col += cell.colspan
+		}
+	}
+	return grid
+}
+
+// mergeHeaderRows folds multi-row headers into one, joining distinct values per column with " / ".
+func mergeHeaderRows(rows [][]string) []string {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+
+	header := make([]string, width)
+	for c := range header {
+		var parts []string
+		for _, row := range rows {
+			if c < len(row) && row[c] != "" && !slices.Contains(parts, row[c]) {
+				parts = append(parts, row[c])
+			}
+		}
+		header[c] = strings.Join(parts, " / ")
+	}
+	return header
+}
+
+// padTable extends short rows so every row has the same number of cells.
+func padTable(t *Table) {
+	width := t.Columns()
+	if len(t.Header) > 0 {
+		for len(t.Header) < width {
+			t.Header = append(t.Header, "")
+		}
+	}
+	for i := range t.Rows {
+		for len(t.Rows[i]) < width {
+			t.Rows[i] = append(t.Rows[i], "")
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestParsePipeTable(t *testing.T) {
+	table, err := ParseTable("| name | note |\n| :--- | ---: |\n| a \\| b | x |\n| c |\n")
+	if err != nil {
+		t.Fatalf("ParseTable: %v", err)
+	}
+
+	wantHeader := []string{"name", "note"}
+	wantRows := [][]string{{"a | b", "x"}, {"c", ""}}
+	if !reflect.DeepEqual(table.Header, wantHeader) || !reflect.DeepEqual(table.Rows, wantRows) {
+		t.Errorf("table = %q / %q, want %q / %q", table.Header, table.Rows, wantHeader, wantRows)
+	}
+
+	if _, err := ParseTable("| a | b |\n| 1 | 2 |"); err == nil {
+		t.Error("pipe table without separator row parsed without error")
+	}
+	if _, err := ParseTable("plain text"); err == nil {
+		t.Error("text without table markup parsed without error")
+	}
+}
+
+func TestParseHTMLTableSpans(t *testing.T) {
+	table, err := ParseTable(`<table>
+<thead>
+<tr><th rowspan="2">Model</th><th colspan="2">Score</th></tr>
+<tr><th>dev</th><th>test</th></tr>
+</thead>
+<tbody>
+<tr><td rowspan="2">base<br>v1</td><td>1</td><td>2</td></tr>
+<tr><td>3</td><td>4</td></tr>
+</tbody>
+</table>`)
+	if err != nil {
+		t.Fatalf("ParseTable: %v", err)
+	}
+
+	wantHeader := []string{"Model", "Score / dev", "Score / test"}
+	wantRows := [][]string{{"base v1", "1", "2"}, {"base v1", "3", "4"}}
+	if !reflect.DeepEqual(table.Header, wantHeader) {
+		t.Errorf("header = %q, want %q", table.Header, wantHeader)
+	}
+	if !reflect.DeepEqual(table.Rows, wantRows) {
+		t.Errorf("rows = %q, want %q", table.Rows, wantRows)
+	}
+}
+
+func TestParseHTMLTableWithoutHeader(t *testing.T) {
+	table, err := ParseTable("<table><tr><td>a</td><td>b</td></tr><tr><td>c</td></tr></table>")
+	if err != nil {
+		t.Fatalf("ParseTable: %v", err)
+	}
+	if table.Header != nil {
+		t.Errorf("header = %q, want none", table.Header)
+	}
+	if want := [][]string{{"a", "b"}, {"c", ""}}; !reflect.DeepEqual(table.Rows, want) {
+		t.Errorf("rows = %q, want %q", table.Rows, want)
+	}
+}
+
+func TestExtractAndStitchTables(t *testing.T) {
+	result := &ParseResult{Pages: []ParsePage{
+		{PageIdx: 0, Md: "Intro text.\n\nTable 1: results\n\n| k | v |\n|---|---|\n| a | 1 |\n\n[^1]: footnotes do not end the page content"},
+		{PageIdx: 1, Md: "| k | v |\n|---|---|\n| b | 2 |\n\nAfter the table."},
+		{PageIdx: 2, Md: "| x | y | z |\n|---|---|---|\n| 1 | 2 | 3 |"},
+	}}
+
+	tables := ExtractTables(result)
+	if len(tables) != 3 {
+		t.Fatalf("extracted %d tables, want 3", len(tables))
+	}
+	first := tables[0]
+	if first.Caption != "Table 1: results" || first.StartsPage || !first.EndsPage || first.Order != 2 {
+		t.Errorf("first table = %+v", first)
+	}
+	if second := tables[1]; !second.StartsPage || second.EndsPage {
+		t.Errorf("second table = %+v", second)
+	}
+
+	stitched := StitchTables(tables)
+	if len(stitched) != 2 {
+		t.Fatalf("stitched into %d tables, want 2", len(stitched))
+	}
+	merged := stitched[0]
+	if merged.Page != 0 || merged.EndPage != 1 {
+		t.Errorf("merged table spans pages %d-%d, want 0-1", merged.Page, merged.EndPage)
+	}
+	// The repeated header of the continuation is dropped.
+	if want := [][]string{{"a", "1"}, {"b", "2"}}; !reflect.DeepEqual(merged.Rows, want) {
+		t.Errorf("merged rows = %q, want %q", merged.Rows, want)
+	}
+	// A different column count is not a continuation.
+	if stitched[1].Page != 2 || stitched[1].Columns() != 3 {
+		t.Errorf("last table = %+v", stitched[1])
+	}
+}
+
+func TestStitchTablesKeepsDifferentHeaderAsRow(t *testing.T) {
+	tables := []Table{
+		{Page: 0, EndPage: 0, EndsPage: true, Header: []string{"k", "v"}, Rows: [][]string{{"a", "1"}}},
+		{Page: 1, EndPage: 1, StartsPage: true, Header: []string{"b", "2"}, Rows: [][]string{{"c", "3"}}},
+	}
+
+	stitched := StitchTables(tables)
+	if len(stitched) != 1 {
+		t.Fatalf("stitched into %d tables, want 1", len(stitched))
+	}
+	if want := [][]string{{"a", "1"}, {"b", "2"}, {"c", "3"}}; !reflect.DeepEqual(stitched[0].Rows, want) {
+		t.Errorf("rows = %q, want %q", stitched[0].Rows, want)
+	}
+}
+
+func TestTableWriteCSV(t *testing.T) {
+	table := Table{Header: []string{"a", "b"}, Rows: [][]string{{"1", "x,y"}}}
+
+	var buf bytes.Buffer
+	if err := table.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	if got, want := buf.String(), "a,b\n1,\"x,y\"\n"; got != want {
+		t.Errorf("csv = %q, want %q", got, want)
+	}
+}
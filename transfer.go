@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// transferProgressInterval throttles TransferProgress callbacks; the final event is always delivered.
+const transferProgressInterval = 200 * time.Millisecond
+
+// TransferProgress reports the state of an upload or download.
+type TransferProgress struct {
+	Operation Operation
+	Bytes     int64         // Bytes transferred so far
+	Total     int64         // Expected size in bytes, or -1 when unknown
+	Rate      float64       // Average throughput in bytes per second since the transfer started
+	ETA       time.Duration // Estimated time left, 0 when Total or Rate is unknown
+	Elapsed   time.Duration
+	Done      bool // Set on the last event of a transfer, whether it succeeded or not
+	Time      time.Time
+}
+
+// Percent returns the completed percentage (0-100), or -1 when Total is unknown.
+func (p TransferProgress) Percent() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+	return float64(p.Bytes) * 100 / float64(p.Total)
+}
+
+// TransferProgressFunc receives transfer progress. It is called from the goroutine reading the body
+// and should return quickly.
+type TransferProgressFunc func(TransferProgress)
+
+// WithTransferProgress registers a callback that receives the progress of UploadPDFReader,
+// UploadToPresignedURLFrom, DownloadFileTo and the methods built on them.
+func WithTransferProgress(fn TransferProgressFunc) Option {
+	return func(c *client) {
+		c.transferProgress = fn
+	}
+}
+
+type (
+	transferProgressKey struct{}
+	uploadSizeKey       struct{}
+)
+
+// ContextWithTransferProgress overrides the transfer progress callback for calls made with the
+// returned context, such as one progress bar per file in a batch.
+func ContextWithTransferProgress(ctx context.Context, fn TransferProgressFunc) context.Context {
+	return context.WithValue(ctx, transferProgressKey{}, fn)
+}
+
+// transferProgressFrom resolves the callback for a call: context first, then the client default.
+func transferProgressFrom(ctx context.Context, fallback TransferProgressFunc) TransferProgressFunc {
+	if fn, ok := ctx.Value(transferProgressKey{}).(TransferProgressFunc); ok && fn != nil {
+		return fn
+	}
+	return fallback
+}
+
+// contextWithUploadSize passes the known body size to the upload methods when the reader hides it.
+func contextWithUploadSize(ctx context.Context, size int64) context.Context {
+	return context.WithValue(ctx, uploadSizeKey{}, size)
+}
+
+// uploadSize returns the size of r, or -1 when neither the context nor the reader reveal it.
+func uploadSize(ctx context.Context, r io.Reader) int64 {
+	if size, ok := ctx.Value(uploadSizeKey{}).(int64); ok && size > 0 {
+		return size
+	}
+
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return int64(v.Len())
+	case *os.File:
+		info, err := v.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - offset
+	}
+	return -1
+}
+
+// progressReader reports the bytes read through it.
+type progressReader struct {
+	r         io.Reader
+	fn        TransferProgressFunc
+	operation Operation
+	total     int64
+	started   time.Time
+
+	mu       sync.Mutex
+	bytes    int64
+	reported time.Time
+	done     bool
+}
+
+func newProgressReader(r io.Reader, fn TransferProgressFunc, operation Operation, total int64) *progressReader {
+	now := time.Now()
+	return &progressReader{r: r, fn: fn, operation: operation, total: total, started: now, reported: now}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.bytes += int64(n)
+	now := time.Now()
+	if err == io.EOF {
+		p.reportLocked(now, true)
+	} else if now.Sub(p.reported) >= transferProgressInterval {
+		p.reportLocked(now, false)
+	}
+	return n, err
+}
+
+// finish delivers the final event if the body was not read to EOF, e.g. after a failed request.
+func (p *progressReader) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reportLocked(time.Now(), true)
+}
+
+func (p *progressReader) reportLocked(now time.Time, done bool) {
+	if p.done {
+		return
+	}
+	p.done, p.reported = done, now
+
+	elapsed := now.Sub(p.started)
+	ev := TransferProgress{
+		Operation: p.operation,
+		Bytes:     p.bytes,
+		Total:     p.total,
+		Elapsed:   elapsed,
+		Done:      done,
+		Time:      now,
+	}
+	if elapsed > 0 {
+		ev.Rate = float64(p.bytes) / elapsed.Seconds()
+	}
+	if p.total > 0 && ev.Rate > 0 && p.bytes < p.total {
+		ev.ETA = time.Duration(float64(p.total-p.bytes) / ev.Rate * float64(time.Second))
+	}
+	p.fn(ev)
+}
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestTransferProgressPercent(t *testing.T) {
+	tests := []struct {
+		bytes, total int64
+		want         float64
+	}{
+		{50, 200, 25},
+		{200, 200, 100},
+		{0, 200, 0},
+		{50, -1, -1},
+		{50, 0, -1},
+	}
+	for _, tt := range tests {
+		if got := (TransferProgress{Bytes: tt.bytes, Total: tt.total}).Percent(); got != tt.want {
+			t.Errorf("Percent of %d/%d = %v, want %v", tt.bytes, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestUploadSize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.pdf")
+	if err := os.WriteFile(path, []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.Seek(3, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	dirFile, err := os.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dirFile.Close()
+
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		ctx  context.Context
+		r    io.Reader
+		want int64
+	}{
+		{"reader with Len", ctx, bytes.NewReader(make([]byte, 42)), 42},
+		{"file from its offset", ctx, file, 7},
+		{"directory", ctx, dirFile, -1},
+		{"opaque reader", ctx, io.MultiReader(bytes.NewReader(make([]byte, 42))), -1},
+		{"size from context", contextWithUploadSize(ctx, 99), io.MultiReader(), 99},
+		{"context wins over Len", contextWithUploadSize(ctx, 99), bytes.NewReader(make([]byte, 42)), 99},
+		{"zero context size ignored", contextWithUploadSize(ctx, 0), bytes.NewReader(make([]byte, 42)), 42},
+	}
+	for _, tt := range tests {
+		if got := uploadSize(tt.ctx, tt.r); got != tt.want {
+			t.Errorf("%s: uploadSize = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// readAllForcingEvents reads p in small chunks, backdating the last report before every read so
+// each read is due an event.
+func readAllForcingEvents(t *testing.T, p *progressReader) {
+	t.Helper()
+	buf := make([]byte, 10)
+	for {
+		p.mu.Lock()
+		p.reported = p.reported.Add(-transferProgressInterval)
+		p.mu.Unlock()
+		_, err := p.Read(buf)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestProgressReader(t *testing.T) {
+	data := make([]byte, 95)
+	var events []TransferProgress
+	p := newProgressReader(bytes.NewReader(data), func(ev TransferProgress) { events = append(events, ev) }, OperationUploadPDF, int64(len(data)))
+
+	readAllForcingEvents(t, p)
+	p.finish() // Already reported at EOF, so no second final event.
+
+	if len(events) < 3 {
+		t.Fatalf("got %d events, want intermediate events and a final one", len(events))
+	}
+	var last int64
+	for i, ev := range events {
+		if ev.Bytes < last {
+			t.Errorf("event %d went back from %d to %d bytes", i, last, ev.Bytes)
+		}
+		last = ev.Bytes
+		if ev.Operation != OperationUploadPDF || ev.Total != int64(len(data)) || ev.Time.IsZero() {
+			t.Errorf("event %d = %+v", i, ev)
+		}
+		if final := i == len(events)-1; ev.Done != final {
+			t.Errorf("event %d Done = %v, want %v", i, ev.Done, final)
+		}
+		if ev.Bytes > 0 && ev.Bytes < ev.Total && ev.ETA <= 0 {
+			t.Errorf("event %d at %d/%d bytes has no ETA", i, ev.Bytes, ev.Total)
+		}
+	}
+	final := events[len(events)-1]
+	if final.Bytes != int64(len(data)) || final.Percent() != 100 || final.ETA != 0 {
+		t.Errorf("final event = %+v, want all bytes and no ETA", final)
+	}
+}
+
+func TestProgressReaderThrottles(t *testing.T) {
+	var events []TransferProgress
+	p := newProgressReader(iotest.OneByteReader(bytes.NewReader(make([]byte, 100))), func(ev TransferProgress) { events = append(events, ev) }, OperationDownloadFile, 100)
+	p.reported = time.Now().Add(time.Hour) // No interval elapses while reading.
+
+	if _, err := io.Copy(io.Discard, p); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || !events[0].Done || events[0].Bytes != 100 {
+		t.Errorf("events = %+v, want only the final one", events)
+	}
+}
+
+func TestProgressReaderUnknownTotal(t *testing.T) {
+	var events []TransferProgress
+	p := newProgressReader(bytes.NewReader(make([]byte, 95)), func(ev TransferProgress) { events = append(events, ev) }, OperationDownloadFile, -1)
+	readAllForcingEvents(t, p)
+
+	for i, ev := range events {
+		if ev.Total != -1 || ev.Percent() != -1 || ev.ETA != 0 {
+			t.Errorf("event %d = %+v, want an unknown total without percentage or ETA", i, ev)
+		}
+	}
+	if final := events[len(events)-1]; !final.Done || final.Bytes != 95 {
+		t.Errorf("final event = %+v", final)
+	}
+}
+
+func TestProgressReaderFinishBeforeEOF(t *testing.T) {
+	var events []TransferProgress
+	fail := errors.New("connection reset")
+	r := io.MultiReader(bytes.NewReader(make([]byte, 30)), iotest.ErrReader(fail))
+	p := newProgressReader(r, func(ev TransferProgress) { events = append(events, ev) }, OperationUploadPresigned, 100)
+
+	if _, err := io.Copy(io.Discard, p); !errors.Is(err, fail) {
+		t.Fatalf("copy error = %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("events before finish = %+v, want none", events)
+	}
+	p.finish()
+	p.finish()
+	if len(events) != 1 || !events[0].Done || events[0].Bytes != 30 || events[0].Percent() != 30 {
+		t.Errorf("events = %+v, want one final event at 30 bytes", events)
+	}
+}
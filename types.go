@@ -52,6 +52,8 @@ const (
 	OperationParseImageLayout      Operation = "parse image layout"
 	OperationAsyncParseImageLayout Operation = "async parse image layout"
 	OperationGetImageLayoutStatus  Operation = "get image layout status"
+	OperationUploadPresigned       Operation = "upload to presigned URL"
+	OperationDownloadFile          Operation = "download file"
 )
 
 // UploadResponse represents the response from direct PDF upload
@@ -64,6 +66,21 @@ type UploadResponse struct {
 	} `json:"data"`
 }
 
+// UploadMethod is the upload flow chosen by Upload.
+type UploadMethod string
+
+const (
+	UploadMethodDirect    UploadMethod = "direct"    // UploadPDF, for files up to MaxDirectUploadSize
+	UploadMethodPresigned UploadMethod = "presigned" // PreUpload and OSS PUT, up to MaxPresignedUploadSize
+)
+
+// UploadResult describes a PDF uploaded by Upload.
+type UploadResult struct {
+	UID     string
+	TraceID string
+	Method  UploadMethod
+}
+
 // PreUploadResponse represents the response from preupload request
 // Used to obtain presigned URLs for large file uploads
 type PreUploadResponse struct {
@@ -82,22 +99,29 @@ type StatusResponse struct {
 	Code    string `json:"code"`          // Response status code, "success" on successful request
 	Msg     string `json:"msg,omitempty"` // Error message, only present on failure
 	Data    *struct {
-		Progress int         `json:"progress"` // Parsing progress percentage (0-100)
-		Status   ParseStatus `json:"status"`   // Parsing status: "processing", "success", or "failed"
-		Detail   string      `json:"detail"`   // Detailed status description or error message
-		Result   *struct {
-			Version string `json:"version"` // Parser engine version
-			Pages   []struct {
-				URL        string `json:"url"`         // Page preview image URL
-				PageIdx    int    `json:"page_idx"`    // Page index, starting from 0
-				PageWidth  int    `json:"page_width"`  // Page width in pixels
-				PageHeight int    `json:"page_height"` // Page height in pixels
-				Md         string `json:"md"`          // Parsed Markdown content for this page
-			} `json:"pages"`
-		} `json:"result"` // Parsing result, only present on successful parsing
+		Progress int          `json:"progress"` // Parsing progress percentage (0-100)
+		Status   ParseStatus  `json:"status"`   // Parsing status: "processing", "success", or "failed"
+		Detail   string       `json:"detail"`   // Detailed status description or error message
+		Result   *ParseResult `json:"result"`   // Parsing result, only present on successful parsing
 	} `json:"data"`
 }
 
+// ParsePage is a single page of a parse result.
+type ParsePage struct {
+	URL        string `json:"url"`         // Page preview image URL
+	PageIdx    int    `json:"page_idx"`    // Page index, starting from 0
+	PageWidth  int    `json:"page_width"`  // Page width in pixels
+	PageHeight int    `json:"page_height"` // Page height in pixels
+	Md         string `json:"md"`          // Parsed Markdown content for this page
+}
+
+// ParseResult is the parsed document returned once parsing succeeds.
+// It is also the JSON written by the CLI for parse results.
+type ParseResult struct {
+	Version string      `json:"version"` // Parser engine version
+	Pages   []ParsePage `json:"pages"`
+}
+
 // ConvertRequest represents a document conversion request
 type ConvertRequest struct {
 	UID                 string        `json:"uid"`                              // Document unique identifier from upload response
@@ -107,6 +131,15 @@ type ConvertRequest struct {
 	MergeCrossPageForms bool          `json:"merge_cross_page_forms,omitempty"` // Whether to merge tables across pages (optional)
 }
 
+// ConvertOutput is the outcome of one request passed to ConvertAll.
+type ConvertOutput struct {
+	Request ConvertRequest // Request as sent, with UID and default formula mode filled in
+	URL     string         // Download URL of the converted file
+	TraceID string         // Trace ID of the final result poll
+	Err     error          // Failure for this format, nil on success
+	Cached  bool           // Served from a Pipeline cache instead of converted
+}
+
 // ConvertResponse represents the document conversion response
 type ConvertResponse struct {
 	TraceID string `json:"-"`